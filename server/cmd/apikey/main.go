@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/renantatsuo/app-review/server/internal/apikeys"
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/db"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/workspaces"
+)
+
+const usage = `usage:
+  apikey create -name <name> [-scopes admin] [-workspace 1] create a key, printing it once
+  apikey list [-workspace 1]                                list the keys of a workspace as JSON lines, without the keys themselves
+  apikey revoke <id>                                        revoke a key`
+
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	config, err := config.LoadConfigFromEnv()
+	if err != nil {
+		slog.Error("error loading config", "error", err)
+		os.Exit(1)
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	db := db.New(config.DatabaseConnStr).Connect()
+	defer db.Close()
+	apiKeysClient := apikeys.New(slog.Default(), db)
+	workspacesClient := workspaces.New(slog.Default(), db)
+
+	switch os.Args[1] {
+	case "create":
+		err = create(apiKeysClient, workspacesClient, os.Args[2:])
+	case "list":
+		err = list(apiKeysClient, os.Args[2:])
+	case "revoke":
+		err = revoke(apiKeysClient, os.Args[2:])
+	default:
+		err = errUsage
+	}
+
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		slog.Error("error running command", "command", os.Args[1], "error", err)
+		os.Exit(1)
+	}
+}
+
+// create creates a key and prints it, the first admin key of a workspace is minted this way.
+func create(apiKeysClient *apikeys.APIKeysClient, workspacesClient *workspaces.WorkspacesClient, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	name := flags.String("name", "", "the name of the key, to recognize it (required)")
+	rawScopes := flags.String("scopes", string(models.ScopeAdmin), "the comma separated scopes of the key")
+	workspaceID := flags.Int64("workspace", models.DefaultWorkspaceID, "the id of the workspace of the key")
+	if err := flags.Parse(args); err != nil || *name == "" {
+		return errUsage
+	}
+
+	scopes, err := models.ParseScopes(strings.Split(*rawScopes, ","))
+	if err != nil {
+		return err
+	}
+	if _, err := workspacesClient.GetWorkspace(*workspaceID); err != nil {
+		return err
+	}
+
+	apiKey, key, err := apiKeysClient.CreateAPIKey(*workspaceID, *name, scopes)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("api key created, it is not shown again", "id", apiKey.ID, "name", apiKey.Name, "scopes", apiKey.Scopes, "workspaceID", apiKey.WorkspaceID)
+	fmt.Println(key)
+
+	return nil
+}
+
+// list prints every key of the workspace as a JSON line.
+func list(apiKeysClient *apikeys.APIKeysClient, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	workspaceID := flags.Int64("workspace", models.DefaultWorkspaceID, "the id of the workspace to list the keys of")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return errUsage
+	}
+
+	apiKeys, err := apiKeysClient.GetAPIKeys(*workspaceID)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, apiKey := range apiKeys {
+		if err := encoder.Encode(apiKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revoke revokes the key of the given id.
+func revoke(apiKeysClient *apikeys.APIKeysClient, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid id %q: %w", args[0], err)
+	}
+
+	apiKey, err := apiKeysClient.GetAPIKey(id)
+	if err != nil {
+		return err
+	}
+
+	apiKey, err = apiKeysClient.RevokeAPIKey(apiKey.WorkspaceID, id)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("api key revoked", "id", apiKey.ID, "name", apiKey.Name)
+
+	return nil
+}
@@ -7,12 +7,20 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/backfills"
 	"github.com/renantatsuo/app-review/server/internal/config"
 	"github.com/renantatsuo/app-review/server/internal/consumer"
 	"github.com/renantatsuo/app-review/server/internal/db"
+	"github.com/renantatsuo/app-review/server/internal/httpcache"
+	"github.com/renantatsuo/app-review/server/internal/incidents"
 	"github.com/renantatsuo/app-review/server/internal/queue"
 	"github.com/renantatsuo/app-review/server/internal/reviews"
+	"github.com/renantatsuo/app-review/server/internal/sources"
+	"github.com/renantatsuo/app-review/server/internal/webhooks"
+	"github.com/renantatsuo/app-review/server/internal/workspaces"
 	"github.com/renantatsuo/app-review/server/pkg/apple"
+	"github.com/renantatsuo/app-review/server/pkg/googleplay"
 )
 
 func main() {
@@ -26,13 +34,42 @@ func main() {
 		Level: config.LogLevel,
 	})).With(slog.String("service", "consumer"))
 
+	if !db.FTS5Enabled {
+		l.Error("the consumer must be built with the sqlite_fts5 build tag to store reviews")
+		os.Exit(1)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
-	queue := queue.New(config.QueueConnStr)
-	appleClient := apple.New()
+	deadLetter := queue.NewDeadLetter(config.DeadLetterConnStr)
+	webhookQueue := queue.New(config.WebhookQueueConnStr,
+		queue.WithMaxRetries(config.WebhookMaxRetries),
+		queue.WithRetryBackoff(config.WebhookRetryBackoff),
+		queue.WithAckTimeout(config.QueueAckTimeout),
+	)
+	queue := queue.New(config.QueueConnStr,
+		queue.WithMaxRetries(config.QueueMaxRetries),
+		queue.WithRetryBackoff(config.QueueRetryBackoff),
+		queue.WithAckTimeout(config.QueueAckTimeout),
+		queue.WithDeadLetter(deadLetter),
+		queue.WithUnique(config.QueueUnique),
+	)
 	db := db.New(config.DatabaseConnStr).Connect()
-	reviewsClient := reviews.New(l, appleClient, db, config)
-	consumer := consumer.New(l, queue, config, reviewsClient)
+	appleClient := apple.New(
+		apple.WithRateLimit(config.AppleRateLimit, config.AppleRateBurst),
+		apple.WithRetries(config.AppleMaxRetries, config.AppleRetryBackoff, config.AppleMaxRetryWait),
+		apple.WithCache(httpcache.New(db)),
+	)
+	sources := sources.New(sources.NewAppleSource(appleClient), sources.NewGooglePlaySource(googleplay.New()))
+	reviewsClient := reviews.New(l, sources, db, config)
+	webhooksClient := webhooks.New(l, db)
+	dispatcher := webhooks.NewDispatcher(l, webhooksClient, webhookQueue, config)
+	dispatcher.Start(ctx)
+	appsClient := apps.New(db, sources)
+	incidentsClient := incidents.New(l, db)
+	detector := incidents.NewDetector(l, incidentsClient, reviewsClient, appsClient, workspaces.New(l, db), config)
+	backfillsClient := backfills.New(l, db)
+	consumer := consumer.New(l, queue, config, reviewsClient, backfillsClient, detector)
 	consumer.Start(ctx)
 
 	kill := make(chan os.Signal, 1)
@@ -40,8 +77,14 @@ func main() {
 
 	<-kill
 
+	l.Info("received shutdown signal, waiting for in-flight jobs")
+
 	cancel()
+	consumer.Wait()
+	dispatcher.Wait()
 	queue.Close()
+	webhookQueue.Close()
+	deadLetter.Close()
 
 	l.Info("consumer stopped")
 }
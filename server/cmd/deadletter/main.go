@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/queue"
+)
+
+const usage = `usage:
+  deadletter list           list the jobs that exhausted their retries
+  deadletter replay [id...] enqueue the jobs again, all of them if no id is given`
+
+func main() {
+	config, err := config.LoadConfigFromEnv()
+	if err != nil {
+		slog.Error("error loading config", "error", err)
+		os.Exit(1)
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	deadLetter := queue.NewDeadLetter(config.DeadLetterConnStr)
+	defer deadLetter.Close()
+
+	switch os.Args[1] {
+	case "list":
+		err = list(deadLetter)
+	case "replay":
+		err = replay(deadLetter, config, os.Args[2:])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		slog.Error("error running command", "command", os.Args[1], "error", err)
+		os.Exit(1)
+	}
+}
+
+// list prints every dead letter as a JSON line.
+func list(deadLetter *queue.DeadLetter) error {
+	items, err := deadLetter.List()
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replay enqueues the given dead letters back to the queue.
+func replay(deadLetter *queue.DeadLetter, config config.Config, args []string) error {
+	ids := []int64{}
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid id %q: %w", arg, err)
+		}
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		items, err := deadLetter.List()
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+	}
+
+	q := queue.New(config.QueueConnStr, queue.WithUnique(config.QueueUnique))
+	defer q.Close()
+
+	for _, id := range ids {
+		if err := deadLetter.Replay(q, id); err != nil {
+			return fmt.Errorf("error replaying %d: %w", id, err)
+		}
+		slog.Info("replayed job", "id", id)
+	}
+
+	return nil
+}
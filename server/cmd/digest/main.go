@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/db"
+	"github.com/renantatsuo/app-review/server/internal/digest"
+	"github.com/renantatsuo/app-review/server/internal/httpcache"
+	"github.com/renantatsuo/app-review/server/internal/reviews"
+	"github.com/renantatsuo/app-review/server/internal/sources"
+	"github.com/renantatsuo/app-review/server/internal/workspaces"
+	"github.com/renantatsuo/app-review/server/pkg/apple"
+	"github.com/renantatsuo/app-review/server/pkg/googleplay"
+)
+
+func main() {
+	once := flag.Bool("once", false, "send the digests of the last complete window and exit")
+	flag.Parse()
+
+	config, err := config.LoadConfigFromEnv()
+	if err != nil {
+		slog.Error("error loading config", "error", err)
+		os.Exit(1)
+	}
+
+	l := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: config.LogLevel,
+	})).With(slog.String("service", "digest"))
+
+	db := db.New(config.DatabaseConnStr).Connect()
+	defer db.Close()
+	appleClient := apple.New(
+		apple.WithRateLimit(config.AppleRateLimit, config.AppleRateBurst),
+		apple.WithRetries(config.AppleMaxRetries, config.AppleRetryBackoff, config.AppleMaxRetryWait),
+		apple.WithCache(httpcache.New(db)),
+	)
+	sources := sources.New(sources.NewAppleSource(appleClient), sources.NewGooglePlaySource(googleplay.New()))
+	reviewsClient := reviews.New(l, sources, db, config)
+	appsClient := apps.New(db, sources)
+
+	digester := digest.New(l, reviewsClient, appsClient, workspaces.New(l, db), config)
+
+	if *once {
+		if err := digester.SendAll(digester.WindowEnd(time.Now())); err != nil {
+			l.Error("error sending digests", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	digester.Start(ctx)
+
+	kill := make(chan os.Signal, 1)
+	signal.Notify(kill, syscall.SIGINT, syscall.SIGTERM)
+
+	<-kill
+
+	cancel()
+
+	l.Info("digest stopped")
+}
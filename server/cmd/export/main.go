@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/db"
+	"github.com/renantatsuo/app-review/server/internal/export"
+	"github.com/renantatsuo/app-review/server/internal/httpcache"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/reviews"
+	"github.com/renantatsuo/app-review/server/internal/sources"
+	"github.com/renantatsuo/app-review/server/pkg/apple"
+	"github.com/renantatsuo/app-review/server/pkg/googleplay"
+)
+
+func main() {
+	appID := flag.String("app", "", "the id of the app to export the reviews of (required)")
+	workspaceID := flag.Int64("workspace", 0, "only export the reviews of an app the workspace of this id watches (default any workspace)")
+	format := flag.String("format", string(export.FormatCSV), "the export format: csv, ndjson or xlsx")
+	out := flag.String("out", "", `the file to write, "-" for stdout (default reviews-<app>-<date>.<format>)`)
+	params := url.Values{}
+	for _, name := range []string{"country", "min_rating", "max_rating", "since", "until", "author"} {
+		flag.Func(name, "only export the reviews matching this "+name+", as in GET /reviews/{appID}", func(value string) error {
+			params.Set(name, value)
+			return nil
+		})
+	}
+	flag.Parse()
+
+	config, err := config.LoadConfigFromEnv()
+	if err != nil {
+		slog.Error("error loading config", "error", err)
+		os.Exit(1)
+	}
+
+	l := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+		Level: config.LogLevel,
+	})).With(slog.String("service", "export"))
+
+	if *appID == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	exportFormat := export.Format(*format)
+	if !exportFormat.Valid() {
+		l.Error("format must be csv, ndjson or xlsx", "format", *format)
+		os.Exit(2)
+	}
+
+	// unlike the reviews listing, every review is exported when no date range is given
+	query, err := reviews.ParseQuery(*workspaceID, *appID, params, 0)
+	if err != nil {
+		l.Error("error parsing filters", "error", err)
+		os.Exit(2)
+	}
+
+	if *out == "" {
+		*out = fmt.Sprintf("reviews-%s-%s.%s", *appID, time.Now().UTC().Format("20060102"), exportFormat)
+	}
+
+	db := db.New(config.DatabaseConnStr).Connect()
+	appleClient := apple.New(
+		apple.WithRateLimit(config.AppleRateLimit, config.AppleRateBurst),
+		apple.WithRetries(config.AppleMaxRetries, config.AppleRetryBackoff, config.AppleMaxRetryWait),
+		apple.WithCache(httpcache.New(db)),
+	)
+	sources := sources.New(sources.NewAppleSource(appleClient), sources.NewGooglePlaySource(googleplay.New()))
+	defer db.Close()
+	reviewsClient := reviews.New(l, sources, db, config)
+
+	count := 0
+	write := func(w io.Writer) error {
+		writer, err := export.NewWriter(exportFormat, w)
+		if err != nil {
+			return err
+		}
+		err = reviewsClient.EachReview(query, func(review models.Review) error {
+			count++
+			return writer.Write(review)
+		})
+		if err != nil {
+			return err
+		}
+		return writer.Close()
+	}
+
+	if *out == "-" {
+		err = write(os.Stdout)
+	} else {
+		err = writeFile(*out, write)
+	}
+	if err != nil {
+		l.Error("error exporting reviews", "appID", *appID, "error", err)
+		os.Exit(1)
+	}
+
+	l.Info("reviews exported", "appID", *appID, "format", exportFormat, "out", *out, "count", count)
+}
+
+// writeFile writes the file through a temporary file renamed once complete,
+// so a failed export never replaces the previous file a cron job wrote.
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	// temporary files are only readable by their owner
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
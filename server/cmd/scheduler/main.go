@@ -10,9 +10,12 @@ import (
 	"github.com/renantatsuo/app-review/server/internal/apps"
 	"github.com/renantatsuo/app-review/server/internal/config"
 	"github.com/renantatsuo/app-review/server/internal/db"
+	"github.com/renantatsuo/app-review/server/internal/httpcache"
 	"github.com/renantatsuo/app-review/server/internal/queue"
 	"github.com/renantatsuo/app-review/server/internal/scheduler"
+	"github.com/renantatsuo/app-review/server/internal/sources"
 	"github.com/renantatsuo/app-review/server/pkg/apple"
+	"github.com/renantatsuo/app-review/server/pkg/googleplay"
 )
 
 func main() {
@@ -27,9 +30,14 @@ func main() {
 	})).With(slog.String("service", "scheduler"))
 
 	db := db.New(config.DatabaseConnStr).Connect()
-	appleClient := apple.New()
-	appsClient := apps.New(db, appleClient)
-	queue := queue.New(config.QueueConnStr)
+	appleClient := apple.New(
+		apple.WithRateLimit(config.AppleRateLimit, config.AppleRateBurst),
+		apple.WithRetries(config.AppleMaxRetries, config.AppleRetryBackoff, config.AppleMaxRetryWait),
+		apple.WithCache(httpcache.New(db)),
+	)
+	sources := sources.New(sources.NewAppleSource(appleClient), sources.NewGooglePlaySource(googleplay.New()))
+	appsClient := apps.New(db, sources)
+	queue := queue.New(config.QueueConnStr, queue.WithUnique(config.QueueUnique))
 
 	s := scheduler.New(l, appsClient, queue, config)
 
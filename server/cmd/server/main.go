@@ -10,13 +10,22 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/renantatsuo/app-review/server/internal/apikeys"
 	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/backfills"
 	"github.com/renantatsuo/app-review/server/internal/config"
 	"github.com/renantatsuo/app-review/server/internal/db"
+	"github.com/renantatsuo/app-review/server/internal/httpcache"
+	"github.com/renantatsuo/app-review/server/internal/incidents"
 	"github.com/renantatsuo/app-review/server/internal/queue"
 	"github.com/renantatsuo/app-review/server/internal/reviews"
 	"github.com/renantatsuo/app-review/server/internal/server"
+	"github.com/renantatsuo/app-review/server/internal/sources"
+	"github.com/renantatsuo/app-review/server/internal/users"
+	"github.com/renantatsuo/app-review/server/internal/webhooks"
+	"github.com/renantatsuo/app-review/server/internal/workspaces"
 	"github.com/renantatsuo/app-review/server/pkg/apple"
+	"github.com/renantatsuo/app-review/server/pkg/googleplay"
 )
 
 const (
@@ -36,13 +45,34 @@ func main() {
 
 	l.Info("initializing server", "port", config.Port, "logLevel", config.LogLevel)
 
-	appleClient := apple.New()
+	// the reviews_fts triggers also run when the reviews of an app are deleted, not only on search
+	if !db.FTS5Enabled {
+		l.Error("the server must be built with the sqlite_fts5 build tag to search and delete reviews")
+		os.Exit(1)
+	}
+
 	db := db.New(config.DatabaseConnStr).Connect()
-	reviewsClient := reviews.New(l, appleClient, db, config)
-	appsClient := apps.New(db, appleClient)
-	queue := queue.New(config.QueueConnStr)
+	appleClient := apple.New(
+		apple.WithRateLimit(config.AppleRateLimit, config.AppleRateBurst),
+		apple.WithRetries(config.AppleMaxRetries, config.AppleRetryBackoff, config.AppleMaxRetryWait),
+		apple.WithCache(httpcache.New(db)),
+	)
+	sources := sources.New(sources.NewAppleSource(appleClient), sources.NewGooglePlaySource(googleplay.New()))
+	reviewsClient := reviews.New(l, sources, db, config)
+	tailer := reviews.NewTailer(l, reviewsClient, config)
+	appsClient := apps.New(db, sources)
+	webhooksClient := webhooks.New(l, db)
+	incidentsClient := incidents.New(l, db)
+	backfillsClient := backfills.New(l, db)
+	apiKeysClient := apikeys.New(l, db)
+	usersClient := users.New(l, db)
+	workspacesClient := workspaces.New(l, db)
+	queue := queue.New(config.QueueConnStr, queue.WithUnique(config.QueueUnique))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tailer.Start(ctx)
 
-	s := server.New(config.Port, l, reviewsClient, appsClient, queue, config)
+	s := server.New(config.Port, l, reviewsClient, tailer, appsClient, webhooksClient, incidentsClient, backfillsClient, apiKeysClient, usersClient, workspacesClient, queue, config)
 
 	go func() {
 		if err := s.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -58,6 +88,8 @@ func main() {
 
 	l.Info("received shutdown signal, gracefully shutting down server")
 
+	// ends the reviews streams, the server waits for them to shut down
+	cancel()
 	db.Close()
 
 	killCtx, killCancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
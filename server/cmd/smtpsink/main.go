@@ -0,0 +1,94 @@
+// smtpsink is a local SMTP stand-in for testing the email digests.
+// It accepts every email without authentication and prints it to stdout.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/textproto"
+	"os"
+	"strings"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:1025", "address to listen on")
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		slog.Error("error listening", "addr", *addr, "error", err)
+		os.Exit(1)
+	}
+
+	slog.Info("smtp sink listening", "addr", listener.Addr().String())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			slog.Error("error accepting connection", "error", err)
+			continue
+		}
+		go handle(conn)
+	}
+}
+
+// handle speaks just enough SMTP for net/smtp clients to deliver their emails.
+func handle(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	reply := func(format string, args ...any) bool {
+		return tp.PrintfLine(format, args...) == nil
+	}
+
+	if !reply("220 smtpsink ready") {
+		return
+	}
+
+	var from string
+	var to []string
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "HELO", "EHLO":
+			reply("250 smtpsink")
+		case "MAIL":
+			from, to = arg, nil
+			reply("250 OK")
+		case "RCPT":
+			to = append(to, arg)
+			reply("250 OK")
+		case "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			body, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			printEmail(from, to, body)
+			reply("250 OK")
+		case "RSET", "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+func printEmail(from string, to []string, body []byte) {
+	w := bufio.NewWriter(os.Stdout)
+	fmt.Fprintf(w, "----- %s -> %s\n", from, strings.Join(to, ", "))
+	w.Write(body)
+	fmt.Fprintln(w, "-----")
+	w.Flush()
+}
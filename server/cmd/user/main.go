@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/db"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/users"
+	"github.com/renantatsuo/app-review/server/internal/workspaces"
+)
+
+const usage = `usage:
+  user create -username <name> [-role admin] [-workspace 1] create a user, reading the password from the first line of stdin
+  user list [-workspace 1]                                  list the users of a workspace as JSON lines
+  user password <id>                                        set the password of a user from the first line of stdin, logging them out
+  user delete <id>                                          delete a user and log them out`
+
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	config, err := config.LoadConfigFromEnv()
+	if err != nil {
+		slog.Error("error loading config", "error", err)
+		os.Exit(1)
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	db := db.New(config.DatabaseConnStr).Connect()
+	defer db.Close()
+	usersClient := users.New(slog.Default(), db)
+	workspacesClient := workspaces.New(slog.Default(), db)
+
+	switch os.Args[1] {
+	case "create":
+		err = create(usersClient, workspacesClient, os.Args[2:])
+	case "list":
+		err = list(usersClient, os.Args[2:])
+	case "password":
+		err = password(usersClient, os.Args[2:])
+	case "delete":
+		err = remove(usersClient, os.Args[2:])
+	default:
+		err = errUsage
+	}
+
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		slog.Error("error running command", "command", os.Args[1], "error", err)
+		os.Exit(1)
+	}
+}
+
+// create creates a user, the first admin of a workspace is created this way.
+func create(usersClient *users.UsersClient, workspacesClient *workspaces.WorkspacesClient, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	username := flags.String("username", "", "the username to log in with (required)")
+	role := flags.String("role", string(models.RoleAdmin), "the role of the user: viewer, editor or admin")
+	workspaceID := flags.Int64("workspace", models.DefaultWorkspaceID, "the id of the workspace of the user")
+	if err := flags.Parse(args); err != nil || *username == "" {
+		return errUsage
+	}
+
+	if err := users.ValidateUsername(*username); err != nil {
+		return err
+	}
+	if !models.Role(*role).Valid() {
+		return fmt.Errorf("invalid role %q, must be viewer, editor or admin", *role)
+	}
+	if _, err := workspacesClient.GetWorkspace(*workspaceID); err != nil {
+		return err
+	}
+
+	pass, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	user, err := usersClient.CreateUser(*workspaceID, *username, pass, models.Role(*role))
+	if err != nil {
+		return err
+	}
+
+	slog.Info("user created", "id", user.ID, "username", user.Username, "role", user.Role, "workspaceID", user.WorkspaceID)
+
+	return nil
+}
+
+// list prints every user of the workspace as a JSON line.
+func list(usersClient *users.UsersClient, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	workspaceID := flags.Int64("workspace", models.DefaultWorkspaceID, "the id of the workspace to list the users of")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return errUsage
+	}
+
+	users, err := usersClient.GetUsers(*workspaceID)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, user := range users {
+		if err := encoder.Encode(user); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// password sets the password of the user of the given id.
+func password(usersClient *users.UsersClient, args []string) error {
+	id, err := parseID(args)
+	if err != nil {
+		return err
+	}
+
+	user, err := usersClient.GetUser(id)
+	if err != nil {
+		return err
+	}
+
+	pass, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	user, err = usersClient.UpdateUser(user.WorkspaceID, id, users.Update{Password: &pass})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("user password changed", "id", user.ID, "username", user.Username)
+
+	return nil
+}
+
+// remove deletes the user of the given id.
+func remove(usersClient *users.UsersClient, args []string) error {
+	id, err := parseID(args)
+	if err != nil {
+		return err
+	}
+
+	user, err := usersClient.GetUser(id)
+	if err != nil {
+		return err
+	}
+
+	if err := usersClient.DeleteUser(user.WorkspaceID, id); err != nil {
+		return err
+	}
+
+	slog.Info("user deleted", "id", id)
+
+	return nil
+}
+
+// parseID parses the only argument as a user id.
+func parseID(args []string) (int64, error) {
+	if len(args) != 1 {
+		return 0, errUsage
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q: %w", args[0], err)
+	}
+	return id, nil
+}
+
+// readPassword reads the password from the first line of stdin, so it is not in the shell history.
+func readPassword() (string, error) {
+	fmt.Fprint(os.Stderr, "password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("error reading the password: %w", err)
+	}
+	pass := strings.TrimRight(line, "\r\n")
+	if err := users.ValidatePassword(pass); err != nil {
+		return "", err
+	}
+	return pass, nil
+}
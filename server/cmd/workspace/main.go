@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/db"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/workspaces"
+)
+
+const usage = `usage:
+  workspace create -name <name> create a workspace, its first admin is created with the user or apikey command
+  workspace list                list the workspaces as JSON lines
+  workspace notify -id <id> [-digest_slack_webhook_url <url>] [-digest_email_to <emails>]
+                   [-incident_slack_webhook_url <url>] [-incident_email_to <emails>]
+                                set where the digests and the incidents of the workspace apps are sent,
+                                the targets left out are cleared`
+
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	config, err := config.LoadConfigFromEnv()
+	if err != nil {
+		slog.Error("error loading config", "error", err)
+		os.Exit(1)
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	db := db.New(config.DatabaseConnStr).Connect()
+	defer db.Close()
+	workspacesClient := workspaces.New(slog.Default(), db)
+
+	switch os.Args[1] {
+	case "create":
+		err = create(workspacesClient, os.Args[2:])
+	case "list":
+		err = list(workspacesClient)
+	case "notify":
+		err = setNotifications(workspacesClient, os.Args[2:])
+	default:
+		err = errUsage
+	}
+
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		slog.Error("error running command", "command", os.Args[1], "error", err)
+		os.Exit(1)
+	}
+}
+
+// create creates an empty workspace.
+func create(workspacesClient *workspaces.WorkspacesClient, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	name := flags.String("name", "", "the name of the workspace, usually the team or client (required)")
+	if err := flags.Parse(args); err != nil || *name == "" {
+		return errUsage
+	}
+
+	workspace, err := workspacesClient.CreateWorkspace(*name)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("workspace created", "id", workspace.ID, "name", workspace.Name)
+
+	return nil
+}
+
+// list prints every workspace as a JSON line, with its notification targets.
+func list(workspacesClient *workspaces.WorkspacesClient) error {
+	workspaces, err := workspacesClient.GetWorkspaces()
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, workspace := range workspaces {
+		// the notification targets are only printed here, the responses leave them out
+		line := struct {
+			models.Workspace
+			Digest   models.NotificationTargets `json:"digest"`
+			Incident models.NotificationTargets `json:"incident"`
+		}{workspace, workspace.Digest, workspace.Incident}
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setNotifications sets the notification targets of the workspace.
+func setNotifications(workspacesClient *workspaces.WorkspacesClient, args []string) error {
+	flags := flag.NewFlagSet("notify", flag.ContinueOnError)
+	id := flags.Int64("id", 0, "the id of the workspace (required)")
+	digestSlackWebhookURL := flags.String("digest_slack_webhook_url", "", "the Slack compatible incoming webhook the digests are posted to")
+	digestEmailTo := flags.String("digest_email_to", "", "the comma separated emails the digests are sent to")
+	incidentSlackWebhookURL := flags.String("incident_slack_webhook_url", "", "the Slack compatible incoming webhook the incidents are posted to")
+	incidentEmailTo := flags.String("incident_email_to", "", "the comma separated emails the incidents are sent to")
+	if err := flags.Parse(args); err != nil || *id == 0 {
+		return errUsage
+	}
+
+	digest := models.NotificationTargets{SlackWebhookURL: *digestSlackWebhookURL, EmailTo: splitEmails(*digestEmailTo)}
+	incident := models.NotificationTargets{SlackWebhookURL: *incidentSlackWebhookURL, EmailTo: splitEmails(*incidentEmailTo)}
+	if err := workspacesClient.SetNotifications(*id, digest, incident); err != nil {
+		return err
+	}
+
+	slog.Info("workspace notifications set", "id", *id)
+
+	return nil
+}
+
+// splitEmails splits the comma separated emails, skipping the empty ones.
+func splitEmails(emails string) []string {
+	res := []string{}
+	for _, email := range strings.Split(emails, ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			res = append(res, email)
+		}
+	}
+	return res
+}
@@ -6,4 +6,7 @@ require (
 	github.com/mattdeak/gopq v0.2.1
 	github.com/renantatsuo/envv v0.3.0
 	github.com/mattn/go-sqlite3 v1.14.22
+	golang.org/x/crypto v0.43.0
 )
+
+require golang.org/x/sys v0.37.0 // indirect
@@ -0,0 +1,19 @@
+package apikeys
+
+import (
+	"database/sql"
+	"log/slog"
+)
+
+// APIKeysClient is the client for the API keys authenticating the requests to the server.
+type APIKeysClient struct {
+	logger *slog.Logger
+	db     *sql.DB
+}
+
+func New(logger *slog.Logger, db *sql.DB) *APIKeysClient {
+	return &APIKeysClient{
+		logger: logger,
+		db:     db,
+	}
+}
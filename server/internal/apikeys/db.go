@@ -0,0 +1,186 @@
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+const (
+	apiKeyColumns = "id, workspace_id, name, prefix, scopes, created_at, last_used_at, revoked_at"
+
+	// keyPrefix starts every key, so they are easy to recognize in a config or a leak scanner.
+	keyPrefix = "ar_"
+	// keyBytes is the number of random bytes of a key.
+	keyBytes = 24
+	// prefixLength is the length of the stored start of the key.
+	prefixLength = len(keyPrefix) + 8
+
+	scopesSeparator = ","
+
+	// lastUsedPrecision is how old the recorded last use of a key must be to record a new one,
+	// so a busy key does not write to the database on every request.
+	lastUsedPrecision = time.Minute
+)
+
+// ErrInvalidAPIKey is returned when a key does not exist or was revoked.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// ErrAPIKeyNotFound is an error type for when an API key is not found.
+type ErrAPIKeyNotFound struct {
+	ID int64
+}
+
+func (e ErrAPIKeyNotFound) Error() string {
+	return fmt.Sprintf("api key not found: %d", e.ID)
+}
+
+// CreateAPIKey creates a key of the workspace with the given scopes,
+// it returns the key, which is not stored and cannot be retrieved again.
+func (a *APIKeysClient) CreateAPIKey(workspaceID int64, name string, scopes []models.Scope) (models.APIKey, string, error) {
+	random := make([]byte, keyBytes)
+	if _, err := rand.Read(random); err != nil {
+		return models.APIKey{}, "", err
+	}
+	key := keyPrefix + hex.EncodeToString(random)
+
+	rawScopes := make([]string, len(scopes))
+	for i, scope := range scopes {
+		rawScopes[i] = string(scope)
+	}
+
+	res, err := a.db.Exec("INSERT INTO api_keys (workspace_id, name, prefix, key_hash, scopes, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		workspaceID, name, key[:prefixLength], hashKey(key), strings.Join(rawScopes, scopesSeparator), time.Now().UTC())
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+
+	apiKey, err := a.GetAPIKey(id)
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+
+	return apiKey, key, nil
+}
+
+// Authenticate returns the API key of the given key, and records that it was used, to the minute.
+// It returns ErrInvalidAPIKey if the key does not exist or was revoked.
+func (a *APIKeysClient) Authenticate(key string) (models.APIKey, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+
+	// the lookup is by hash, so the comparison does not leak the stored keys through timing
+	row := a.db.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = ? AND revoked_at IS NULL", hashKey(key))
+	apiKey, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	now := time.Now().UTC()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedPrecision {
+		// conditional, so the concurrent requests of the key record its use once
+		_, err := a.db.Exec("UPDATE api_keys SET last_used_at = ? WHERE id = ? AND (last_used_at IS NULL OR last_used_at <= ?)",
+			now, apiKey.ID, now.Add(-lastUsedPrecision))
+		if err != nil {
+			a.logger.Error("error recording api key use", "id", apiKey.ID, "error", err)
+		}
+	}
+
+	return apiKey, nil
+}
+
+// GetAPIKey returns the API key.
+func (a *APIKeysClient) GetAPIKey(id int64) (models.APIKey, error) {
+	row := a.db.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?", id)
+	apiKey, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.APIKey{}, ErrAPIKeyNotFound{ID: id}
+	}
+	return apiKey, err
+}
+
+// GetAPIKeys returns every API key of the workspace, including the revoked ones.
+func (a *APIKeysClient) GetAPIKeys(workspaceID int64) ([]models.APIKey, error) {
+	apiKeys := []models.APIKey{}
+
+	rows, err := a.db.Query("SELECT "+apiKeyColumns+" FROM api_keys WHERE workspace_id = ? ORDER BY id", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		apiKey, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	return apiKeys, rows.Err()
+}
+
+// RevokeAPIKey revokes the API key of the workspace, it no longer authenticates any request.
+// Revoking a revoked key keeps its first revocation time.
+func (a *APIKeysClient) RevokeAPIKey(workspaceID int64, id int64) (models.APIKey, error) {
+	res, err := a.db.Exec("UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ? AND workspace_id = ?", time.Now().UTC(), id, workspaceID)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	if affected == 0 {
+		return models.APIKey{}, ErrAPIKeyNotFound{ID: id}
+	}
+
+	return a.GetAPIKey(id)
+}
+
+// hashKey returns the hex SHA-256 of the key.
+// The keys are random, so unlike passwords they do not need a slow hash.
+func hashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func scanAPIKey(row interface{ Scan(...any) error }) (models.APIKey, error) {
+	var apiKey models.APIKey
+	var scopes string
+	var lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(&apiKey.ID, &apiKey.WorkspaceID, &apiKey.Name, &apiKey.Prefix, &scopes, &apiKey.CreatedAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	for _, scope := range strings.Split(scopes, scopesSeparator) {
+		apiKey.Scopes = append(apiKey.Scopes, models.Scope(scope))
+	}
+	if lastUsedAt.Valid {
+		apiKey.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		apiKey.RevokedAt = &revokedAt.Time
+	}
+
+	return apiKey, nil
+}
@@ -3,14 +3,14 @@ package apps
 import (
 	"database/sql"
 
-	"github.com/renantatsuo/app-review/server/pkg/apple"
+	"github.com/renantatsuo/app-review/server/internal/sources"
 )
 
 type AppsClient struct {
-	db          *sql.DB
-	appleClient *apple.AppleClient
+	db      *sql.DB
+	sources sources.Sources
 }
 
-func New(db *sql.DB, appleClient *apple.AppleClient) *AppsClient {
-	return &AppsClient{db: db, appleClient: appleClient}
+func New(db *sql.DB, sources sources.Sources) *AppsClient {
+	return &AppsClient{db: db, sources: sources}
 }
@@ -1,34 +1,372 @@
 package apps
 
-import "github.com/renantatsuo/app-review/server/internal/models"
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
 
-// AddApp adds a new app to the database.
-func (a *AppsClient) AddApp(app models.App) error {
-	_, err := a.db.Exec("INSERT INTO apps (id, name, thumbnail_url) VALUES (?, ?, ?)", app.ID, app.Name, app.ThumbnailURL)
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+const (
+	// countriesSeparator separates the country storefronts stored in the countries column.
+	countriesSeparator = ","
+
+	// appColumns are the columns of an app watched by a workspace, selected from watchedApps.
+	appColumns = "a.id, w.workspace_id, a.platform, a.name, a.thumbnail_url, w.countries, w.status, w.polling_interval, w.adaptive_polling," +
+		" a.next_poll_at, a.last_polled_at, w.added_by, w.status_updated_by, w.created_at, w.updated_at"
+	// watchedApps joins the apps watched by the workspaces with their store data and polling schedule.
+	watchedApps = "workspace_apps w JOIN apps a ON a.id = w.app_id"
+)
+
+// Update holds the app fields to update, nil fields are left unchanged.
+type Update struct {
+	Countries []string
+	// PollingInterval of zero resets the app to the default interval.
+	PollingInterval *time.Duration
+	AdaptivePolling *bool
+}
+
+// AddApp adds the app to the workspace.
+// The store data of an app another workspace already watches is refreshed, and its reviews are shared.
+func (a *AppsClient) AddApp(workspaceID int64, app models.App) error {
+	tx, err := a.db.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO apps (id, platform, name, thumbnail_url) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, thumbnail_url = excluded.thumbnail_url, updated_at = CURRENT_TIMESTAMP`,
+		app.ID, app.Platform, app.Name, app.ThumbnailURL)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO workspace_apps (workspace_id, app_id, countries, added_by) VALUES (?, ?, ?, ?)",
+		workspaceID, app.ID, strings.Join(app.Countries, countriesSeparator), app.AddedBy)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetApp returns the app watched by the workspace.
+func (a *AppsClient) GetApp(workspaceID int64, appID string) (models.App, error) {
+	row := a.db.QueryRow("SELECT "+appColumns+" FROM "+watchedApps+" WHERE w.workspace_id = ? AND w.app_id = ?", workspaceID, appID)
+
+	app, err := scanApp(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.App{}, ErrAppNotFound{AppID: appID}
+	}
+
+	return app, err
+}
+
+// GetAllApps returns all the apps watched by the workspace.
+// If status is not empty only the apps with that status are returned.
+func (a *AppsClient) GetAllApps(workspaceID int64, status models.AppStatus) ([]models.App, error) {
+	return a.queryApps("SELECT "+appColumns+" FROM "+watchedApps+" WHERE w.workspace_id = ? AND (? = '' OR w.status = ?) ORDER BY w.created_at, a.id",
+		workspaceID, status, status)
 }
 
-// GetAllApps returns all the app IDs from the database.
-func (a *AppsClient) GetAllApps() ([]models.App, error) {
+// GetAppName returns the store name of the app, whichever workspaces watch it.
+func (a *AppsClient) GetAppName(appID string) (string, error) {
+	var name string
+	err := a.db.QueryRow("SELECT name FROM apps WHERE id = ?", appID).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrAppNotFound{AppID: appID}
+	}
+	return name, err
+}
+
+// GetDueApps returns the apps that are due to be polled at the given time for the workspaces watching them,
+// each app once with the settings of all its active watchers merged, see mergeWatchers.
+// The watchers without a polling interval use the given default.
+func (a *AppsClient) GetDueApps(now time.Time, defaultInterval time.Duration) ([]models.App, error) {
+	watchers, err := a.queryApps("SELECT "+appColumns+" FROM "+watchedApps+
+		" WHERE w.status = ? AND (a.next_poll_at IS NULL OR a.next_poll_at <= ?) ORDER BY a.id, w.workspace_id",
+		models.AppStatusActive, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeWatchers(watchers, defaultInterval), nil
+}
+
+// GetPolledApps returns every app an active workspace watches, each app once as in GetDueApps.
+func (a *AppsClient) GetPolledApps(defaultInterval time.Duration) ([]models.App, error) {
+	watchers, err := a.queryApps("SELECT "+appColumns+" FROM "+watchedApps+" WHERE w.status = ? ORDER BY a.id, w.workspace_id",
+		models.AppStatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeWatchers(watchers, defaultInterval), nil
+}
+
+// UpdateApp updates the non nil fields of the update of the app watched by the workspace.
+// Changing the polling interval makes the app due right away.
+func (a *AppsClient) UpdateApp(workspaceID int64, appID string, update Update) error {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sets := []string{"updated_at = CURRENT_TIMESTAMP"}
+	args := []any{}
+
+	if update.Countries != nil {
+		sets = append(sets, "countries = ?")
+		args = append(args, strings.Join(update.Countries, countriesSeparator))
+	}
+	if update.PollingInterval != nil {
+		sets = append(sets, "polling_interval = ?")
+		args = append(args, intervalToSeconds(*update.PollingInterval))
+	}
+	if update.AdaptivePolling != nil {
+		sets = append(sets, "adaptive_polling = ?")
+		args = append(args, *update.AdaptivePolling)
+	}
+
+	res, err := tx.Exec("UPDATE workspace_apps SET "+strings.Join(sets, ", ")+" WHERE workspace_id = ? AND app_id = ?",
+		append(args, workspaceID, appID)...)
+	if err != nil {
+		return err
+	}
+	if err := checkAppUpdated(res, appID); err != nil {
+		return err
+	}
+
+	if update.PollingInterval != nil {
+		if _, err := tx.Exec("UPDATE apps SET next_poll_at = NULL WHERE id = ?", appID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SchedulePoll records that the app was polled at the given time and when it is due again, for every workspace watching it.
+// If interval is not nil it also becomes the polling interval of the active adaptive watchers.
+func (a *AppsClient) SchedulePoll(appID string, polledAt time.Time, nextPollAt time.Time, interval *time.Duration) error {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE apps SET last_polled_at = ?, next_poll_at = ? WHERE id = ?", polledAt.UTC(), nextPollAt.UTC(), appID)
+	if err != nil {
+		return err
+	}
+	if err := checkAppUpdated(res, appID); err != nil {
+		return err
+	}
+
+	if interval != nil {
+		_, err := tx.Exec("UPDATE workspace_apps SET polling_interval = ? WHERE app_id = ? AND status = ? AND adaptive_polling",
+			intervalToSeconds(*interval), appID, models.AppStatusActive)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeferPoll sets when the app is due again, without recording a poll,
+// for an app that was due while its previous poll was still in the queue.
+func (a *AppsClient) DeferPoll(appID string, nextPollAt time.Time) error {
+	res, err := a.db.Exec("UPDATE apps SET next_poll_at = ? WHERE id = ?", nextPollAt.UTC(), appID)
+	if err != nil {
+		return err
+	}
+
+	return checkAppUpdated(res, appID)
+}
+
+// CountReviewsSince counts the reviews of the app stored since the given time.
+func (a *AppsClient) CountReviewsSince(appID string, since time.Time) (int, error) {
+	var count int
+	// created_at is set by SQLite as an UTC "2006-01-02 15:04:05" string
+	err := a.db.QueryRow("SELECT COUNT(*) FROM reviews WHERE app_id = ? AND created_at >= ?",
+		appID, since.UTC().Format(time.DateTime)).Scan(&count)
+	return count, err
+}
+
+// SetAppStatus sets the monitoring status of the app watched by the workspace, by is the user or API key changing it.
+// The app is polled as long as one workspace watching it is active.
+func (a *AppsClient) SetAppStatus(workspaceID int64, appID string, status models.AppStatus, by string) error {
+	res, err := a.db.Exec("UPDATE workspace_apps SET status = ?, status_updated_by = ?, updated_at = CURRENT_TIMESTAMP WHERE workspace_id = ? AND app_id = ?",
+		status, by, workspaceID, appID)
+	if err != nil {
+		return err
+	}
+
+	return checkAppUpdated(res, appID)
+}
+
+// DeleteApp removes the app from the workspace, along with the webhook subscriptions of the workspace to its reviews and their triage.
+// Once no workspace watches the app anymore, it also deletes the app, all its reviews, review revisions, stats, review events, webhook outbox, sync state,
+// cached feed validators, backfills and incidents, and the remaining webhook subscriptions to its reviews.
+func (a *AppsClient) DeleteApp(workspaceID int64, appID string) error {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM webhook_deliveries WHERE subscription_id IN (SELECT id FROM webhook_subscriptions WHERE workspace_id = ? AND app_id = ?)", workspaceID, appID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM webhook_subscriptions WHERE workspace_id = ? AND app_id = ?", workspaceID, appID); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("DELETE FROM workspace_apps WHERE workspace_id = ? AND app_id = ?", workspaceID, appID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAppUpdated(res, appID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM review_triage WHERE workspace_id = ? AND review_id IN (SELECT id FROM reviews WHERE app_id = ?)", workspaceID, appID); err != nil {
+		return err
+	}
+
+	var watchers int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM workspace_apps WHERE app_id = ?", appID).Scan(&watchers); err != nil {
+		return err
+	}
+	if watchers > 0 {
+		return tx.Commit()
+	}
+
+	if _, err := tx.Exec("DELETE FROM webhook_deliveries WHERE subscription_id IN (SELECT id FROM webhook_subscriptions WHERE app_id = ?)", appID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM webhook_subscriptions WHERE app_id = ?", appID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM review_revisions WHERE app_id = ?", appID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM review_daily_stats WHERE app_id = ?", appID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM review_events WHERE app_id = ?", appID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM webhook_outbox WHERE app_id = ?", appID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM sync_state WHERE app_id = ?", appID); err != nil {
+		return err
+	}
+
+	// the cached validators of the reviews feeds of the app, so a re-added app fetches its reviews again.
+	// instr matches the app ID literally, its _ and % would be wildcards of LIKE
+	if _, err := tx.Exec("DELETE FROM http_cache WHERE instr(url, ?) > 0", "/id="+appID+"/"); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM backfills WHERE app_id = ?", appID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM incidents WHERE app_id = ?", appID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM reviews WHERE app_id = ?", appID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM apps WHERE id = ?", appID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// queryApps returns the apps selected with appColumns.
+func (a *AppsClient) queryApps(query string, args ...any) ([]models.App, error) {
 	apps := []models.App{}
 
-	rows, err := a.db.Query("SELECT * FROM apps")
+	rows, err := a.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var app models.App
-		err := rows.Scan(&app.ID, &app.Name, &app.ThumbnailURL, &app.CreatedAt, &app.UpdatedAt)
+		app, err := scanApp(rows)
 		if err != nil {
 			return nil, err
 		}
 		apps = append(apps, app)
 	}
 
-	return apps, nil
+	return apps, rows.Err()
+}
+
+// scanApp scans an app selected with appColumns.
+func scanApp(row interface{ Scan(dest ...any) error }) (models.App, error) {
+	var app models.App
+	var countries string
+	var pollingInterval sql.NullInt64
+	var nextPollAt, lastPolledAt sql.NullTime
+	err := row.Scan(&app.ID, &app.WorkspaceID, &app.Platform, &app.Name, &app.ThumbnailURL, &countries, &app.Status,
+		&pollingInterval, &app.AdaptivePolling, &nextPollAt, &lastPolledAt,
+		&app.AddedBy, &app.StatusUpdatedBy, &app.CreatedAt, &app.UpdatedAt)
+	if err != nil {
+		return models.App{}, err
+	}
+
+	app.Countries = strings.Split(countries, countriesSeparator)
+	if pollingInterval.Valid {
+		interval := models.Duration(time.Duration(pollingInterval.Int64) * time.Second)
+		app.PollingInterval = &interval
+	}
+	if nextPollAt.Valid {
+		app.NextPollAt = &nextPollAt.Time
+	}
+	if lastPolledAt.Valid {
+		app.LastPolledAt = &lastPolledAt.Time
+	}
+
+	return app, nil
+}
+
+// intervalToSeconds converts the interval to the polling_interval column, NULL if zero.
+func intervalToSeconds(interval time.Duration) sql.NullInt64 {
+	seconds := int64(interval / time.Second)
+	return sql.NullInt64{Int64: seconds, Valid: seconds > 0}
+}
+
+// checkAppUpdated returns ErrAppNotFound if no app was affected by the statement.
+func checkAppUpdated(res sql.Result, appID string) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrAppNotFound{AppID: appID}
+	}
+
+	return nil
 }
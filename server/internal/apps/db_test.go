@@ -0,0 +1,54 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/renantatsuo/app-review/server/internal/dbtest"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/sources"
+)
+
+// feedURL returns the URL of the reviews feed of the app.
+func feedURL(appID string) string {
+	return "https://itunes.apple.com/us/rss/customerreviews/id=" + appID + "/sortBy=mostRecent/json"
+}
+
+func TestDeleteAppOnlyDeletesItsCachedFeeds(t *testing.T) {
+	db := dbtest.New(t)
+	appsClient := New(db, sources.New())
+
+	// the _ of the deleted app ID would match any character with LIKE
+	deleted, kept := "com.example_app", "com.exampleXapp"
+	for _, appID := range []string{deleted, kept} {
+		app := models.App{ID: appID, Platform: models.PlatformIOS, Name: appID, Countries: []string{"us"}}
+		if err := appsClient.AddApp(models.DefaultWorkspaceID, app); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec("INSERT INTO http_cache (url, etag) VALUES (?, ?)", feedURL(appID), "etag"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := appsClient.DeleteApp(models.DefaultWorkspaceID, deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		appID string
+		want  int
+	}{
+		{deleted, 0},
+		{kept, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.appID, func(t *testing.T) {
+			var count int
+			if err := db.QueryRow("SELECT COUNT(*) FROM http_cache WHERE url = ?", feedURL(tt.appID)).Scan(&count); err != nil {
+				t.Fatal(err)
+			}
+			if count != tt.want {
+				t.Errorf("got %d cached feeds, want %d", count, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package apps
+
+import (
+	"slices"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+const (
+	// busyPollReviews is how many new reviews make an adaptive app be polled more often.
+	busyPollReviews = 10
+	// adaptiveFactor is how much an adaptive app polling interval changes after a poll.
+	adaptiveFactor = 2
+)
+
+// AdaptInterval returns the polling interval of an adaptive app after a poll that found newReviews.
+// Busy apps are polled twice as often and apps without new reviews half as often,
+// within the given bounds.
+func AdaptInterval(interval time.Duration, newReviews int, minInterval, maxInterval time.Duration) time.Duration {
+	switch {
+	case newReviews >= busyPollReviews:
+		interval /= adaptiveFactor
+	case newReviews == 0:
+		interval *= adaptiveFactor
+	}
+
+	return min(max(interval, minInterval), maxInterval)
+}
+
+// mergeWatchers merges the watchers of each app, sorted by app, into the app polled for all of them:
+// the union of their countries, their shortest polling interval, and adaptive polling only if they all have it,
+// so no workspace has its app polled less often or on fewer storefronts than it asked.
+func mergeWatchers(watchers []models.App, defaultInterval time.Duration) []models.App {
+	merged := []models.App{}
+
+	for _, watcher := range watchers {
+		interval := models.Duration(watcher.Interval(defaultInterval))
+
+		if len(merged) == 0 || merged[len(merged)-1].ID != watcher.ID {
+			app := watcher
+			app.WorkspaceID = 0
+			app.Countries = slices.Clone(watcher.Countries)
+			app.PollingInterval = &interval
+			app.AddedBy, app.StatusUpdatedBy = "", ""
+			merged = append(merged, app)
+			continue
+		}
+
+		app := &merged[len(merged)-1]
+		for _, country := range watcher.Countries {
+			if !slices.Contains(app.Countries, country) {
+				app.Countries = append(app.Countries, country)
+			}
+		}
+		if interval < *app.PollingInterval {
+			app.PollingInterval = &interval
+		}
+		app.AdaptivePolling = app.AdaptivePolling && watcher.AdaptivePolling
+	}
+
+	return merged
+}
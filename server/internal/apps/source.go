@@ -0,0 +1,84 @@
+package apps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/sources"
+)
+
+// ErrSearchUnsupported is returned when the apps of the platform cannot be searched or looked up by bundle ID.
+var ErrSearchUnsupported = errors.New("search is not supported on this platform")
+
+// ErrAppNotFound is an error type for when an app is not found.
+type ErrAppNotFound struct {
+	AppID string
+}
+
+func (e ErrAppNotFound) Error() string {
+	return fmt.Sprintf("app not found: %s", e.AppID)
+}
+
+// GetAppData gets the app data from the store of the platform.
+func (a *AppsClient) GetAppData(ctx context.Context, platform models.Platform, appID string) (models.App, error) {
+	source, err := a.sources.Get(platform)
+	if err != nil {
+		return models.App{}, err
+	}
+
+	app, err := source.LookupApp(ctx, appID)
+	if err != nil {
+		if errors.Is(err, sources.ErrAppNotFound) {
+			return models.App{}, ErrAppNotFound{AppID: appID}
+		}
+		return models.App{}, err
+	}
+
+	return app, nil
+}
+
+// GetAppDataByBundleID gets the app data of the bundle ID from the store of the platform.
+// The app ID of the returned app is its store ID.
+func (a *AppsClient) GetAppDataByBundleID(ctx context.Context, platform models.Platform, bundleID string) (models.App, error) {
+	searcher, err := a.searcher(platform)
+	if err != nil {
+		return models.App{}, err
+	}
+
+	app, err := searcher.LookupAppByBundleID(ctx, bundleID)
+	if err != nil {
+		if errors.Is(err, sources.ErrAppNotFound) {
+			return models.App{}, ErrAppNotFound{AppID: bundleID}
+		}
+		return models.App{}, err
+	}
+
+	return app, nil
+}
+
+// SearchApps searches the store of the platform for at most limit apps of the country storefront matching the term.
+func (a *AppsClient) SearchApps(ctx context.Context, platform models.Platform, term string, country string, limit int) ([]models.AppSearchResult, error) {
+	searcher, err := a.searcher(platform)
+	if err != nil {
+		return nil, err
+	}
+
+	return searcher.SearchApps(ctx, term, country, limit)
+}
+
+// searcher returns the source of the platform if its apps can be searched.
+func (a *AppsClient) searcher(platform models.Platform) (sources.Searcher, error) {
+	source, err := a.sources.Get(platform)
+	if err != nil {
+		return nil, err
+	}
+
+	searcher, ok := source.(sources.Searcher)
+	if !ok {
+		return nil, ErrSearchUnsupported
+	}
+
+	return searcher, nil
+}
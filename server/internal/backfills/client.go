@@ -0,0 +1,19 @@
+package backfills
+
+import (
+	"database/sql"
+	"log/slog"
+)
+
+// BackfillsClient is the client for the progress of the reviews backfills.
+type BackfillsClient struct {
+	logger *slog.Logger
+	db     *sql.DB
+}
+
+func New(logger *slog.Logger, db *sql.DB) *BackfillsClient {
+	return &BackfillsClient{
+		logger: logger,
+		db:     db,
+	}
+}
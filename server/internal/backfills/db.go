@@ -0,0 +1,131 @@
+package backfills
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// backfillColumns are the columns scanned by scanBackfill.
+const backfillColumns = "app_id, country, status, page, created, error, requested_at, updated_at, completed_at"
+
+// ErrBackfillNotFound is returned when the app storefront was never backfilled.
+type ErrBackfillNotFound struct {
+	AppID   string
+	Country string
+}
+
+func (e ErrBackfillNotFound) Error() string {
+	return fmt.Sprintf("backfill of app %s on storefront %s not found", e.AppID, e.Country)
+}
+
+// StartBackfill requests the backfill of the app on the given country storefronts and returns them.
+// A completed backfill starts again from the first page and a failed one resumes from its last stored page,
+// while a pending, running or retrying backfill is left as is.
+func (b *BackfillsClient) StartBackfill(appID string, countries []string) ([]models.Backfill, error) {
+	now := time.Now().UTC()
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, country := range countries {
+		_, err := tx.Exec(`INSERT INTO backfills (app_id, country, status, requested_at, updated_at) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (app_id, country) DO UPDATE SET
+				page = CASE WHEN status = ? THEN 0 ELSE page END,
+				created = CASE WHEN status = ? THEN 0 ELSE created END,
+				requested_at = CASE WHEN status = ? THEN excluded.requested_at ELSE requested_at END,
+				status = excluded.status, error = '', updated_at = excluded.updated_at, completed_at = NULL
+			WHERE status IN (?, ?)`,
+			appID, country, models.BackfillPending, now, now,
+			models.BackfillCompleted, models.BackfillCompleted, models.BackfillCompleted,
+			models.BackfillCompleted, models.BackfillFailed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return b.GetBackfills(appID)
+}
+
+// GetBackfills returns the backfills of every storefront of the app.
+func (b *BackfillsClient) GetBackfills(appID string) ([]models.Backfill, error) {
+	backfills := []models.Backfill{}
+
+	rows, err := b.db.Query("SELECT "+backfillColumns+" FROM backfills WHERE app_id = ? ORDER BY country", appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		backfill, err := scanBackfill(rows)
+		if err != nil {
+			return nil, err
+		}
+		backfills = append(backfills, backfill)
+	}
+
+	return backfills, rows.Err()
+}
+
+// GetBackfill returns the backfill of the app on the country storefront.
+func (b *BackfillsClient) GetBackfill(appID string, country string) (models.Backfill, error) {
+	row := b.db.QueryRow("SELECT "+backfillColumns+" FROM backfills WHERE app_id = ? AND country = ?", appID, country)
+	backfill, err := scanBackfill(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Backfill{}, ErrBackfillNotFound{AppID: appID, Country: country}
+	}
+	return backfill, err
+}
+
+// SavePage records that the page of the backfill is stored, with the number of reviews it created.
+func (b *BackfillsClient) SavePage(appID string, country string, page int, created int) error {
+	_, err := b.db.Exec("UPDATE backfills SET status = ?, page = ?, created = created + ?, error = '', updated_at = ? WHERE app_id = ? AND country = ?",
+		models.BackfillRunning, page, created, time.Now().UTC(), appID, country)
+	return err
+}
+
+// CompleteBackfill marks the backfill as completed.
+func (b *BackfillsClient) CompleteBackfill(appID string, country string) error {
+	now := time.Now().UTC()
+	_, err := b.db.Exec("UPDATE backfills SET status = ?, error = '', updated_at = ?, completed_at = ? WHERE app_id = ? AND country = ?",
+		models.BackfillCompleted, now, now, appID, country)
+	return err
+}
+
+// RetryBackfill marks the backfill as retrying after the error, it keeps its progress.
+func (b *BackfillsClient) RetryBackfill(appID string, country string, cause error) error {
+	_, err := b.db.Exec("UPDATE backfills SET status = ?, error = ?, updated_at = ? WHERE app_id = ? AND country = ?",
+		models.BackfillRetrying, cause.Error(), time.Now().UTC(), appID, country)
+	return err
+}
+
+// FailBackfill marks the backfill as failed with the error of its last attempt, once its job exhausted its retries.
+// It keeps its progress.
+func (b *BackfillsClient) FailBackfill(appID string, country string, cause error) error {
+	_, err := b.db.Exec("UPDATE backfills SET status = ?, error = ?, updated_at = ? WHERE app_id = ? AND country = ?",
+		models.BackfillFailed, cause.Error(), time.Now().UTC(), appID, country)
+	return err
+}
+
+// scanBackfill scans a row of backfillColumns into a backfill.
+func scanBackfill(row interface{ Scan(...any) error }) (models.Backfill, error) {
+	var backfill models.Backfill
+	var completedAt sql.NullTime
+	err := row.Scan(&backfill.AppID, &backfill.Country, &backfill.Status, &backfill.Page, &backfill.Created,
+		&backfill.Error, &backfill.RequestedAt, &backfill.UpdatedAt, &completedAt)
+	if completedAt.Valid {
+		backfill.CompletedAt = &completedAt.Time
+	}
+	return backfill, err
+}
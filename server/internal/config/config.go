@@ -2,19 +2,66 @@ package config
 
 import (
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/renantatsuo/envv"
 )
 
 type Config struct {
-	LogLevel         slog.Level
-	Port             int
-	StoreDir         string
-	ReviewsTimeLimit time.Duration
-	PollingInterval  time.Duration
-	DatabaseConnStr  string
-	QueueConnStr     string
+	LogLevel                slog.Level
+	Port                    int
+	StoreDir                string
+	ReviewsTimeLimit        time.Duration
+	PollingInterval         time.Duration
+	SchedulerTick           time.Duration
+	MinPollingInterval      time.Duration
+	MaxPollingInterval      time.Duration
+	DatabaseConnStr         string
+	QueueConnStr            string
+	DeadLetterConnStr       string
+	QueueMaxRetries         int
+	QueueRetryBackoff       time.Duration
+	QueueAckTimeout         time.Duration
+	QueueUnique             bool
+	ConsumerWorkers         int
+	WebhookQueueConnStr     string
+	WebhookWorkers          int
+	WebhookTimeout          time.Duration
+	WebhookMaxRetries       int
+	WebhookRetryBackoff     time.Duration
+	WebhookOutboxInterval   time.Duration
+	DigestInterval          time.Duration
+	DigestRatingDrop        float64
+	DigestLowestReviews     int
+	DigestKeywords          int
+	DigestSlackWebhookURL   string
+	DigestEmailTo           []string
+	SMTPAddr                string
+	SMTPUsername            string
+	SMTPPassword            string
+	SMTPFrom                string
+	AnomalyWindow           time.Duration
+	AnomalyBaseline         time.Duration
+	AnomalyMinReviews       int
+	AnomalyRatingDrop       float64
+	AnomalySpikeFactor      float64
+	AnomalyKeywords         []string
+	IncidentSlackWebhookURL string
+	IncidentEmailTo         []string
+	AppleRateLimit          float64
+	AppleRateBurst          int
+	AppleMaxRetries         int
+	AppleRetryBackoff       time.Duration
+	AppleMaxRetryWait       time.Duration
+	SessionTTL              time.Duration
+	SessionCookieSecure     bool
+	CORSAllowedOrigins      []string
+	LoginAddrRateLimit      int
+	LoginUsernameRateLimit  int
+	StreamPollInterval      time.Duration
+	StreamKeepAlive         time.Duration
+	StreamRetention         time.Duration
 }
 
 // LoadConfigFromEnv loads the config from the environment variables.
@@ -24,8 +71,54 @@ func LoadConfigFromEnv() (Config, error) {
 	storeDir := envv.Get("STORE_DIR").String().Default("data").Parse()
 	reviewsTimeLimit := envv.Get("REVIEWS_TIME_LIMIT").Duration().Default(48 * time.Hour).Parse()
 	pollingInterval := envv.Get("POLLING_INTERVAL").Duration().Default(30 * time.Second).Parse()
+	schedulerTick := envv.Get("SCHEDULER_TICK").Duration().Default(5 * time.Second).Parse()
+	minPollingInterval := envv.Get("MIN_POLLING_INTERVAL").Duration().Default(30 * time.Second).Parse()
+	maxPollingInterval := envv.Get("MAX_POLLING_INTERVAL").Duration().Default(6 * time.Hour).Parse()
 	databaseConnStr := envv.Get("DATABASE_CONN_STR").String().Default("data/database.db").Parse()
 	queueConnStr := envv.Get("QUEUE_CONN_STR").String().Default("data/queue.db").Parse()
+	deadLetterConnStr := envv.Get("DEAD_LETTER_CONN_STR").String().Default("data/dead_letter.db").Parse()
+	queueMaxRetries := envv.Get("QUEUE_MAX_RETRIES").Int().Default(5).Parse()
+	queueRetryBackoff := envv.Get("QUEUE_RETRY_BACKOFF").Duration().Default(30 * time.Second).Parse()
+	queueAckTimeout := envv.Get("QUEUE_ACK_TIMEOUT").Duration().Default(1 * time.Minute).Parse()
+	queueUnique := envv.Get("QUEUE_UNIQUE").Bool().Default(true).Parse()
+	consumerWorkers := envv.Get("CONSUMER_WORKERS").Int().Default(4).Parse()
+	webhookQueueConnStr := envv.Get("WEBHOOK_QUEUE_CONN_STR").String().Default("data/webhook_queue.db").Parse()
+	webhookWorkers := envv.Get("WEBHOOK_WORKERS").Int().Default(2).Parse()
+	webhookTimeout := envv.Get("WEBHOOK_TIMEOUT").Duration().Default(10 * time.Second).Parse()
+	webhookMaxRetries := envv.Get("WEBHOOK_MAX_RETRIES").Int().Default(5).Parse()
+	webhookRetryBackoff := envv.Get("WEBHOOK_RETRY_BACKOFF").Duration().Default(1 * time.Minute).Parse()
+	webhookOutboxInterval := envv.Get("WEBHOOK_OUTBOX_INTERVAL").Duration().Default(1 * time.Second).Parse()
+	digestInterval := envv.Get("DIGEST_INTERVAL").Duration().Default(24 * time.Hour).Parse()
+	digestRatingDrop := envv.Get("DIGEST_RATING_DROP").Float64().Default(0.5).Parse()
+	digestLowestReviews := envv.Get("DIGEST_LOWEST_REVIEWS").Int().Default(3).Parse()
+	digestKeywords := envv.Get("DIGEST_KEYWORDS").Int().Default(5).Parse()
+	digestSlackWebhookURL := envv.Get("DIGEST_SLACK_WEBHOOK_URL").String().Optional().Parse()
+	digestEmailTo := envv.Get("DIGEST_EMAIL_TO").String().Optional().Parse()
+	smtpAddr := envv.Get("SMTP_ADDR").String().Default("localhost:1025").Parse()
+	smtpUsername := envv.Get("SMTP_USERNAME").String().Optional().Parse()
+	smtpPassword := envv.Get("SMTP_PASSWORD").String().Optional().Parse()
+	smtpFrom := envv.Get("SMTP_FROM").String().Default("app-review@localhost").Parse()
+	anomalyWindow := envv.Get("ANOMALY_WINDOW").Duration().Default(24 * time.Hour).Parse()
+	anomalyBaseline := envv.Get("ANOMALY_BASELINE").Duration().Default(7 * 24 * time.Hour).Parse()
+	anomalyMinReviews := envv.Get("ANOMALY_MIN_REVIEWS").Int().Default(5).Parse()
+	anomalyRatingDrop := envv.Get("ANOMALY_RATING_DROP").Float64().Default(0.5).Parse()
+	anomalySpikeFactor := envv.Get("ANOMALY_SPIKE_FACTOR").Float64().Default(3).Parse()
+	anomalyKeywords := envv.Get("ANOMALY_KEYWORDS").String().Default("crash,bug,freeze,login,refund").Parse()
+	incidentSlackWebhookURL := envv.Get("INCIDENT_SLACK_WEBHOOK_URL").String().Optional().Parse()
+	incidentEmailTo := envv.Get("INCIDENT_EMAIL_TO").String().Optional().Parse()
+	appleRateLimit := envv.Get("APPLE_RATE_LIMIT").Float64().Default(5).Parse()
+	appleRateBurst := envv.Get("APPLE_RATE_BURST").Int().Default(5).Parse()
+	appleMaxRetries := envv.Get("APPLE_MAX_RETRIES").Int().Default(3).Parse()
+	appleRetryBackoff := envv.Get("APPLE_RETRY_BACKOFF").Duration().Default(500 * time.Millisecond).Parse()
+	appleMaxRetryWait := envv.Get("APPLE_MAX_RETRY_WAIT").Duration().Default(30 * time.Second).Parse()
+	sessionTTL := envv.Get("SESSION_TTL").Duration().Default(7 * 24 * time.Hour).Parse()
+	sessionCookieSecure := envv.Get("SESSION_COOKIE_SECURE").Bool().Default(false).Parse()
+	corsAllowedOrigins := envv.Get("CORS_ALLOWED_ORIGINS").String().Optional().Parse()
+	loginAddrRateLimit := envv.Get("LOGIN_ADDR_RATE_LIMIT").Int().Default(20).Parse()
+	loginUsernameRateLimit := envv.Get("LOGIN_USERNAME_RATE_LIMIT").Int().Default(5).Parse()
+	streamPollInterval := envv.Get("STREAM_POLL_INTERVAL").Duration().Default(1 * time.Second).Parse()
+	streamKeepAlive := envv.Get("STREAM_KEEPALIVE").Duration().Default(15 * time.Second).Parse()
+	streamRetention := envv.Get("STREAM_RETENTION").Duration().Default(24 * time.Hour).Parse()
 
 	logLevel, err := parseLogLevel(logLevelStr)
 	if err != nil {
@@ -33,16 +126,73 @@ func LoadConfigFromEnv() (Config, error) {
 	}
 
 	return Config{
-		LogLevel:         logLevel,
-		Port:             port,
-		StoreDir:         storeDir,
-		ReviewsTimeLimit: reviewsTimeLimit,
-		PollingInterval:  pollingInterval,
-		DatabaseConnStr:  databaseConnStr,
-		QueueConnStr:     queueConnStr,
+		LogLevel:                logLevel,
+		Port:                    port,
+		StoreDir:                storeDir,
+		ReviewsTimeLimit:        reviewsTimeLimit,
+		PollingInterval:         pollingInterval,
+		SchedulerTick:           schedulerTick,
+		MinPollingInterval:      minPollingInterval,
+		MaxPollingInterval:      maxPollingInterval,
+		DatabaseConnStr:         databaseConnStr,
+		QueueConnStr:            queueConnStr,
+		DeadLetterConnStr:       deadLetterConnStr,
+		QueueMaxRetries:         queueMaxRetries,
+		QueueRetryBackoff:       queueRetryBackoff,
+		QueueAckTimeout:         queueAckTimeout,
+		QueueUnique:             queueUnique,
+		ConsumerWorkers:         consumerWorkers,
+		WebhookQueueConnStr:     webhookQueueConnStr,
+		WebhookWorkers:          webhookWorkers,
+		WebhookTimeout:          webhookTimeout,
+		WebhookMaxRetries:       webhookMaxRetries,
+		WebhookRetryBackoff:     webhookRetryBackoff,
+		WebhookOutboxInterval:   webhookOutboxInterval,
+		DigestInterval:          digestInterval,
+		DigestRatingDrop:        digestRatingDrop,
+		DigestLowestReviews:     digestLowestReviews,
+		DigestKeywords:          digestKeywords,
+		DigestSlackWebhookURL:   digestSlackWebhookURL,
+		DigestEmailTo:           splitList(digestEmailTo),
+		SMTPAddr:                smtpAddr,
+		SMTPUsername:            smtpUsername,
+		SMTPPassword:            smtpPassword,
+		SMTPFrom:                smtpFrom,
+		AnomalyWindow:           anomalyWindow,
+		AnomalyBaseline:         anomalyBaseline,
+		AnomalyMinReviews:       anomalyMinReviews,
+		AnomalyRatingDrop:       anomalyRatingDrop,
+		AnomalySpikeFactor:      anomalySpikeFactor,
+		AnomalyKeywords:         splitList(anomalyKeywords),
+		IncidentSlackWebhookURL: incidentSlackWebhookURL,
+		IncidentEmailTo:         splitList(incidentEmailTo),
+		AppleRateLimit:          appleRateLimit,
+		AppleRateBurst:          appleRateBurst,
+		AppleMaxRetries:         appleMaxRetries,
+		AppleRetryBackoff:       appleRetryBackoff,
+		AppleMaxRetryWait:       appleMaxRetryWait,
+		SessionTTL:              sessionTTL,
+		SessionCookieSecure:     sessionCookieSecure,
+		CORSAllowedOrigins:      splitList(corsAllowedOrigins),
+		LoginAddrRateLimit:      loginAddrRateLimit,
+		LoginUsernameRateLimit:  loginUsernameRateLimit,
+		StreamPollInterval:      streamPollInterval,
+		StreamKeepAlive:         streamKeepAlive,
+		StreamRetention:         streamRetention,
 	}, nil
 }
 
+// splitList splits a comma separated list, skipping the empty items.
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func parseLogLevel(logLevel string) (l slog.Level, err error) {
 	err = l.UnmarshalText([]byte(logLevel))
 	return
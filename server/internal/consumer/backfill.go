@@ -0,0 +1,82 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/renantatsuo/app-review/server/internal/backfills"
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// processBackfill stores every page of the reviews feed of the app and country storefront of the job,
+// resuming after the last page stored by a previous attempt.
+// The reviews are upserted, so the pages overlapping the stored reviews are ignored.
+// The webhook subscriptions are not notified of the backfilled reviews, as they are not new.
+func (c *Consumer) processBackfill(ctx context.Context, job models.Job) error {
+	appID, country := job.AppID, job.Country
+
+	backfill, err := c.backfillsClient.GetBackfill(appID, country)
+	if err != nil {
+		if errors.As(err, &backfills.ErrBackfillNotFound{}) {
+			c.l.Info("backfill not found, skipping", "appID", appID, "country", country)
+			return nil
+		}
+		return fmt.Errorf("error getting backfill: %w", err)
+	}
+
+	if backfill.Status == models.BackfillCompleted {
+		return nil
+	}
+
+	if err := c.backfillPages(ctx, job.Platform, appID, country, backfill.Page+1); err != nil {
+		if ctx.Err() != nil {
+			// canceled by the shutdown, the progress is saved and the backfill resumes from it
+			return err
+		}
+		if err := c.backfillsClient.RetryBackfill(appID, country, err); err != nil {
+			c.l.Error("error saving backfill error", "appID", appID, "country", country, "error", err)
+		}
+		return err
+	}
+
+	if err := c.backfillsClient.CompleteBackfill(appID, country); err != nil {
+		return fmt.Errorf("error completing backfill: %w", err)
+	}
+
+	c.l.Info("backfill completed", "appID", appID, "country", country)
+
+	return nil
+}
+
+// backfillPages stores the pages of the reviews feed from the given page to the last one,
+// saving the progress after each page.
+func (c *Consumer) backfillPages(ctx context.Context, platform models.Platform, appID string, country string, from int) error {
+	for page := from; ; page++ {
+		reviews, hasNext, err := c.reviewsClient.FetchReviewsPage(ctx, platform, appID, country, page)
+		if err != nil {
+			return fmt.Errorf("error getting reviews page %d: %w", page, err)
+		}
+
+		created := 0
+		for _, r := range reviews {
+			change, err := c.reviewsClient.UpsertBackfilledReview(r)
+			if err != nil {
+				return fmt.Errorf("error upserting review: %w", err)
+			}
+			if change == models.ReviewCreated {
+				created++
+			}
+		}
+
+		if err := c.backfillsClient.SavePage(appID, country, page, created); err != nil {
+			return fmt.Errorf("error saving backfill progress: %w", err)
+		}
+
+		c.l.Info("backfilled reviews page", "appID", appID, "country", country, "page", page, "reviews", len(reviews), "created", created)
+
+		if !hasNext {
+			return nil
+		}
+	}
+}
@@ -2,87 +2,307 @@ package consumer
 
 import (
 	"context"
-	"database/sql"
 	"errors"
+	"fmt"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/mattdeak/gopq"
+	"github.com/renantatsuo/app-review/server/internal/backfills"
 	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/incidents"
 	"github.com/renantatsuo/app-review/server/internal/models"
 	"github.com/renantatsuo/app-review/server/internal/queue"
 	"github.com/renantatsuo/app-review/server/internal/reviews"
+	"github.com/renantatsuo/app-review/server/internal/sources"
+)
+
+const (
+	// dequeueErrorBackoff is how long a worker waits before dequeuing again after an error.
+	dequeueErrorBackoff = time.Second
+	// minLeaseInterval is the shortest interval between the extensions of a job lease.
+	minLeaseInterval = 100 * time.Millisecond
 )
 
 type Consumer struct {
-	l             *slog.Logger
-	queue         queue.Queue
-	config        config.Config
-	reviewsClient *reviews.ReviewsClient
+	l               *slog.Logger
+	queue           queue.Queue
+	config          config.Config
+	reviewsClient   *reviews.ReviewsClient
+	backfillsClient *backfills.BackfillsClient
+	detector        *incidents.Detector
+	locker          *appLocker
+	wg              sync.WaitGroup
 }
 
-func New(l *slog.Logger, queue queue.Queue, config config.Config, reviewsClient *reviews.ReviewsClient) *Consumer {
-	return &Consumer{l: l, queue: queue, config: config, reviewsClient: reviewsClient}
+func New(l *slog.Logger, queue queue.Queue, config config.Config, reviewsClient *reviews.ReviewsClient, backfillsClient *backfills.BackfillsClient, detector *incidents.Detector) *Consumer {
+	return &Consumer{l: l, queue: queue, config: config, reviewsClient: reviewsClient, backfillsClient: backfillsClient, detector: detector, locker: newAppLocker()}
 }
 
+// Start starts the workers, they stop dequeuing once the context is done.
 func (c *Consumer) Start(ctx context.Context) {
-	c.l.Info("starting consumer")
-	ticker := time.NewTicker(1 * time.Second)
+	workers := max(c.config.ConsumerWorkers, 1)
+	c.l.Info("starting consumer", "workers", workers)
+
+	for worker := range workers {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.work(ctx, c.l.With("worker", worker))
+		}()
+	}
+}
+
+// Wait blocks until every worker finished its in-flight job and stopped.
+func (c *Consumer) Wait() {
+	c.wg.Wait()
+}
+
+// work dequeues and processes jobs until the context is done.
+func (c *Consumer) work(ctx context.Context, l *slog.Logger) {
+	for {
+		msg, err := c.queue.Dequeue(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			l.Error("error dequeuing item", "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(dequeueErrorBackoff):
+			}
+			continue
+		}
+
+		job, err := models.DecodeJob(msg.Item)
+		if err != nil {
+			l.Error("error decoding job, job will be retried", "item", string(msg.Item), "error", err)
+			if _, err := c.queue.Nack(msg.ID, err); err != nil {
+				l.Error("error nacking item", "item", string(msg.Item), "error", err)
+			}
+			continue
+		}
+
+		// the lease is held from the dequeue, waiting for another worker to finish the app counts against the ack timeout too
+		jobCtx, endLease := c.lease(ctx, l, msg.ID)
+		unlock := c.locker.Lock(job.AppID)
+		err = c.process(jobCtx, job)
+		unlock()
 
+		if endLease() {
+			// another worker may have dequeued the job again, it is neither acked nor nacked so it is not settled twice
+			l.Warn("job lease lost, the job will be processed again", "appID", job.AppID, "country", job.Country, "type", job.Type, "error", err)
+			continue
+		}
+
+		if err != nil && ctx.Err() != nil {
+			// interrupted by the shutdown, the job did not fail so it is released without counting a retry
+			l.Info("job interrupted by shutdown, releasing it", "appID", job.AppID, "country", job.Country, "type", job.Type)
+			if err := c.queue.Release(msg.ID); err != nil {
+				l.Error("error releasing item", "appID", job.AppID, "country", job.Country, "error", err)
+			}
+			return
+		}
+
+		if err != nil {
+			l.Error("error processing app, job will be retried", "appID", job.AppID, "country", job.Country, "type", job.Type, "error", err)
+			exhausted, nackErr := c.queue.Nack(msg.ID, err)
+			if errors.Is(nackErr, queue.ErrLeaseLost) {
+				l.Warn("job lease lost before nacking, the job will be processed again", "appID", job.AppID, "country", job.Country, "type", job.Type)
+			} else if nackErr != nil {
+				l.Error("error nacking item", "appID", job.AppID, "country", job.Country, "error", nackErr)
+			}
+			if exhausted {
+				c.exhausted(job, err)
+			}
+			continue
+		}
+
+		if err := c.queue.Ack(msg.ID); errors.Is(err, queue.ErrLeaseLost) {
+			l.Warn("job lease lost before acking, the job will be processed again", "appID", job.AppID, "country", job.Country, "type", job.Type)
+		} else if err != nil {
+			l.Error("error acking item", "appID", job.AppID, "country", job.Country, "error", err)
+		}
+	}
+}
+
+// lease extends the ack deadline of the message every third of the ack timeout while its job runs,
+// so the jobs running longer than QUEUE_ACK_TIMEOUT, e.g. backfills, are not dequeued again by another worker.
+// The returned context is canceled if the lease is lost, and the returned function ends the lease,
+// reporting whether it was lost.
+func (c *Consumer) lease(ctx context.Context, l *slog.Logger, id int64) (context.Context, func() bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	var lost atomic.Bool
+	var wg sync.WaitGroup
+
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(max(c.config.QueueAckTimeout/3, minLeaseInterval))
+		defer ticker.Stop()
+
 		for {
 			select {
-			case <-ctx.Done():
+			case <-done:
 				return
 			case <-ticker.C:
-				item, err := c.queue.Dequeue()
-				if err != nil {
-					if errors.Is(err, &gopq.ErrNoItemsWaiting{}) {
-						c.l.Info("queue is empty, waiting for next tick")
-						continue
-					}
-					c.l.Error("error dequeuing item", "error", err)
-					continue
-				}
-
-				appID := string(item)
-				var latestTime time.Time
-				latestReview, err := c.reviewsClient.FindLatestReviewByAppID(appID)
-				if err != nil && !errors.Is(err, sql.ErrNoRows) {
-					c.l.Error("error finding latest review", "error", err)
-					continue
-				}
-
-				if err != nil && errors.Is(err, sql.ErrNoRows) {
-					c.l.Info("no latest review found, fetching all reviews")
-					latestTime = time.Now().Add(-c.config.ReviewsTimeLimit)
-				} else {
-					latestTime = latestReview.SentAt
-				}
-
-				reviews, err := c.reviewsClient.GetLatestReviewsFromApple(appID, latestTime)
-				if err != nil {
-					c.l.Error("error getting latest reviews", "error", err)
-					continue
-				}
-
-				if len(reviews) == 0 {
-					c.l.Info("no new reviews found, skipping")
-					continue
-				}
-
-				for _, review := range reviews {
-					r, err := models.ReviewFromAppleReview(review, appID)
-					if err != nil {
-						c.l.Error("error converting apple review to model", "error", err)
-						continue
-					}
-					if err := c.reviewsClient.AddReview(r); err != nil {
-						c.l.Error("error adding review", "error", err)
-						continue
-					}
-				}
+			}
+
+			err := c.queue.Extend(id)
+			if errors.Is(err, queue.ErrLeaseLost) {
+				lost.Store(true)
+				cancel()
+				return
+			}
+			if err != nil {
+				// the next tick tries again, the lease is only lost once the deadline expired
+				l.Error("error extending job lease", "error", err)
 			}
 		}
 	}()
+
+	return ctx, func() bool {
+		close(done)
+		wg.Wait()
+		cancel()
+		return lost.Load()
+	}
+}
+
+// exhausted is called when the job exhausted its retries, with the error of its last attempt.
+// A backfill is only marked as failed then, until then it is retrying.
+func (c *Consumer) exhausted(job models.Job, cause error) {
+	if job.Type != models.JobTypeBackfill {
+		return
+	}
+
+	if err := c.backfillsClient.FailBackfill(job.AppID, job.Country, cause); err != nil {
+		c.l.Error("error failing backfill", "appID", job.AppID, "country", job.Country, "error", err)
+	}
+}
+
+// process runs the job according to its type.
+// The context cancels the requests to the stores, so a shutdown does not wait for them,
+// and the errors caused by the cancellation are not recorded, see syncFailed.
+func (c *Consumer) process(ctx context.Context, job models.Job) error {
+	switch job.Type {
+	case models.JobTypeBackfill:
+		return c.processBackfill(ctx, job)
+	default:
+		return c.processApp(ctx, job)
+	}
+}
+
+// processApp fetches and stores the new reviews for the app and country storefront of the job,
+// resuming from the high water mark of its sync state, then looks for anomalies in the app reviews.
+// The created reviews are added to the webhook outbox as they are stored, see ReviewsClient.UpsertReview.
+// It only returns nil once every new review is persisted. If the source truncated the reviews,
+// the state is advanced to the fetched ones and the next fetches resume with the older ones from the cursor
+// of the source, down to the same since time, so every fetch makes progress and the older reviews are not skipped.
+func (c *Consumer) processApp(ctx context.Context, job models.Job) error {
+	appID, country := job.AppID, job.Country
+
+	state, err := c.reviewsClient.GetSyncState(appID, country)
+	if err != nil {
+		return fmt.Errorf("error getting sync state: %w", err)
+	}
+
+	fetchedAt := time.Now()
+	since, cursor := syncSince(state, fetchedAt.Add(-c.config.ReviewsTimeLimit)), ""
+	if state.ResumeCursor != "" {
+		since, cursor = *state.ResumeSince, state.ResumeCursor
+	}
+
+	latest, commit, err := c.reviewsClient.FetchReviewsSince(ctx, job.Platform, appID, country, since, cursor)
+	var truncated *sources.TruncatedError
+	if err != nil && !errors.As(err, &truncated) {
+		return c.syncFailed(ctx, appID, country, fmt.Errorf("error getting latest reviews: %w", err))
+	}
+
+	fetched := []models.Review{}
+	for _, r := range latest {
+		if !state.Seen(r.ID, r.SentAt) {
+			fetched = append(fetched, r)
+		}
+	}
+
+	created, edited := 0, 0
+	for _, r := range fetched {
+		change, err := c.reviewsClient.UpsertReview(r)
+		if err != nil {
+			return c.syncFailed(ctx, appID, country, fmt.Errorf("error upserting review: %w", err))
+		}
+		switch change {
+		case models.ReviewCreated:
+			created++
+		case models.ReviewEdited:
+			edited++
+		}
+	}
+
+	if len(fetched) == 0 {
+		c.l.Info("no new reviews found, skipping")
+	} else {
+		c.l.Info("stored reviews", "appID", appID, "country", country, "created", created, "edited", edited)
+	}
+
+	state = state.Advance(fetched)
+	if truncated != nil {
+		c.l.Warn("reviews truncated, the next fetch resumes with the older ones", "appID", appID, "country", country, "since", since)
+		state = state.Truncate(since, truncated.Cursor)
+	} else {
+		state = state.Resumed()
+	}
+
+	if err := c.reviewsClient.SaveSyncSuccess(state, fetchedAt); err != nil {
+		return fmt.Errorf("error saving sync state: %w", err)
+	}
+	c.commit(ctx, commit, appID, country)
+
+	if created > 0 || edited > 0 {
+		if _, err := c.detector.Detect(appID, country); err != nil {
+			c.l.Error("error detecting incidents", "appID", appID, "country", country, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// syncSince returns the time the fetch of the reviews resumes from:
+// the high water mark of the state, or the last fetch if no review was ever fetched,
+// or the given default for the first fetch.
+func syncSince(state models.SyncState, defaultSince time.Time) time.Time {
+	switch {
+	case state.HighWaterMark != nil:
+		return *state.HighWaterMark
+	case state.LastFetchedAt != nil:
+		return *state.LastFetchedAt
+	default:
+		return defaultSince
+	}
+}
+
+// commit commits the fetch of the app storefront once its reviews are persisted,
+// so the next fetch is conditional. A failed commit only makes the next fetch unconditional.
+func (c *Consumer) commit(ctx context.Context, commit sources.Commit, appID string, country string) {
+	if err := commit(ctx); err != nil {
+		c.l.Error("error committing fetch", "appID", appID, "country", country, "error", err)
+	}
+}
+
+// syncFailed records the error in the sync state of the app storefront and returns it.
+// The errors of a fetch canceled by the shutdown are not recorded, as the fetch did not fail.
+func (c *Consumer) syncFailed(ctx context.Context, appID string, country string, err error) error {
+	if ctx.Err() != nil {
+		return err
+	}
+
+	if err := c.reviewsClient.SaveSyncError(appID, country, err); err != nil {
+		c.l.Error("error saving sync error", "appID", appID, "country", country, "error", err)
+	}
+	return err
 }
@@ -0,0 +1,160 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/backfills"
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/dbtest"
+	"github.com/renantatsuo/app-review/server/internal/incidents"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/reviews"
+	"github.com/renantatsuo/app-review/server/internal/sources"
+	"github.com/renantatsuo/app-review/server/internal/workspaces"
+)
+
+const (
+	testAppID   = "com.example.app"
+	testCountry = "us"
+)
+
+// backlogSource is a store whose fetches stop after a page of its reviews, most recent first,
+// truncating them while older ones are left. The cursor is the index of the next review.
+type backlogSource struct {
+	reviews  []models.Review
+	pageSize int
+	// cursors are the cursors of the fetches.
+	cursors []string
+}
+
+func (b *backlogSource) Platform() models.Platform {
+	return models.PlatformAndroid
+}
+
+func (b *backlogSource) LookupApp(ctx context.Context, appID string) (models.App, error) {
+	return models.App{}, sources.ErrAppNotFound
+}
+
+func (b *backlogSource) FetchReviewsSince(ctx context.Context, appID string, country string, since time.Time, cursor string) ([]models.Review, sources.Commit, error) {
+	b.cursors = append(b.cursors, cursor)
+	commit := func(ctx context.Context) error { return nil }
+
+	start, _ := strconv.Atoi(cursor)
+	res := []models.Review{}
+	for i := start; i < min(start+b.pageSize, len(b.reviews)); i++ {
+		if b.reviews[i].SentAt.Before(since) {
+			return res, commit, nil
+		}
+		res = append(res, b.reviews[i])
+	}
+
+	if start+b.pageSize >= len(b.reviews) {
+		return res, commit, nil
+	}
+	return res, commit, &sources.TruncatedError{Cursor: strconv.Itoa(start + b.pageSize)}
+}
+
+// newReviews returns n reviews sent a minute apart, the most recent first at the given time.
+func newReviews(n int, latest time.Time) []models.Review {
+	reviews := []models.Review{}
+	for i := range n {
+		reviews = append(reviews, models.Review{
+			ID:      fmt.Sprint(n - i),
+			AppID:   testAppID,
+			Country: testCountry,
+			Author:  "author",
+			Content: "content",
+			Rating:  5,
+			SentAt:  latest.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	return reviews
+}
+
+// newTestConsumer returns a consumer of the source storing the reviews in a temporary database.
+func newTestConsumer(t *testing.T, source sources.Source) (*Consumer, *reviews.ReviewsClient) {
+	t.Helper()
+
+	db := dbtest.New(t)
+	config, err := config.LoadConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := slog.New(slog.DiscardHandler)
+
+	sources := sources.New(source)
+	reviewsClient := reviews.New(l, sources, db, config)
+	detector := incidents.NewDetector(l, incidents.New(l, db), reviewsClient, apps.New(db, sources), workspaces.New(l, db), config)
+
+	return New(l, nil, config, reviewsClient, backfills.New(l, db), detector), reviewsClient
+}
+
+func TestProcessAppResumesTheTruncatedFetches(t *testing.T) {
+	latest := time.Now().UTC().Truncate(time.Second)
+	source := &backlogSource{reviews: newReviews(35, latest), pageSize: 10}
+	c, reviewsClient := newTestConsumer(t, source)
+	job := models.Job{AppID: testAppID, Country: testCountry, Platform: models.PlatformAndroid}
+
+	stored := func() int {
+		reviews, _, err := reviewsClient.FindReviewsByAppID(reviews.NewQuery(testAppID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(reviews)
+	}
+
+	// every fetch of the backlog is truncated, each one goes on from the previous one
+	for fetch := 1; fetch <= 3; fetch++ {
+		if err := c.processApp(context.Background(), job); err != nil {
+			t.Fatalf("fetch %d: %v", fetch, err)
+		}
+
+		state, err := reviewsClient.GetSyncState(testAppID, testCountry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := stored(); got != fetch*10 {
+			t.Errorf("fetch %d: got %d reviews stored, want %d", fetch, got, fetch*10)
+		}
+		if state.LastFetchedAt == nil || state.ResumeCursor != strconv.Itoa(fetch*10) {
+			t.Errorf("fetch %d: got state %+v, want it fetched and resuming from review %d", fetch, state, fetch*10)
+		}
+		if state.HighWaterMark == nil || !state.HighWaterMark.Equal(latest) {
+			t.Errorf("fetch %d: got high water mark %v, want the latest review at %v", fetch, state.HighWaterMark, latest)
+		}
+	}
+
+	// the last page reaches the since time of the truncated fetch
+	if err := c.processApp(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	state, err := reviewsClient.GetSyncState(testAppID, testCountry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stored(); got != 35 {
+		t.Errorf("got %d reviews stored, want the 35 reviews of the backlog", got)
+	}
+	if state.ResumeCursor != "" || state.ResumeSince != nil {
+		t.Errorf("got state %+v, want the truncated fetch resumed", state)
+	}
+
+	// the next fetch starts from the latest reviews again, down to the high water mark
+	source.reviews = append(newReviews(1, latest.Add(time.Minute)), source.reviews...)
+	source.reviews[0].ID = "36"
+	if err := c.processApp(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := source.cursors, []string{"", "10", "20", "30", ""}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got cursors %q, want %q", got, want)
+	}
+	if got := stored(); got != 36 {
+		t.Errorf("got %d reviews stored, want the new review stored too", got)
+	}
+}
@@ -0,0 +1,44 @@
+package consumer
+
+import "sync"
+
+// appLocker serializes the processing of the same app across workers.
+type appLocker struct {
+	mu    sync.Mutex
+	locks map[string]*appLock
+}
+
+type appLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newAppLocker() *appLocker {
+	return &appLocker{locks: make(map[string]*appLock)}
+}
+
+// Lock blocks until no other worker is processing the given app.
+// It returns the function that releases the lock.
+func (l *appLocker) Lock(appID string) func() {
+	l.mu.Lock()
+	lock, ok := l.locks[appID]
+	if !ok {
+		lock = &appLock{}
+		l.locks[appID] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.mu.Lock()
+
+	return func() {
+		lock.mu.Unlock()
+
+		l.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, appID)
+		}
+		l.mu.Unlock()
+	}
+}
@@ -2,12 +2,20 @@ package db
 
 import (
 	"database/sql"
+	"fmt"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 const (
 	sqliteDriver = "sqlite3"
+
+	// connOptions make the writers of the database wait for each other instead of failing with SQLITE_BUSY:
+	// the server, scheduler and consumer processes and the consumer workers all write to it.
+	// WAL lets the readers run along the writer, the busy timeout makes a writer wait for the lock,
+	// and immediate transactions take the write lock on BEGIN, so a transaction reading before writing
+	// cannot deadlock upgrading its read lock.
+	connOptions = "_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
 )
 
 type DB struct {
@@ -22,7 +30,7 @@ func New(connStr string) *DB {
 
 // Connect to the database using sqlite3
 func (d *DB) Connect() *sql.DB {
-	db, err := sql.Open(sqliteDriver, d.connStr)
+	db, err := sql.Open(sqliteDriver, fmt.Sprintf("file:%s?%s", d.connStr, connOptions))
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,7 @@
+//go:build sqlite_fts5 || fts5
+
+package db
+
+// FTS5Enabled reports whether the sqlite driver is built with FTS5,
+// which the reviews full-text search table requires.
+const FTS5Enabled = true
@@ -0,0 +1,68 @@
+// Package dbtest creates migrated temporary databases for the tests of the packages storing data.
+package dbtest
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/renantatsuo/app-review/server/internal/db"
+)
+
+// New migrates a temporary database and returns a connection to it, closed when the test ends.
+// It skips the test without the sqlite_fts5 build tag, the migrations create the reviews full-text search table.
+func New(t testing.TB) *sql.DB {
+	t.Helper()
+
+	connStr := filepath.Join(t.TempDir(), "database.db")
+	if err := Migrate(t, connStr); err != nil {
+		t.Fatalf("error migrating database: %v", err)
+	}
+
+	conn := db.New(connStr).Connect()
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// Migrate applies the up migrations to the new database, as goose up does, and removes the seeded apps.
+// It skips the test without the sqlite_fts5 build tag.
+func Migrate(t testing.TB, connStr string) error {
+	t.Helper()
+
+	if !db.FTS5Enabled {
+		t.Skip("the database tests require the sqlite_fts5 build tag")
+	}
+
+	_, file, _, _ := runtime.Caller(0)
+	paths, err := filepath.Glob(filepath.Join(filepath.Dir(file), "..", "..", "migrations", "*.sql"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no migrations found")
+	}
+	sort.Strings(paths)
+
+	conn := db.New(connStr).Connect()
+	defer conn.Close()
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		up, _, _ := strings.Cut(string(content), "-- +goose Down")
+		if _, err := conn.Exec(up); err != nil {
+			return fmt.Errorf("error applying %s: %w", filepath.Base(path), err)
+		}
+	}
+
+	// the migrations seed an app, the tests start without any
+	_, err = conn.Exec("DELETE FROM workspace_apps; DELETE FROM apps")
+	return err
+}
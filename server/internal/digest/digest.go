@@ -0,0 +1,181 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/notify"
+	"github.com/renantatsuo/app-review/server/internal/reviews"
+	"github.com/renantatsuo/app-review/server/internal/workspaces"
+)
+
+// Digester sends to every workspace a digest of the reviews of each of its active apps at the end of every digest window.
+type Digester struct {
+	l                *slog.Logger
+	reviewsClient    *reviews.ReviewsClient
+	appsClient       *apps.AppsClient
+	workspacesClient *workspaces.WorkspacesClient
+	config           config.Config
+}
+
+func New(l *slog.Logger, reviewsClient *reviews.ReviewsClient, appsClient *apps.AppsClient, workspacesClient *workspaces.WorkspacesClient, config config.Config) *Digester {
+	return &Digester{l: l, reviewsClient: reviewsClient, appsClient: appsClient, workspacesClient: workspacesClient, config: config}
+}
+
+// Start sends the digests at the end of every window until the context is done.
+// Windows are aligned to the digest interval, e.g. a 24h interval sends the digests at midnight UTC.
+func (d *Digester) Start(ctx context.Context) {
+	d.l.Info("starting digester", "interval", d.config.DigestInterval.String())
+
+	go func() {
+		for {
+			end := d.WindowEnd(time.Now()).Add(d.config.DigestInterval)
+			timer := time.NewTimer(time.Until(end))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				if err := d.SendAll(end); err != nil {
+					d.l.Error("error sending digests", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// WindowEnd returns the end of the last window complete at the given time.
+func (d *Digester) WindowEnd(now time.Time) time.Time {
+	return now.UTC().Truncate(d.config.DigestInterval)
+}
+
+// SendAll sends to the digest channels of every workspace the digest of the window ending at end of each app it has active,
+// over the reviews of the storefronts it watches. The workspaces without digest channels are skipped,
+// as are the apps without reviews in the window and the previous one.
+func (d *Digester) SendAll(end time.Time) error {
+	workspaces, err := d.workspacesClient.GetWorkspaces()
+	if err != nil {
+		return fmt.Errorf("error getting workspaces: %w", err)
+	}
+
+	var errs []error
+	sent := 0
+	for _, workspace := range workspaces {
+		channels := notify.DigestChannels(workspace, d.config)
+		if len(channels) == 0 {
+			d.l.Debug("no digest channels, skipping workspace", "workspaceID", workspace.ID)
+			continue
+		}
+
+		apps, err := d.appsClient.GetAllApps(workspace.ID, models.AppStatusActive)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error getting active apps of workspace %d: %w", workspace.ID, err))
+			continue
+		}
+
+		for _, app := range apps {
+			digest, err := d.Build(workspace.ID, app, end)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error building digest of app %s for workspace %d: %w", app.ID, workspace.ID, err))
+				continue
+			}
+
+			if digest.Count == 0 && digest.PreviousCount == 0 {
+				d.l.Debug("no reviews, skipping digest", "workspaceID", workspace.ID, "appID", app.ID)
+				continue
+			}
+
+			msg := message(digest)
+			for _, channel := range channels {
+				if err := channel.Send(msg); err != nil {
+					errs = append(errs, fmt.Errorf("error sending digest of app %s for workspace %d to %s: %w", app.ID, workspace.ID, channel.Name(), err))
+					continue
+				}
+			}
+			sent++
+		}
+	}
+
+	d.l.Info("sent digests", "windowEnd", end, "workspaces", len(workspaces), "sent", sent)
+
+	return errors.Join(errs...)
+}
+
+// Build builds the digest of the app reviews sent in the window ending at end, on the storefronts the workspace watches.
+func (d *Digester) Build(workspaceID int64, app models.App, end time.Time) (models.Digest, error) {
+	start := end.Add(-d.config.DigestInterval)
+	previousStart := start.Add(-d.config.DigestInterval)
+
+	current, err := d.findReviews(workspaceID, app.ID, start, end)
+	if err != nil {
+		return models.Digest{}, err
+	}
+	previous, err := d.findReviews(workspaceID, app.ID, previousStart, start)
+	if err != nil {
+		return models.Digest{}, err
+	}
+
+	digest := models.Digest{
+		App:                   app,
+		WindowStart:           start,
+		WindowEnd:             end,
+		Count:                 len(current),
+		PreviousCount:         len(previous),
+		AverageRating:         averageRating(current),
+		PreviousAverageRating: averageRating(previous),
+		LowestRated:           lowestRated(current, d.config.DigestLowestReviews),
+		TrendingKeywords:      trendingKeywords(current, previous, d.config.DigestKeywords),
+	}
+	digest.RatingDropped = digest.RatingChange() <= -d.config.DigestRatingDrop
+
+	return digest, nil
+}
+
+// findReviews returns the app reviews of the storefronts the workspace watches sent in [start, end).
+func (d *Digester) findReviews(workspaceID int64, appID string, start time.Time, end time.Time) ([]models.Review, error) {
+	// Since is exclusive, so start from right before the window start
+	query := reviews.NewQuery(appID).Workspace(workspaceID).Since(start.Add(-time.Nanosecond)).Until(end)
+	reviews, _, err := d.reviewsClient.FindReviewsByAppID(query)
+	return reviews, err
+}
+
+// averageRating returns the average rating of the reviews, zero if there are none.
+func averageRating(reviews []models.Review) float64 {
+	if len(reviews) == 0 {
+		return 0
+	}
+
+	total := 0
+	for _, review := range reviews {
+		total += review.Rating
+	}
+
+	return float64(total) / float64(len(reviews))
+}
+
+// lowestRated returns up to limit of the lowest rated reviews, the most recent first on ties.
+func lowestRated(reviews []models.Review, limit int) []models.Review {
+	sorted := make([]models.Review, len(reviews))
+	copy(sorted, reviews)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Rating != sorted[j].Rating {
+			return sorted[i].Rating < sorted[j].Rating
+		}
+		return sorted[i].SentAt.After(sorted[j].SentAt)
+	})
+
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+
+	return sorted
+}
@@ -0,0 +1,133 @@
+package digest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/dbtest"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/reviews"
+	"github.com/renantatsuo/app-review/server/internal/sources"
+	"github.com/renantatsuo/app-review/server/internal/workspaces"
+)
+
+// slackSink is a Slack compatible incoming webhook recording the texts posted to it.
+type slackSink struct {
+	mu    sync.Mutex
+	texts []string
+}
+
+func (s *slackSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Text string `json:"text"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.texts = append(s.texts, body.Text)
+}
+
+func (s *slackSink) Texts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.texts...)
+}
+
+func TestSendAllSendsEachWorkspaceTheDigestOfItsStorefronts(t *testing.T) {
+	db := dbtest.New(t)
+	config, err := config.LoadConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.DigestInterval = 24 * time.Hour
+	config.DigestSlackWebhookURL, config.DigestEmailTo = "", nil
+	l := slog.New(slog.DiscardHandler)
+
+	sources := sources.New()
+	reviewsClient := reviews.New(l, sources, db, config)
+	appsClient := apps.New(db, sources)
+	workspacesClient := workspaces.New(l, db)
+
+	us, gb := &slackSink{}, &slackSink{}
+	usServer, gbServer := httptest.NewServer(us), httptest.NewServer(gb)
+	defer usServer.Close()
+	defer gbServer.Close()
+
+	// the default workspace watches the us storefront, a second one the gb storefront
+	// and a third one both without notification targets
+	gbWorkspace, err := workspacesClient.CreateWorkspace("gb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	silentWorkspace, err := workspacesClient.CreateWorkspace("silent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	watched := []struct {
+		workspaceID int64
+		countries   []string
+		slackURL    string
+	}{
+		{models.DefaultWorkspaceID, []string{"us"}, usServer.URL},
+		{gbWorkspace.ID, []string{"gb"}, gbServer.URL},
+		{silentWorkspace.ID, []string{"us", "gb"}, ""},
+	}
+	for _, w := range watched {
+		app := models.App{ID: "com.example.app", Platform: models.PlatformAndroid, Name: "Example", Countries: w.countries}
+		if err := appsClient.AddApp(w.workspaceID, app); err != nil {
+			t.Fatal(err)
+		}
+		digest := models.NotificationTargets{SlackWebhookURL: w.slackURL}
+		if err := workspacesClient.SetNotifications(w.workspaceID, digest, models.NotificationTargets{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	end := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	countries := []string{"us", "us", "us", "gb"}
+	for i, country := range countries {
+		review := models.Review{
+			ID:      fmt.Sprint(i),
+			AppID:   "com.example.app",
+			Country: country,
+			Author:  "author",
+			Content: "content",
+			Rating:  5,
+			SentAt:  end.Add(-time.Duration(i+1) * time.Hour),
+		}
+		if _, err := reviewsClient.UpsertReview(review); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := New(l, reviewsClient, appsClient, workspacesClient, config).SendAll(end); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		sink *slackSink
+		want string
+	}{
+		{"us", us, "3 new reviews"},
+		{"gb", gb, "1 new reviews"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			texts := tt.sink.Texts()
+			if len(texts) != 1 || !strings.Contains(texts[0], tt.want) {
+				t.Errorf("got digests %q, want one with %q", texts, tt.want)
+			}
+		})
+	}
+}
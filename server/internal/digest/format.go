@@ -0,0 +1,101 @@
+package digest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/notify"
+)
+
+// maxExcerptLength is how many characters of the lowest rated reviews content are shown.
+const maxExcerptLength = 200
+
+// message formats the digest as a notification.
+func message(d models.Digest) notify.Message {
+	return notify.Message{
+		Subject:  subject(d),
+		Text:     formatText(d, false),
+		Markdown: formatText(d, true),
+	}
+}
+
+// subject returns the one line summary of the digest.
+func subject(d models.Digest) string {
+	prefix := ""
+	if d.RatingDropped {
+		prefix = "[Rating drop] "
+	}
+
+	return fmt.Sprintf("%sReviews digest for %s: %d new reviews, %s average rating",
+		prefix, d.App.Name, d.Count, formatRating(d.AverageRating, d.Count))
+}
+
+// formatText formats the digest as plain text.
+// With markdown the headings and quotes use the Slack mrkdwn syntax.
+func formatText(d models.Digest, markdown bool) string {
+	var b strings.Builder
+
+	bold := func(s string) string {
+		if markdown {
+			return "*" + s + "*"
+		}
+		return s
+	}
+
+	fmt.Fprintln(&b, bold(subject(d)))
+	fmt.Fprintf(&b, "%s to %s\n\n", d.WindowStart.Format("2006-01-02 15:04 MST"), d.WindowEnd.Format("2006-01-02 15:04 MST"))
+
+	fmt.Fprintf(&b, "New reviews: %d (previous window: %d)\n", d.Count, d.PreviousCount)
+	fmt.Fprintf(&b, "Average rating: %s (previous window: %s", formatRating(d.AverageRating, d.Count), formatRating(d.PreviousAverageRating, d.PreviousCount))
+	if change := d.RatingChange(); change != 0 {
+		fmt.Fprintf(&b, ", %+.2f", change)
+	}
+	fmt.Fprintln(&b, ")")
+
+	if len(d.TrendingKeywords) > 0 {
+		keywords := make([]string, len(d.TrendingKeywords))
+		for i, keyword := range d.TrendingKeywords {
+			keywords[i] = fmt.Sprintf("%s (%d)", keyword.Keyword, keyword.Count)
+		}
+		fmt.Fprintf(&b, "Trending keywords: %s\n", strings.Join(keywords, ", "))
+	}
+
+	if len(d.LowestRated) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", bold("Lowest rated reviews"))
+		for _, review := range d.LowestRated {
+			fmt.Fprintf(&b, "\n%s %s by %s (%s)\n", stars(review.Rating), review.Title, review.Author, strings.ToUpper(review.Country))
+			for _, line := range strings.Split(excerpt(review.Content), "\n") {
+				if markdown {
+					line = "> " + line
+				}
+				fmt.Fprintln(&b, line)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// formatRating formats an average rating, or "n/a" if there are no reviews.
+func formatRating(rating float64, count int) string {
+	if count == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.2f", rating)
+}
+
+// excerpt shortens the text to maxExcerptLength characters.
+func excerpt(text string) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= maxExcerptLength {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:maxExcerptLength])) + "…"
+}
+
+// stars formats a rating out of 5 stars.
+func stars(rating int) string {
+	rating = min(max(rating, 0), 5)
+	return strings.Repeat("★", rating) + strings.Repeat("☆", 5-rating)
+}
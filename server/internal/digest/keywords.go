@@ -0,0 +1,97 @@
+package digest
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// minKeywordLength skips the short words that are rarely meaningful.
+const minKeywordLength = 3
+
+// stopWords are the common english words that are never keywords.
+var stopWords = toSet(strings.Fields(`
+	about after again all also and any app apps are because been before being but can cant could did
+	didnt does doesnt dont even every for from get gets got had has have her here his how its just
+	like make more most much not now off one only other our out over please really same she should
+	since some still than that the their them then there these they thing this those too use used
+	using very want was way were what when where which while who why will with would you your
+`))
+
+// trendingKeywords returns up to limit keywords mentioned by more of the current reviews
+// than of the previous ones, the biggest increase first.
+// A keyword is counted once per review.
+func trendingKeywords(current []models.Review, previous []models.Review, limit int) []models.KeywordCount {
+	currentCounts := countKeywords(current)
+	previousCounts := countKeywords(previous)
+
+	trending := []models.KeywordCount{}
+	for keyword, count := range currentCounts {
+		// a keyword of a single review is not a trend
+		if count < 2 || count <= previousCounts[keyword] {
+			continue
+		}
+		trending = append(trending, models.KeywordCount{Keyword: keyword, Count: count})
+	}
+
+	sort.Slice(trending, func(i, j int) bool {
+		a, b := trending[i], trending[j]
+		increaseA, increaseB := a.Count-previousCounts[a.Keyword], b.Count-previousCounts[b.Keyword]
+		if increaseA != increaseB {
+			return increaseA > increaseB
+		}
+		return a.Keyword < b.Keyword
+	})
+
+	if len(trending) > limit {
+		trending = trending[:limit]
+	}
+
+	return trending
+}
+
+// countKeywords counts how many reviews mention each keyword in their title or content.
+func countKeywords(reviews []models.Review) map[string]int {
+	counts := map[string]int{}
+
+	for _, review := range reviews {
+		seen := map[string]bool{}
+		for _, word := range words(review.Title + " " + review.Content) {
+			if seen[word] {
+				continue
+			}
+			seen[word] = true
+			counts[word]++
+		}
+	}
+
+	return counts
+}
+
+// words splits the text in lowercase words, without stop words.
+func words(text string) []string {
+	res := []string{}
+
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+	for _, field := range fields {
+		word := strings.ReplaceAll(field, "'", "")
+		if len([]rune(word)) < minKeywordLength || stopWords[word] {
+			continue
+		}
+		res = append(res, word)
+	}
+
+	return res
+}
+
+func toSet(words []string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
@@ -0,0 +1,588 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/server"
+)
+
+const (
+	appID   = "1458862350"
+	timeout = 10 * time.Second
+)
+
+func fakeReviews(n int, from int, updated time.Time) []FakeReview {
+	reviews := []FakeReview{}
+	for i := from; i < from+n; i++ {
+		reviews = append(reviews, FakeReview{
+			ID:      fmt.Sprint(i),
+			Author:  fmt.Sprintf("author %d", i),
+			Title:   fmt.Sprintf("title %d", i),
+			Content: fmt.Sprintf("content %d", i),
+			Rating:  i%5 + 1,
+			Updated: updated.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	return reviews
+}
+
+func (h *Harness) reviews(t *testing.T) []models.Review {
+	t.Helper()
+
+	var response server.ResponseData[[]models.Review]
+	h.Do(t, http.MethodGet, "/reviews/"+appID+"?limit=100", http.StatusOK, &response)
+	return response.Data
+}
+
+func TestAddAppFetchesItsReviews(t *testing.T) {
+	h := Start(t)
+	h.Apple.AddApp(appID, "Test App")
+	h.Apple.AddReviews(appID, "us", fakeReviews(3, 0, time.Now().Add(-time.Hour))...)
+
+	h.Do(t, http.MethodPost, "/apps/"+appID, http.StatusCreated, nil)
+
+	var app server.ResponseData[models.App]
+	h.Do(t, http.MethodGet, "/apps/"+appID, http.StatusOK, &app)
+	if app.Data.Name != "Test App" || app.Data.Platform != models.PlatformIOS {
+		t.Errorf("got app %+v, want the Test App ios app", app.Data)
+	}
+
+	h.Eventually(t, timeout, func() bool { return len(h.reviews(t)) == 3 }, "the reviews of the app were not stored")
+
+	reviews := h.reviews(t)
+	if reviews[0].ID != "2" || reviews[0].Title != "title 2" || reviews[0].Rating != 3 {
+		t.Errorf("got latest review %+v, want review 2", reviews[0])
+	}
+}
+
+func TestAddUnknownApp(t *testing.T) {
+	h := Start(t)
+
+	h.Do(t, http.MethodPost, "/apps/"+appID, http.StatusNotFound, nil)
+}
+
+func TestPollsFetchTheNewReviews(t *testing.T) {
+	h := Start(t)
+	h.Apple.AddApp(appID, "Test App")
+	h.Apple.AddReviews(appID, "us", fakeReviews(2, 0, time.Now().Add(-time.Hour))...)
+
+	h.Do(t, http.MethodPost, "/apps/"+appID, http.StatusCreated, nil)
+	h.Eventually(t, timeout, func() bool { return len(h.reviews(t)) == 2 }, "the reviews of the app were not stored")
+
+	// the polls of an unchanged feed are answered with 304 Not Modified
+	h.Eventually(t, timeout, func() bool { return h.Apple.NotModified() > 0 }, "the feed was not requested conditionally")
+
+	h.Apple.AddReviews(appID, "us", fakeReviews(2, 2, time.Now().Add(-time.Hour))...)
+	h.Eventually(t, timeout, func() bool { return len(h.reviews(t)) == 4 }, "the new reviews were not stored")
+
+	// the sync state is saved after the reviews are stored
+	var syncStates server.ResponseData[[]models.SyncState]
+	h.Eventually(t, timeout, func() bool {
+		h.Do(t, http.MethodGet, "/apps/"+appID+"/sync", http.StatusOK, &syncStates)
+		return len(syncStates.Data) == 1 && syncStates.Data[0].LastReviewIDs[0] == "3"
+	}, "the us storefront was not synced up to review 3")
+}
+
+func TestPollsRetryTheTransientErrors(t *testing.T) {
+	h := Start(t)
+	h.Apple.AddApp(appID, "Test App")
+	h.Apple.AddReviews(appID, "us", fakeReviews(1, 0, time.Now().Add(-time.Hour))...)
+
+	h.Do(t, http.MethodPost, "/apps/"+appID, http.StatusCreated, nil)
+	// the consumer fetch fails more than the client retries, so the job is retried by the queue
+	h.Apple.Fail(http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable,
+		http.StatusServiceUnavailable, http.StatusTooManyRequests)
+
+	h.Eventually(t, timeout, func() bool { return len(h.reviews(t)) == 1 }, "the review was not stored after the errors")
+}
+
+func TestPollsRetryTheFailedPagesInFull(t *testing.T) {
+	h := Start(t)
+	h.Apple.AddApp(appID, "Test App")
+	h.Apple.AddReviews(appID, "us", fakeReviews(fakeApplePageSize+10, 0, time.Now().Add(-2*time.Hour))...)
+	// the second page fails without being retried by the client, after the first one was answered
+	h.Apple.FailPage(2, http.StatusForbidden)
+
+	h.Do(t, http.MethodPost, "/apps/"+appID, http.StatusCreated, nil)
+
+	// the retry is not answered 304 Not Modified, as the validators of the first page were not cached
+	h.Eventually(t, timeout, func() bool { return len(h.reviews(t)) == fakeApplePageSize+10 }, "the reviews of the failed sync were not stored")
+}
+
+func TestWebhooksReceiveTheStoredReviews(t *testing.T) {
+	h := Start(t)
+	h.Apple.AddApp(appID, "Test App")
+	h.Apple.AddReviews(appID, "us", fakeReviews(fakeApplePageSize+2, 0, time.Now().Add(-2*time.Hour))...)
+	// the first sync fails after the first page, its reviews are notified once stored by the retry
+	h.Apple.FailPage(2, http.StatusForbidden)
+
+	var mu sync.Mutex
+	received := map[string]bool{}
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload models.WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("error decoding webhook payload: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, review := range payload.Reviews {
+			received[review.ID] = true
+		}
+	}))
+	t.Cleanup(hook.Close)
+
+	h.DoWithKey(t, h.APIKey, http.MethodPost, "/webhooks", map[string]any{"url": hook.URL}, http.StatusCreated, nil)
+	h.Do(t, http.MethodPost, "/apps/"+appID, http.StatusCreated, nil)
+
+	h.Eventually(t, timeout, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == fakeApplePageSize+2
+	}, "the webhook did not receive every stored review")
+
+	var pending int
+	if err := h.DB.QueryRow("SELECT COUNT(*) FROM webhook_outbox").Scan(&pending); err != nil {
+		t.Fatal(err)
+	}
+	if pending != 0 {
+		t.Errorf("got %d reviews in the webhook outbox, want none once enqueued", pending)
+	}
+}
+
+func TestSearchEscapesTheReviewsHTML(t *testing.T) {
+	h := Start(t)
+	h.Apple.AddApp(appID, "Test App")
+	h.Apple.AddReviews(appID, "us", FakeReview{
+		ID:      "1",
+		Author:  "author",
+		Title:   `<img src=x onerror="alert(1)"> crash`,
+		Content: "<script>alert(1)</script> the app crash on login",
+		Rating:  1,
+		Updated: time.Now().Add(-time.Hour),
+	})
+
+	h.Do(t, http.MethodPost, "/apps/"+appID, http.StatusCreated, nil)
+	h.Eventually(t, timeout, func() bool { return len(h.reviews(t)) == 1 }, "the review was not stored")
+
+	var results server.ResponseData[[]models.ReviewSearchResult]
+	h.Do(t, http.MethodGet, "/reviews/search?q=crash", http.StatusOK, &results)
+	if len(results.Data) != 1 {
+		t.Fatalf("got %d results, want the review", len(results.Data))
+	}
+	result := results.Data[0]
+	if want := "&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>crash</mark>"; result.TitleHighlight != want {
+		t.Errorf("got title highlight %q, want %q", result.TitleHighlight, want)
+	}
+	if want := "&lt;script&gt;alert(1)&lt;/script&gt; the app <mark>crash</mark> on login"; result.Snippet != want {
+		t.Errorf("got snippet %q, want %q", result.Snippet, want)
+	}
+	if result.Title != `<img src=x onerror="alert(1)"> crash` {
+		t.Errorf("got title %q, want the raw title", result.Title)
+	}
+}
+
+func TestBackfillStoresEveryPage(t *testing.T) {
+	h := Start(t, func(c *config.Config) {
+		// the polls only fetch the last day, older reviews are backfilled
+		c.ReviewsTimeLimit = 24 * time.Hour
+	})
+	h.Apple.AddApp(appID, "Test App")
+	h.Apple.AddReviews(appID, "us", fakeReviews(120, 0, time.Now().Add(-30*24*time.Hour))...)
+
+	h.Do(t, http.MethodPost, "/apps/"+appID, http.StatusCreated, nil)
+	h.Do(t, http.MethodPost, "/apps/"+appID+"/backfill", http.StatusAccepted, nil)
+
+	h.Eventually(t, timeout, func() bool {
+		var backfills server.ResponseData[[]models.Backfill]
+		h.Do(t, http.MethodGet, "/apps/"+appID+"/backfill", http.StatusOK, &backfills)
+		return len(backfills.Data) == 1 && backfills.Data[0].Status == models.BackfillCompleted
+	}, "the backfill did not complete")
+
+	var count int
+	if err := h.DB.QueryRow("SELECT COUNT(*) FROM reviews WHERE app_id = ?", appID).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 120 {
+		t.Errorf("got %d reviews, want the 120 reviews of the 3 pages", count)
+	}
+}
+
+func TestBackfillFailsOnceItsRetriesAreExhausted(t *testing.T) {
+	h := Start(t, func(c *config.Config) {
+		c.ReviewsTimeLimit = 24 * time.Hour
+		c.QueueMaxRetries = 1
+	})
+	h.Apple.AddApp(appID, "Test App")
+	h.Apple.AddReviews(appID, "us", fakeReviews(120, 0, time.Now().Add(-30*24*time.Hour))...)
+	// the second page fails on the first attempt and on its retry
+	h.Apple.FailPage(2, http.StatusForbidden, http.StatusForbidden)
+
+	h.Do(t, http.MethodPost, "/apps/"+appID, http.StatusCreated, nil)
+	h.Do(t, http.MethodPost, "/apps/"+appID+"/backfill", http.StatusAccepted, nil)
+
+	backfill := func() models.Backfill {
+		var backfills server.ResponseData[[]models.Backfill]
+		h.Do(t, http.MethodGet, "/apps/"+appID+"/backfill", http.StatusOK, &backfills)
+		return backfills.Data[0]
+	}
+	h.Eventually(t, timeout, func() bool { return backfill().Status == models.BackfillRetrying }, "the failed backfill was not retrying")
+	h.Eventually(t, timeout, func() bool { return backfill().Status == models.BackfillFailed }, "the backfill did not fail once its retries were exhausted")
+
+	if got := backfill(); got.Page != 1 || got.Error == "" {
+		t.Errorf("got backfill %+v, want it failed after the first page", got)
+	}
+}
+
+func TestBackfillFailsWhenItsAttemptsOutlastTheAckTimeout(t *testing.T) {
+	h := Start(t, func(c *config.Config) {
+		c.ReviewsTimeLimit = 24 * time.Hour
+		c.QueueMaxRetries = 1
+	})
+	h.Apple.AddApp(appID, "Test App")
+	h.Apple.AddReviews(appID, "us", fakeReviews(120, 0, time.Now().Add(-30*24*time.Hour))...)
+	// the first attempt fails on the second page after more than the ack timeout of a second,
+	// its nack must still count the attempt so the failed retry exhausts the retries
+	h.Apple.DelayPage(2, 2500*time.Millisecond)
+	h.Apple.FailPage(2, http.StatusForbidden, http.StatusForbidden)
+
+	h.Do(t, http.MethodPost, "/apps/"+appID, http.StatusCreated, nil)
+	h.Do(t, http.MethodPost, "/apps/"+appID+"/backfill", http.StatusAccepted, nil)
+
+	backfill := func() models.Backfill {
+		var backfills server.ResponseData[[]models.Backfill]
+		h.Do(t, http.MethodGet, "/apps/"+appID+"/backfill", http.StatusOK, &backfills)
+		return backfills.Data[0]
+	}
+	h.Eventually(t, timeout, func() bool { return backfill().Status == models.BackfillFailed }, "the backfill did not fail once its retries were exhausted")
+
+	if got := backfill(); got.Page != 1 || got.Error == "" {
+		t.Errorf("got backfill %+v, want it failed after the first page", got)
+	}
+}
+
+func TestAPIKeysScopes(t *testing.T) {
+	h := Start(t)
+	h.Apple.AddApp(appID, "Test App")
+
+	h.DoWithKey(t, "", http.MethodGet, "/apps", nil, http.StatusUnauthorized, nil)
+	h.DoWithKey(t, "ar_unknown", http.MethodGet, "/apps", nil, http.StatusUnauthorized, nil)
+
+	var created server.ResponseData[struct {
+		models.APIKey
+		Key string `json:"key"`
+	}]
+	h.DoWithKey(t, h.APIKey, http.MethodPost, "/api-keys", map[string]any{"name": "reader", "scopes": []string{"apps:read", "reviews:read"}}, http.StatusCreated, &created)
+	reader := created.Data.Key
+
+	h.DoWithKey(t, reader, http.MethodGet, "/apps", nil, http.StatusOK, nil)
+	h.DoWithKey(t, reader, http.MethodGet, "/reviews/"+appID, nil, http.StatusOK, nil)
+	h.DoWithKey(t, reader, http.MethodPost, "/apps/"+appID, nil, http.StatusForbidden, nil)
+	h.DoWithKey(t, reader, http.MethodGet, "/api-keys", nil, http.StatusForbidden, nil)
+
+	h.DoWithKey(t, h.APIKey, http.MethodPost, "/api-keys", map[string]any{"name": "unknown", "scopes": []string{"apps:delete"}}, http.StatusBadRequest, nil)
+
+	h.DoWithKey(t, h.APIKey, http.MethodDelete, fmt.Sprintf("/api-keys/%d", created.Data.ID), nil, http.StatusNoContent, nil)
+	h.DoWithKey(t, reader, http.MethodGet, "/apps", nil, http.StatusUnauthorized, nil)
+
+	var keys server.ResponseData[[]models.APIKey]
+	h.Do(t, http.MethodGet, "/api-keys", http.StatusOK, &keys)
+	if len(keys.Data) != 2 || keys.Data[1].RevokedAt == nil || keys.Data[0].LastUsedAt == nil {
+		t.Errorf("got keys %+v, want the used admin key and the revoked reader key", keys.Data)
+	}
+}
+
+func TestCORSAllowsTheConfiguredOrigins(t *testing.T) {
+	h := Start(t, func(c *config.Config) {
+		c.CORSAllowedOrigins = []string{"https://reviews.example.com"}
+	})
+
+	for origin, want := range map[string]string{
+		"https://reviews.example.com": "https://reviews.example.com",
+		"https://evil.example.com":    "",
+	} {
+		request := h.newRequest(t, http.MethodGet, "/apps", nil)
+		request.Header.Set("Authorization", "Bearer "+h.APIKey)
+		request.Header.Set("Origin", origin)
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+
+		if got := response.Header.Get("Access-Control-Allow-Origin"); got != want {
+			t.Errorf("got Access-Control-Allow-Origin %q for the origin %s, want %q", got, origin, want)
+		}
+	}
+}
+
+func TestUsersRolesAndSessions(t *testing.T) {
+	h := Start(t)
+	h.Apple.AddApp(appID, "Test App")
+
+	h.DoWithKey(t, h.APIKey, http.MethodPost, "/users", map[string]any{"username": "viewer", "password": "viewer-password", "role": "viewer"}, http.StatusCreated, nil)
+	h.DoWithKey(t, h.APIKey, http.MethodPost, "/users", map[string]any{"username": "editor", "password": "editor-password", "role": "editor"}, http.StatusCreated, nil)
+	h.DoWithKey(t, h.APIKey, http.MethodPost, "/users", map[string]any{"username": "Editor", "password": "editor-password", "role": "editor"}, http.StatusConflict, nil)
+
+	h.Login(t, "editor", "wrong-password", http.StatusUnauthorized)
+
+	viewer := h.Login(t, "viewer", "viewer-password", http.StatusOK)
+	viewer.Do(t, http.MethodGet, "/apps", nil, http.StatusOK, nil)
+	viewer.Do(t, http.MethodPost, "/apps/"+appID, nil, http.StatusForbidden, nil)
+	viewer.Do(t, http.MethodGet, "/users", nil, http.StatusForbidden, nil)
+
+	editor := h.Login(t, "editor", "editor-password", http.StatusOK)
+	csrfToken := editor.CSRFToken
+	editor.CSRFToken = ""
+	editor.Do(t, http.MethodPost, "/apps/"+appID, nil, http.StatusForbidden, nil)
+	editor.CSRFToken = csrfToken
+	editor.Do(t, http.MethodPost, "/apps/"+appID, nil, http.StatusCreated, nil)
+	editor.Do(t, http.MethodPost, "/apps/"+appID+"/pause", nil, http.StatusOK, nil)
+
+	var apps server.ResponseData[[]models.App]
+	h.Do(t, http.MethodGet, "/apps", http.StatusOK, &apps)
+	if len(apps.Data) != 1 || apps.Data[0].AddedBy != "editor" || apps.Data[0].StatusUpdatedBy != "editor" {
+		t.Errorf("got apps %+v, want the app added and paused by the editor", apps.Data)
+	}
+
+	var whoami server.ResponseData[struct {
+		User *models.User `json:"user"`
+	}]
+	editor.Do(t, http.MethodGet, "/auth/whoami", nil, http.StatusOK, &whoami)
+	if whoami.Data.User == nil || whoami.Data.User.Role != models.RoleEditor {
+		t.Errorf("got whoami %+v, want the editor", whoami.Data)
+	}
+
+	editor.Do(t, http.MethodPost, "/auth/logout", nil, http.StatusNoContent, nil)
+	editor.Do(t, http.MethodGet, "/apps", nil, http.StatusUnauthorized, nil)
+}
+
+func TestReviewsTriage(t *testing.T) {
+	h := Start(t)
+	h.Apple.AddApp(appID, "Test App")
+	h.Apple.AddReviews(appID, "us", fakeReviews(2, 0, time.Now().Add(-time.Hour))...)
+	other := h.NewWorkspace(t, "other")
+	outsider := h.NewWorkspace(t, "outsider")
+
+	h.Do(t, http.MethodPost, "/apps/"+appID, http.StatusCreated, nil)
+	h.DoWithKey(t, other, http.MethodPost, "/apps/"+appID, nil, http.StatusCreated, nil)
+	h.Eventually(t, timeout, func() bool { return len(h.reviews(t)) == 2 }, "the reviews were not stored")
+	reviewID := h.reviews(t)[0].ID
+	triagePath := "/reviews/" + appID + "/" + reviewID + "/triage"
+
+	h.DoWithKey(t, h.APIKey, http.MethodPost, "/users", map[string]any{"username": "viewer", "password": "viewer-password", "role": "viewer"}, http.StatusCreated, nil)
+	h.DoWithKey(t, h.APIKey, http.MethodPost, "/users", map[string]any{"username": "editor", "password": "editor-password", "role": "editor"}, http.StatusCreated, nil)
+	viewer := h.Login(t, "viewer", "viewer-password", http.StatusOK)
+	editor := h.Login(t, "editor", "editor-password", http.StatusOK)
+
+	viewer.Do(t, http.MethodPut, triagePath, map[string]any{"status": "resolved"}, http.StatusForbidden, nil)
+	editor.Do(t, http.MethodPut, triagePath, map[string]any{"status": "done"}, http.StatusBadRequest, nil)
+	editor.Do(t, http.MethodPut, "/reviews/"+appID+"/unknown/triage", map[string]any{"status": "resolved"}, http.StatusNotFound, nil)
+	h.DoWithKey(t, outsider, http.MethodPut, triagePath, map[string]any{"status": "resolved"}, http.StatusNotFound, nil)
+
+	var triage server.ResponseData[models.ReviewTriage]
+	editor.Do(t, http.MethodPut, triagePath, map[string]any{"status": "resolved"}, http.StatusOK, &triage)
+	if triage.Data.Status != models.TriageResolved || triage.Data.TriagedBy != "editor" {
+		t.Errorf("got triage %+v, want the review resolved by the editor", triage.Data)
+	}
+
+	// the listing has the triage of the workspace of the request only
+	reviews := h.reviews(t)
+	if reviews[0].Triage == nil || reviews[0].Triage.TriagedBy != "editor" || reviews[1].Triage != nil {
+		t.Errorf("got reviews %+v, want only the first one triaged by the editor", reviews)
+	}
+	var otherReviews server.ResponseData[[]models.Review]
+	h.DoWithKey(t, other, http.MethodGet, "/reviews/"+appID, nil, http.StatusOK, &otherReviews)
+	if len(otherReviews.Data) != 2 || otherReviews.Data[0].Triage != nil {
+		t.Errorf("got reviews %+v in the other workspace, want them untriaged", otherReviews.Data)
+	}
+
+	editor.Do(t, http.MethodDelete, triagePath, nil, http.StatusNoContent, nil)
+	if got := h.reviews(t)[0].Triage; got != nil {
+		t.Errorf("got triage %+v, want it cleared", got)
+	}
+}
+
+func TestLoginAttemptsAreLimited(t *testing.T) {
+	h := Start(t, func(c *config.Config) {
+		c.LoginAddrRateLimit = 4
+		c.LoginUsernameRateLimit = 2
+	})
+
+	h.DoWithKey(t, h.APIKey, http.MethodPost, "/users", map[string]any{"username": "viewer", "password": "viewer-password", "role": "viewer"}, http.StatusCreated, nil)
+
+	h.Login(t, "viewer", "wrong-password", http.StatusUnauthorized)
+	h.Login(t, "Viewer", "wrong-password", http.StatusUnauthorized)
+	// the username is out of attempts, even with the right password
+	h.Login(t, "viewer", "viewer-password", http.StatusTooManyRequests)
+
+	h.Login(t, "unknown", "wrong-password", http.StatusUnauthorized)
+	// the address is out of attempts, whatever the username
+	h.Login(t, "another", "wrong-password", http.StatusTooManyRequests)
+}
+
+func TestWorkspacesShareTheAppsAndIsolateTheirData(t *testing.T) {
+	h := Start(t)
+	h.Apple.AddApp(appID, "Test App")
+	h.Apple.AddReviews(appID, "us", fakeReviews(2, 0, time.Now().Add(-time.Hour))...)
+	h.Apple.AddReviews(appID, "gb", fakeReviews(1, 2, time.Now().Add(-time.Hour))...)
+	other := h.NewWorkspace(t, "other")
+	outsider := h.NewWorkspace(t, "outsider")
+
+	h.Do(t, http.MethodPost, "/apps/"+appID+"?countries=us", http.StatusCreated, nil)
+	h.DoWithKey(t, other, http.MethodPost, "/apps/"+appID+"?countries=gb", nil, http.StatusCreated, nil)
+
+	// the app is polled once with the storefronts of both workspaces, and each sees the reviews of its storefronts
+	var count int
+	h.Eventually(t, timeout, func() bool {
+		return h.DB.QueryRow("SELECT COUNT(*) FROM reviews").Scan(&count) == nil && count == 3
+	}, "the reviews of both storefronts were not stored")
+	if got := h.reviews(t); len(got) != 2 || got[0].Country != "us" || got[1].Country != "us" {
+		t.Errorf("got reviews %+v, want the 2 reviews of the us storefront", got)
+	}
+	var reviews server.ResponseData[[]models.Review]
+	h.DoWithKey(t, other, http.MethodGet, "/reviews/"+appID, nil, http.StatusOK, &reviews)
+	if len(reviews.Data) != 1 || reviews.Data[0].Country != "gb" {
+		t.Errorf("got reviews %+v in the other workspace, want the review of the gb storefront", reviews.Data)
+	}
+	var stats server.ResponseData[models.AppStats]
+	h.DoWithKey(t, other, http.MethodGet, "/apps/"+appID+"/stats", nil, http.StatusOK, &stats)
+	if stats.Data.Total.Count != 1 {
+		t.Errorf("got %d reviews in the stats of the other workspace, want 1", stats.Data.Total.Count)
+	}
+	var states server.ResponseData[[]models.SyncState]
+	h.DoWithKey(t, other, http.MethodGet, "/apps/"+appID+"/sync", nil, http.StatusOK, &states)
+	if len(states.Data) != 1 || states.Data[0].Country != "gb" {
+		t.Errorf("got sync states %+v in the other workspace, want the one of the gb storefront", states.Data)
+	}
+	_, err := h.DB.Exec(`INSERT INTO incidents (app_id, country, kind, message, value, baseline, window_start, window_end, detected_at)
+		VALUES (?, 'gb', 'one_star_spike', 'one-star reviews spiked', 4, 1, ?, ?, ?)`, appID, time.Now().Add(-time.Hour), time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var incidents server.ResponseData[[]models.Incident]
+	h.Do(t, http.MethodGet, "/apps/"+appID+"/incidents", http.StatusOK, &incidents)
+	if len(incidents.Data) != 0 {
+		t.Errorf("got incidents %+v, want none of the gb storefront", incidents.Data)
+	}
+	h.DoWithKey(t, other, http.MethodGet, "/apps/"+appID+"/incidents", nil, http.StatusOK, &incidents)
+	if len(incidents.Data) != 1 || incidents.Data[0].Country != "gb" {
+		t.Errorf("got incidents %+v in the other workspace, want the one of the gb storefront", incidents.Data)
+	}
+	if err := h.DB.QueryRow("SELECT COUNT(*) FROM apps").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("got %d apps, want the app stored once", count)
+	}
+
+	// pausing the app in a workspace leaves it polled for the other one
+	h.DoWithKey(t, other, http.MethodPost, "/apps/"+appID+"/pause", nil, http.StatusOK, nil)
+	var apps server.ResponseData[[]models.App]
+	h.Do(t, http.MethodGet, "/apps", http.StatusOK, &apps)
+	if len(apps.Data) != 1 || apps.Data[0].Status != models.AppStatusActive || apps.Data[0].Countries[0] != "us" {
+		t.Errorf("got apps %+v, want the app active with its own storefronts", apps.Data)
+	}
+
+	// the workspace not watching the app sees none of it
+	h.DoWithKey(t, outsider, http.MethodGet, "/apps", nil, http.StatusOK, &apps)
+	if len(apps.Data) != 0 {
+		t.Errorf("got apps %+v in the outsider workspace, want none", apps.Data)
+	}
+	h.DoWithKey(t, outsider, http.MethodGet, "/reviews/"+appID, nil, http.StatusOK, &reviews)
+	if len(reviews.Data) != 0 {
+		t.Errorf("got %d reviews in the outsider workspace, want none", len(reviews.Data))
+	}
+	h.DoWithKey(t, outsider, http.MethodGet, "/apps/"+appID+"/stats", nil, http.StatusNotFound, nil)
+	h.DoWithKey(t, outsider, http.MethodPost, "/apps/"+appID+"/pause", nil, http.StatusNotFound, nil)
+
+	var webhook server.ResponseData[models.WebhookSubscription]
+	h.DoWithKey(t, h.APIKey, http.MethodPost, "/webhooks", map[string]any{"url": "https://example.com/hook"}, http.StatusCreated, &webhook)
+	h.DoWithKey(t, outsider, http.MethodGet, fmt.Sprintf("/webhooks/%d", webhook.Data.ID), nil, http.StatusNotFound, nil)
+	h.DoWithKey(t, outsider, http.MethodDelete, fmt.Sprintf("/webhooks/%d", webhook.Data.ID), nil, http.StatusNotFound, nil)
+
+	var user server.ResponseData[models.User]
+	h.DoWithKey(t, h.APIKey, http.MethodPost, "/users", map[string]any{"username": "viewer", "password": "viewer-password", "role": "viewer"}, http.StatusCreated, &user)
+	h.DoWithKey(t, outsider, http.MethodDelete, fmt.Sprintf("/users/%d", user.Data.ID), nil, http.StatusNotFound, nil)
+	var users server.ResponseData[[]models.User]
+	h.DoWithKey(t, outsider, http.MethodGet, "/users", nil, http.StatusOK, &users)
+	if len(users.Data) != 0 {
+		t.Errorf("got users %+v in the outsider workspace, want none", users.Data)
+	}
+
+	var keys server.ResponseData[[]models.APIKey]
+	h.Do(t, http.MethodGet, "/api-keys", http.StatusOK, &keys)
+	h.DoWithKey(t, outsider, http.MethodDelete, fmt.Sprintf("/api-keys/%d", keys.Data[0].ID), nil, http.StatusNotFound, nil)
+	h.Do(t, http.MethodGet, "/apps", http.StatusOK, nil)
+}
+
+func TestStreamSendsTheNewReviews(t *testing.T) {
+	h := Start(t)
+	h.Apple.AddApp(appID, "Test App")
+	h.Apple.AddReviews(appID, "us", fakeReviews(2, 0, time.Now().Add(-time.Hour))...)
+	outsider := h.NewWorkspace(t, "outsider")
+
+	h.Do(t, http.MethodPost, "/apps/"+appID, http.StatusCreated, nil)
+	h.Eventually(t, timeout, func() bool { return len(h.reviews(t)) == 2 }, "the reviews of the app were not stored")
+
+	// a new stream only sends the reviews stored after it was opened, a reconnecting one resumes after its last event
+	live := h.Stream(t, "/reviews/stream", "")
+	resumed := h.Stream(t, "/reviews/"+appID+"/stream", "1")
+
+	event := resumed.Next(t, timeout)
+	var review models.Review
+	if err := json.Unmarshal([]byte(event.Data), &review); err != nil || event.ID != "2" || event.Event != "review" || review.AppID != appID {
+		t.Errorf("got event %+v, want the second review of the app", event)
+	}
+
+	h.Apple.AddReviews(appID, "us", fakeReviews(1, 2, time.Now().Add(-time.Hour))...)
+	for _, stream := range []*Stream{live, resumed} {
+		event := stream.Next(t, timeout)
+		if err := json.Unmarshal([]byte(event.Data), &review); err != nil || event.ID != "3" || review.ID != "2" {
+			t.Errorf("got event %+v, want the new review 2", event)
+		}
+	}
+
+	h.DoWithKey(t, outsider, http.MethodGet, "/reviews/"+appID+"/stream", nil, http.StatusNotFound, nil)
+	h.DoWithKey(t, h.APIKey, http.MethodGet, "/reviews/"+appID+"/stream?last_event_id=last", nil, http.StatusBadRequest, nil)
+}
+
+func TestSearchAndAddAppsByURLOrBundleID(t *testing.T) {
+	h := Start(t)
+	h.Apple.AddApp(appID, "Test App")
+	h.Apple.AddApp("123", "Another App")
+	h.Apple.AddAppWithBundleID("456", "Hyphenated App", "com.my-company.2app")
+
+	var results server.ResponseData[[]models.AppSearchResult]
+	h.Do(t, http.MethodGet, "/apps/search?q=test", http.StatusOK, &results)
+	if len(results.Data) != 1 || results.Data[0].ID != appID || results.Data[0].Developer == "" || results.Data[0].Rating == 0 {
+		t.Errorf("got results %+v, want the Test App", results.Data)
+	}
+
+	h.Do(t, http.MethodPost, "/apps?app="+url.QueryEscape("https://apps.apple.com/us/app/test-app/id"+appID), http.StatusCreated, nil)
+	h.Do(t, http.MethodPost, "/apps/"+FakeBundleID("123"), http.StatusCreated, nil)
+	h.Do(t, http.MethodPost, "/apps/com.my-company.2app", http.StatusCreated, nil)
+
+	var apps server.ResponseData[[]models.App]
+	h.Do(t, http.MethodGet, "/apps", http.StatusOK, &apps)
+	ids := map[string]bool{}
+	for _, app := range apps.Data {
+		ids[app.ID] = true
+	}
+	if len(apps.Data) != 3 || !ids[appID] || !ids["123"] || !ids["456"] {
+		t.Errorf("got apps %+v, want the apps of the URL and the bundle IDs", apps.Data)
+	}
+
+	h.Do(t, http.MethodPost, "/apps/"+FakeBundleID("404"), http.StatusNotFound, nil)
+	h.Do(t, http.MethodPost, "/apps?app="+url.QueryEscape("https://example.com/app/id"+appID), http.StatusBadRequest, nil)
+	h.Do(t, http.MethodGet, "/apps/search?q=test&platform=android", http.StatusBadRequest, nil)
+}
@@ -0,0 +1,292 @@
+package e2e
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/pkg/apple"
+)
+
+// fakeApplePageSize is the number of reviews of a page of the fake reviews feed, as on the App Store.
+const fakeApplePageSize = 50
+
+// fakeBundleIDPrefix is the prefix of the bundle IDs of the apps of the fake App Store, followed by their ID.
+const fakeBundleIDPrefix = "com.example.app"
+
+// feedPathRegexp matches the path of a page of the reviews feed: the country, the optional page and the app id.
+var feedPathRegexp = regexp.MustCompile(`^/([a-z]{2})/rss/customerreviews/(?:page=(\d+)/)?id=([^/]+)/sortBy=mostRecent/(?:json|xml)$`)
+
+// FakeReview is a review of the fake App Store.
+type FakeReview struct {
+	ID      string
+	Author  string
+	Title   string
+	Content string
+	Rating  int
+	Updated time.Time
+}
+
+// FakeApple is a fake of the App Store lookup and search APIs and reviews feeds,
+// answering the conditional requests of the feeds with 304 Not Modified when they did not change.
+type FakeApple struct {
+	mu      sync.Mutex
+	apps    map[string]string
+	reviews map[string][]FakeReview
+	// bundleIDs are the bundle IDs of the apps added with one, the other apps have FakeBundleID.
+	bundleIDs map[string]string
+	// failures are the status codes of the next responses, answered before any other.
+	failures []int
+	// pageFailures are the status codes of the next requests of a page of the reviews feeds.
+	pageFailures map[int][]int
+	// pageDelays are how long the next requests of a page of the reviews feeds wait before being answered.
+	pageDelays map[int][]time.Duration
+
+	requests    int
+	notModified int
+}
+
+func NewFakeApple() *FakeApple {
+	return &FakeApple{apps: map[string]string{}, reviews: map[string][]FakeReview{}, bundleIDs: map[string]string{}, pageFailures: map[int][]int{}, pageDelays: map[int][]time.Duration{}}
+}
+
+// AddApp adds an app to the store, its bundle ID is FakeBundleID(appID).
+func (f *FakeApple) AddApp(appID string, name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.apps[appID] = name
+}
+
+// AddAppWithBundleID adds an app to the store with the given bundle ID.
+func (f *FakeApple) AddAppWithBundleID(appID string, name string, bundleID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.apps[appID] = name
+	f.bundleIDs[appID] = bundleID
+}
+
+// AddReviews adds reviews of the app to the country storefront, they are listed most recent first.
+func (f *FakeApple) AddReviews(appID string, country string, reviews ...FakeReview) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := appID + "/" + country
+	for _, review := range reviews {
+		i := 0
+		for i < len(f.reviews[key]) && !f.reviews[key][i].Updated.Before(review.Updated) {
+			i++
+		}
+		f.reviews[key] = append(f.reviews[key][:i], append([]FakeReview{review}, f.reviews[key][i:]...)...)
+	}
+}
+
+// Fail answers the next requests with the given status codes, one per request.
+func (f *FakeApple) Fail(statusCodes ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = append(f.failures, statusCodes...)
+}
+
+// FailPage answers the next requests of the page of the reviews feeds with the given status codes, one per request.
+func (f *FakeApple) FailPage(page int, statusCodes ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pageFailures[page] = append(f.pageFailures[page], statusCodes...)
+}
+
+// DelayPage delays the next requests of the page of the reviews feeds by the given durations, one per request.
+// The delay comes before the failures of FailPage.
+func (f *FakeApple) DelayPage(page int, delays ...time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pageDelays[page] = append(f.pageDelays[page], delays...)
+}
+
+// Requests returns the number of requests received.
+func (f *FakeApple) Requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.requests
+}
+
+// NotModified returns the number of requests answered with 304 Not Modified.
+func (f *FakeApple) NotModified() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.notModified
+}
+
+func (f *FakeApple) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests++
+
+	if len(f.failures) > 0 {
+		statusCode := f.failures[0]
+		f.failures = f.failures[1:]
+		http.Error(w, http.StatusText(statusCode), statusCode)
+		return
+	}
+
+	if r.URL.Path == "/lookup" {
+		appID := r.URL.Query().Get("id")
+		if bundleID := r.URL.Query().Get("bundleId"); bundleID != "" {
+			appID = f.appIDOfBundleID(bundleID)
+		}
+		f.serveLookup(w, appID)
+		return
+	}
+
+	if r.URL.Path == "/search" {
+		f.serveSearch(w, r.URL.Query().Get("term"))
+		return
+	}
+
+	if match := feedPathRegexp.FindStringSubmatch(r.URL.Path); match != nil {
+		page := 1
+		if match[2] != "" {
+			page, _ = strconv.Atoi(match[2])
+		}
+		if delays := f.pageDelays[page]; len(delays) > 0 {
+			f.pageDelays[page] = delays[1:]
+			// the other requests are answered meanwhile
+			f.mu.Unlock()
+			time.Sleep(delays[0])
+			f.mu.Lock()
+		}
+		if failures := f.pageFailures[page]; len(failures) > 0 {
+			f.pageFailures[page] = failures[1:]
+			http.Error(w, http.StatusText(failures[0]), failures[0])
+			return
+		}
+		f.serveFeed(w, r, match[3], match[1], page)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// serveLookup answers the lookup of an app, without results if the app does not exist.
+func (f *FakeApple) serveLookup(w http.ResponseWriter, appID string) {
+	response := apple.AppsResponse{}
+	if name, ok := f.apps[appID]; ok {
+		response.ResultCount = 1
+		response.Results[0] = f.app(appID, name)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// serveSearch answers the search of the apps whose name contains the term, ignoring the case.
+func (f *FakeApple) serveSearch(w http.ResponseWriter, term string) {
+	response := apple.SearchResponse{Results: []apple.App{}}
+	for appID, name := range f.apps {
+		if strings.Contains(strings.ToLower(name), strings.ToLower(term)) {
+			response.Results = append(response.Results, f.app(appID, name))
+		}
+	}
+	response.ResultCount = len(response.Results)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// FakeBundleID returns the bundle ID of the app of the fake App Store.
+func FakeBundleID(appID string) string {
+	return fakeBundleIDPrefix + appID
+}
+
+// appIDOfBundleID returns the ID of the app with the bundle ID, its own one or FakeBundleID.
+func (f *FakeApple) appIDOfBundleID(bundleID string) string {
+	for appID, id := range f.bundleIDs {
+		if id == bundleID {
+			return appID
+		}
+	}
+	return strings.TrimPrefix(bundleID, fakeBundleIDPrefix)
+}
+
+// app returns the lookup and search result of an app of the fake App Store.
+func (f *FakeApple) app(appID string, name string) apple.App {
+	bundleID, ok := f.bundleIDs[appID]
+	if !ok {
+		bundleID = FakeBundleID(appID)
+	}
+
+	trackID, _ := strconv.Atoi(appID)
+	return apple.App{
+		TrackID:           trackID,
+		TrackName:         name,
+		TrackViewURL:      "https://apps.apple.com/us/app/id" + appID,
+		BundleID:          bundleID,
+		ArtistName:        "Example Inc.",
+		ArtworkURL512:     "https://example.com/" + appID + ".png",
+		AverageUserRating: 4.5,
+		UserRatingCount:   10,
+	}
+}
+
+// serveFeed answers a page of the reviews feed of the app storefront.
+func (f *FakeApple) serveFeed(w http.ResponseWriter, r *http.Request, appID string, country string, page int) {
+	if _, ok := f.apps[appID]; !ok || page < 1 || page > apple.MaxReviewsPages {
+		http.NotFound(w, r)
+		return
+	}
+
+	reviews := f.reviews[appID+"/"+country]
+	start := min((page-1)*fakeApplePageSize, len(reviews))
+	end := min(start+fakeApplePageSize, len(reviews))
+
+	entries := []map[string]any{}
+	for _, review := range reviews[start:end] {
+		entries = append(entries, map[string]any{
+			"id":        label(review.ID),
+			"author":    map[string]any{"name": label(review.Author), "uri": label("https://example.com/" + review.Author)},
+			"title":     label(review.Title),
+			"content":   label(review.Content),
+			"im:rating": label(strconv.Itoa(review.Rating)),
+			"updated":   label(review.Updated.Format(apple.AppleTimeFormat)),
+		})
+	}
+
+	links := []map[string]any{}
+	if end < len(reviews) && page < apple.MaxReviewsPages {
+		// the store links the next page as XML
+		next := fmt.Sprintf("https://itunes.apple.com/%s/rss/customerreviews/page=%d/id=%s/sortBy=mostRecent/xml", country, page+1, appID)
+		links = append(links, map[string]any{"attributes": map[string]any{"rel": "next", "href": next}})
+	}
+
+	body, _ := json.Marshal(map[string]any{"feed": map[string]any{"entry": entries, "link": links}})
+	hash := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(hash[:8]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		f.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func label(value string) map[string]string {
+	return map[string]string{"label": value}
+}
@@ -0,0 +1,462 @@
+// Package e2e runs the server, scheduler and consumer in process against temporary SQLite files
+// and a fake App Store, to test the flows across the services without network access.
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/apikeys"
+	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/backfills"
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/consumer"
+	"github.com/renantatsuo/app-review/server/internal/db"
+	"github.com/renantatsuo/app-review/server/internal/dbtest"
+	"github.com/renantatsuo/app-review/server/internal/httpcache"
+	"github.com/renantatsuo/app-review/server/internal/incidents"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/queue"
+	"github.com/renantatsuo/app-review/server/internal/reviews"
+	"github.com/renantatsuo/app-review/server/internal/scheduler"
+	"github.com/renantatsuo/app-review/server/internal/server"
+	"github.com/renantatsuo/app-review/server/internal/sources"
+	"github.com/renantatsuo/app-review/server/internal/users"
+	"github.com/renantatsuo/app-review/server/internal/webhooks"
+	"github.com/renantatsuo/app-review/server/internal/workspaces"
+	"github.com/renantatsuo/app-review/server/pkg/apple"
+	"github.com/renantatsuo/app-review/server/pkg/googleplay"
+)
+
+// Harness is a running set of services sharing temporary databases and a fake App Store.
+type Harness struct {
+	// URL is the base URL of the API.
+	URL    string
+	Apple  *FakeApple
+	Config config.Config
+	// APIKey is an admin key, sent with the requests of Do.
+	APIKey string
+	// DB is a connection to the database of the services, to assert on what they stored.
+	DB *sql.DB
+
+	logger *slog.Logger
+}
+
+// Start migrates temporary databases and starts the server, scheduler and consumer,
+// with short intervals so the polls happen within the test. They are stopped when the test ends,
+// and their logs are printed if it failed.
+// The config can be adjusted with the configure functions before the services start.
+func Start(t testing.TB, configure ...func(*config.Config)) *Harness {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	config, err := config.LoadConfigFromEnv()
+	if err != nil {
+		t.Fatalf("error loading config: %v", err)
+	}
+	config.DatabaseConnStr = filepath.Join(dir, "database.db")
+	config.QueueConnStr = filepath.Join(dir, "queue.db")
+	config.DeadLetterConnStr = filepath.Join(dir, "dead_letter.db")
+	config.WebhookQueueConnStr = filepath.Join(dir, "webhook_queue.db")
+	config.SchedulerTick = 50 * time.Millisecond
+	config.StreamPollInterval = 50 * time.Millisecond
+	config.WebhookOutboxInterval = 50 * time.Millisecond
+	config.PollingInterval = 200 * time.Millisecond
+	config.MinPollingInterval = 200 * time.Millisecond
+	config.QueueRetryBackoff = 100 * time.Millisecond
+	config.QueueAckTimeout = time.Second
+	config.ConsumerWorkers = 2
+	config.AppleRateLimit = 0
+	config.AppleRetryBackoff = 10 * time.Millisecond
+	config.DigestSlackWebhookURL, config.DigestEmailTo = "", nil
+	config.IncidentSlackWebhookURL, config.IncidentEmailTo = "", nil
+	for _, fn := range configure {
+		fn(&config)
+	}
+
+	if err := dbtest.Migrate(t, config.DatabaseConnStr); err != nil {
+		t.Fatalf("error migrating database: %v", err)
+	}
+
+	logs := &syncBuffer{}
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Logf("services logs:\n%s", logs.String())
+		}
+	})
+	logger := slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	fakeApple := NewFakeApple()
+	appleServer := httptest.NewServer(fakeApple)
+	t.Cleanup(appleServer.Close)
+	target, _ := url.Parse(appleServer.URL)
+	httpClient := &http.Client{Transport: &redirectTransport{target: target}, Timeout: 5 * time.Second}
+
+	// like the services, each one has its own connections to the databases
+	newSources := func(db *sql.DB) sources.Sources {
+		appleClient := apple.New(
+			apple.WithHTTPClient(httpClient),
+			apple.WithRateLimit(config.AppleRateLimit, config.AppleRateBurst),
+			apple.WithRetries(config.AppleMaxRetries, config.AppleRetryBackoff, config.AppleMaxRetryWait),
+			apple.WithCache(httpcache.New(db)),
+		)
+		return sources.New(sources.NewAppleSource(appleClient), sources.NewGooglePlaySource(googleplay.New(googleplay.WithHTTPClient(httpClient))))
+	}
+	connect := func() *sql.DB {
+		db := db.New(config.DatabaseConnStr).Connect()
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// server
+	serverDB := connect()
+	serverSources := newSources(serverDB)
+	serverQueue := queue.New(config.QueueConnStr, queue.WithUnique(config.QueueUnique))
+	serverReviews := reviews.New(logger, serverSources, serverDB, config)
+	tailer := reviews.NewTailer(logger, serverReviews, config)
+	tailer.Start(ctx)
+	s := server.New(config.Port, logger.With("service", "server"),
+		serverReviews, tailer,
+		apps.New(serverDB, serverSources),
+		webhooks.New(logger, serverDB),
+		incidents.New(logger, serverDB),
+		backfills.New(logger, serverDB),
+		apikeys.New(logger, serverDB),
+		users.New(logger, serverDB),
+		workspaces.New(logger, serverDB),
+		serverQueue, config)
+	apiServer := httptest.NewServer(s.Handler())
+
+	// scheduler
+	schedulerDB := connect()
+	schedulerQueue := queue.New(config.QueueConnStr, queue.WithUnique(config.QueueUnique))
+	scheduler.New(logger.With("service", "scheduler"), apps.New(schedulerDB, newSources(schedulerDB)), schedulerQueue, config).Start(ctx)
+
+	// consumer
+	consumerLogger := logger.With("service", "consumer")
+	consumerDB := connect()
+	consumerSources := newSources(consumerDB)
+	deadLetter := queue.NewDeadLetter(config.DeadLetterConnStr)
+	webhookQueue := queue.New(config.WebhookQueueConnStr,
+		queue.WithMaxRetries(config.WebhookMaxRetries),
+		queue.WithRetryBackoff(config.WebhookRetryBackoff),
+		queue.WithAckTimeout(config.QueueAckTimeout),
+	)
+	consumerQueue := queue.New(config.QueueConnStr,
+		queue.WithMaxRetries(config.QueueMaxRetries),
+		queue.WithRetryBackoff(config.QueueRetryBackoff),
+		queue.WithAckTimeout(config.QueueAckTimeout),
+		queue.WithDeadLetter(deadLetter),
+		queue.WithUnique(config.QueueUnique),
+	)
+	reviewsClient := reviews.New(consumerLogger, consumerSources, consumerDB, config)
+	dispatcher := webhooks.NewDispatcher(consumerLogger, webhooks.New(consumerLogger, consumerDB), webhookQueue, config)
+	dispatcher.Start(ctx)
+	incidentsClient := incidents.New(consumerLogger, consumerDB)
+	detector := incidents.NewDetector(consumerLogger, incidentsClient, reviewsClient, apps.New(consumerDB, consumerSources), workspaces.New(consumerLogger, consumerDB), config)
+	consumer := consumer.New(consumerLogger, consumerQueue, config, reviewsClient, backfills.New(consumerLogger, consumerDB), detector)
+	consumer.Start(ctx)
+
+	t.Cleanup(func() {
+		apiServer.Close()
+		cancel()
+		consumer.Wait()
+		dispatcher.Wait()
+		consumerQueue.Close()
+		webhookQueue.Close()
+		deadLetter.Close()
+		schedulerQueue.Close()
+		serverQueue.Close()
+	})
+
+	h := &Harness{
+		URL:    apiServer.URL,
+		Apple:  fakeApple,
+		Config: config,
+		DB:     connect(),
+		logger: logger,
+	}
+
+	_, h.APIKey, err = apikeys.New(logger, h.DB).CreateAPIKey(models.DefaultWorkspaceID, "e2e", []models.Scope{models.ScopeAdmin})
+	if err != nil {
+		t.Fatalf("error creating api key: %v", err)
+	}
+
+	return h
+}
+
+// NewWorkspace creates a workspace and returns an admin key of it, as the workspace and apikey commands do.
+func (h *Harness) NewWorkspace(t testing.TB, name string) string {
+	t.Helper()
+
+	workspace, err := workspaces.New(h.logger, h.DB).CreateWorkspace(name)
+	if err != nil {
+		t.Fatalf("error creating workspace: %v", err)
+	}
+
+	_, key, err := apikeys.New(h.logger, h.DB).CreateAPIKey(workspace.ID, name, []models.Scope{models.ScopeAdmin})
+	if err != nil {
+		t.Fatalf("error creating api key: %v", err)
+	}
+
+	return key
+}
+
+// Do sends a request authenticated with the admin key to the API and decodes its JSON response into v, unless v is nil.
+// It fails the test if the request fails or the response status code is not the expected one.
+func (h *Harness) Do(t testing.TB, method string, path string, statusCode int, v any) {
+	t.Helper()
+
+	h.DoWithKey(t, h.APIKey, method, path, nil, statusCode, v)
+}
+
+// DoWithKey sends a request with the body, authenticated with the given key unless it is empty, and decodes its JSON response into v, unless v is nil.
+// It fails the test if the request fails or the response status code is not the expected one.
+func (h *Harness) DoWithKey(t testing.TB, key string, method string, path string, body any, statusCode int, v any) {
+	t.Helper()
+
+	request := h.newRequest(t, method, path, body)
+	if key != "" {
+		request.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	send(t, http.DefaultClient, request, statusCode, v)
+}
+
+// Session is a browser-like client of a logged in user, keeping the session cookie.
+type Session struct {
+	h      *Harness
+	client *http.Client
+	// CSRFToken is sent in the X-CSRF-Token header of the requests, unless it is empty.
+	CSRFToken string
+}
+
+// Login logs the user in and returns its session, failing the test unless the login returns the expected status code.
+func (h *Harness) Login(t testing.TB, username string, password string, statusCode int) *Session {
+	t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("error creating cookie jar: %v", err)
+	}
+	session := &Session{h: h, client: &http.Client{Jar: jar}}
+
+	var res server.ResponseData[struct {
+		CSRFToken string `json:"csrf_token"`
+	}]
+	request := h.newRequest(t, http.MethodPost, "/auth/login", map[string]string{"username": username, "password": password})
+	request.Header.Set("Content-Type", "application/json")
+	if statusCode != http.StatusOK {
+		send(t, session.client, request, statusCode, nil)
+		return nil
+	}
+	send(t, session.client, request, statusCode, &res)
+	session.CSRFToken = res.Data.CSRFToken
+
+	return session
+}
+
+// Do sends a request with the body in the session and decodes its JSON response into v, unless v is nil.
+// It fails the test if the request fails or the response status code is not the expected one.
+func (s *Session) Do(t testing.TB, method string, path string, body any, statusCode int, v any) {
+	t.Helper()
+
+	request := s.h.newRequest(t, method, path, body)
+	if s.CSRFToken != "" {
+		request.Header.Set("X-CSRF-Token", s.CSRFToken)
+	}
+
+	send(t, s.client, request, statusCode, v)
+}
+
+// Event is a Server-Sent Event of a stream.
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// Stream is an open Server-Sent Events stream of the API.
+type Stream struct {
+	reader *bufio.Reader
+}
+
+// Stream opens the Server-Sent Events stream of the path authenticated with the admin key,
+// resuming after lastEventID unless it is empty. It is closed when the test ends.
+func (h *Harness) Stream(t testing.TB, path string, lastEventID string) *Stream {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	request := h.newRequest(t, http.MethodGet, path, nil).WithContext(ctx)
+	request.Header.Set("Authorization", "Bearer "+h.APIKey)
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		cancel()
+		t.Fatalf("error requesting %s: %v", path, err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		response.Body.Close()
+	})
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s returned %d, want %d", path, response.StatusCode, http.StatusOK)
+	}
+
+	return &Stream{reader: bufio.NewReader(response.Body)}
+}
+
+// Next returns the next event of the stream, skipping the comments.
+// It fails the test if no event is received within the timeout.
+func (s *Stream) Next(t testing.TB, timeout time.Duration) Event {
+	t.Helper()
+
+	events := make(chan Event, 1)
+	errs := make(chan error, 1)
+	go func() {
+		var event Event
+		for {
+			line, err := s.reader.ReadString('\n')
+			if err != nil {
+				errs <- err
+				return
+			}
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" && event != (Event{}) {
+				events <- event
+				return
+			}
+
+			field, value, _ := strings.Cut(line, ": ")
+			switch field {
+			case "id":
+				event.ID = value
+			case "event":
+				event.Event = value
+			case "data":
+				event.Data = value
+			}
+		}
+	}()
+
+	select {
+	case event := <-events:
+		return event
+	case err := <-errs:
+		t.Fatalf("error reading the stream: %v", err)
+	case <-time.After(timeout):
+		t.Fatalf("no event received within %s", timeout)
+	}
+	return Event{}
+}
+
+// newRequest creates a request to the API with the body encoded as JSON, unless it is nil.
+func (h *Harness) newRequest(t testing.TB, method string, path string, body any) *http.Request {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("error encoding request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequest(method, h.URL+path, reader)
+	if err != nil {
+		t.Fatalf("error creating request: %v", err)
+	}
+
+	return request
+}
+
+// send sends the request and decodes its JSON response into v, unless v is nil.
+// It fails the test if the request fails or the response status code is not the expected one.
+func send(t testing.TB, client *http.Client, request *http.Request, statusCode int, v any) {
+	t.Helper()
+
+	method, path := request.Method, request.URL.Path
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("error requesting %s %s: %v", method, path, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != statusCode {
+		body := &bytes.Buffer{}
+		body.ReadFrom(response.Body)
+		t.Fatalf("%s %s returned %d, want %d: %s", method, path, response.StatusCode, statusCode, body)
+	}
+
+	if v != nil {
+		if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+			t.Fatalf("error decoding the response of %s %s: %v", method, path, err)
+		}
+	}
+}
+
+// Eventually calls condition until it returns true, failing the test if it does not within the timeout.
+func (h *Harness) Eventually(t testing.TB, timeout time.Duration, condition func() bool, message string) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out after %s: %s", timeout, message)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// redirectTransport sends the requests to the stores to the target server instead.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t *redirectTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	request = request.Clone(request.Context())
+	request.URL.Scheme = t.target.Scheme
+	request.URL.Host = t.target.Host
+	request.Host = ""
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+// syncBuffer is a buffer the services write their logs to concurrently.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
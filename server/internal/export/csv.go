@@ -0,0 +1,44 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// csvWriter writes the reviews as CSV, with a header row.
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	c := &csvWriter{w: csv.NewWriter(w)}
+	if err := c.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *csvWriter) Write(review models.Review) error {
+	fields := record(review)
+	for i, field := range fields {
+		fields[i] = escapeFormula(field)
+	}
+	return c.w.Write(fields)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula prefixes the fields that spreadsheets would evaluate as formulas with a quote,
+// so a review cannot run a formula when the export is opened.
+func escapeFormula(field string) string {
+	if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
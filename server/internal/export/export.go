@@ -0,0 +1,79 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// Format is the file format of a reviews export.
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+	FormatXLSX   Format = "xlsx"
+)
+
+// columns are the header of the tabular formats, in the order of record.
+var columns = []string{"id", "app_id", "country", "author", "title", "content", "rating", "sent_at"}
+
+// Valid reports whether the format is a known export format.
+func (f Format) Valid() bool {
+	switch f {
+	case FormatCSV, FormatNDJSON, FormatXLSX:
+		return true
+	}
+	return false
+}
+
+// ContentType is the MIME type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+// Writer writes reviews to an export file, one at a time.
+// Close must be called after the last review to complete the file,
+// it does not close the underlying writer.
+type Writer interface {
+	Write(review models.Review) error
+	Close() error
+}
+
+// NewWriter creates a Writer of the given format writing to w.
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	}
+	return nil, fmt.Errorf("unknown export format %q, must be csv, ndjson or xlsx", format)
+}
+
+// record returns the fields of the review in the order of columns.
+func record(review models.Review) []string {
+	return []string{
+		review.ID,
+		review.AppID,
+		review.Country,
+		review.Author,
+		review.Title,
+		review.Content,
+		strconv.Itoa(review.Rating),
+		review.SentAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,174 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+var testReviews = []models.Review{
+	{
+		ID:      "1",
+		AppID:   "1458862350",
+		Country: "us",
+		Author:  "=cmd|' /C calc'!A0",
+		Title:   "+1 great <app> & more",
+		Content: "-2 crashes\x01 on launch",
+		Rating:  5,
+		SentAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	},
+	{
+		ID:      "2",
+		AppID:   "1458862350",
+		Country: "gb",
+		Author:  "@SUM(A1:A2)",
+		Title:   "\tindented",
+		Content: "fine",
+		Rating:  1,
+		SentAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.FixedZone("", 3600)),
+	},
+}
+
+func export(t *testing.T, format Format) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer, err := NewWriter(format, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	for _, review := range testReviews {
+		if err := writer.Write(review); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestCSVEscapesFormulas(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(export(t, FormatCSV))).ReadAll()
+	if err != nil {
+		t.Fatalf("error reading the CSV: %v", err)
+	}
+
+	want := [][]string{
+		columns,
+		{"1", "1458862350", "us", "'=cmd|' /C calc'!A0", "'+1 great <app> & more", "'-2 crashes\x01 on launch", "5", "2024-01-02T03:04:05Z"},
+		{"2", "1458862350", "gb", "'@SUM(A1:A2)", "'\tindented", "fine", "1", "2023-12-31T23:00:00Z"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i := range want {
+		if !slices.Equal(records[i], want[i]) {
+			t.Errorf("record %d = %q, want %q", i, records[i], want[i])
+		}
+	}
+}
+
+func TestNDJSONRoundTrip(t *testing.T) {
+	decoder := json.NewDecoder(bytes.NewReader(export(t, FormatNDJSON)))
+
+	for _, want := range testReviews {
+		var got models.Review
+		if err := decoder.Decode(&got); err != nil {
+			t.Fatalf("error decoding review %s: %v", want.ID, err)
+		}
+		if got.ID != want.ID || got.Author != want.Author || got.Content != want.Content || !got.SentAt.Equal(want.SentAt) {
+			t.Errorf("got review %+v, want %+v", got, want)
+		}
+	}
+	if decoder.More() {
+		t.Error("got more reviews than written")
+	}
+}
+
+// xlsxSheet is the part of a worksheet read back by the tests.
+type xlsxSheet struct {
+	Rows []struct {
+		Ref   int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	data := export(t, FormatXLSX)
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("error opening the XLSX as a zip archive: %v", err)
+	}
+
+	parts := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("error opening %s: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("error reading %s: %v", f.Name, err)
+		}
+		parts[f.Name] = content
+	}
+
+	for _, part := range xlsxParts {
+		if string(parts[part.name]) != part.content {
+			t.Errorf("part %s = %q, want %q", part.name, parts[part.name], part.content)
+		}
+	}
+
+	var sheet xlsxSheet
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatalf("error decoding the sheet: %v", err)
+	}
+
+	want := [][]string{
+		columns,
+		{"1", "1458862350", "us", "=cmd|' /C calc'!A0", "+1 great <app> & more", "-2 crashes� on launch", "5", "2024-01-02T03:04:05Z"},
+		{"2", "1458862350", "gb", "@SUM(A1:A2)", "\tindented", "fine", "1", "2023-12-31T23:00:00Z"},
+	}
+	if len(sheet.Rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(sheet.Rows), len(want))
+	}
+	for i, row := range sheet.Rows {
+		if row.Ref != i+1 {
+			t.Errorf("row %d has ref %d", i, row.Ref)
+		}
+		got := []string{}
+		for j, cell := range row.Cells {
+			if ref := string(rune('A'+j)) + string(rune('1'+i)); cell.Ref != ref {
+				t.Errorf("cell %d of row %d has ref %s, want %s", j, i, cell.Ref, ref)
+			}
+			if i > 0 && j == ratingColumn {
+				if cell.Type != "" {
+					t.Errorf("rating cell of row %d has type %q, want a number", i, cell.Type)
+				}
+				got = append(got, cell.Value)
+				continue
+			}
+			got = append(got, cell.Inline)
+		}
+		if !slices.Equal(got, want[i]) {
+			t.Errorf("row %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
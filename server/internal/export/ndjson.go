@@ -0,0 +1,25 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// ndjsonWriter writes the reviews as newline delimited JSON, one review per line.
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{encoder: json.NewEncoder(w)}
+}
+
+func (n *ndjsonWriter) Write(review models.Review) error {
+	return n.encoder.Encode(review)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,41 @@
+package httpcache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/pkg/apple"
+)
+
+// Cache is an apple.Cache storing the validators in the database, so every service shares them.
+type Cache struct {
+	db *sql.DB
+}
+
+var _ apple.Cache = (*Cache)(nil)
+
+func New(db *sql.DB) *Cache {
+	return &Cache{db: db}
+}
+
+func (c *Cache) Get(ctx context.Context, url string) (apple.Validators, bool, error) {
+	validators := apple.Validators{}
+	err := c.db.QueryRowContext(ctx, "SELECT etag, last_modified FROM http_cache WHERE url = ?", url).
+		Scan(&validators.ETag, &validators.LastModified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apple.Validators{}, false, nil
+	}
+	if err != nil {
+		return apple.Validators{}, false, err
+	}
+	return validators, true, nil
+}
+
+func (c *Cache) Set(ctx context.Context, url string, validators apple.Validators) error {
+	_, err := c.db.ExecContext(ctx, `INSERT INTO http_cache (url, etag, last_modified, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (url) DO UPDATE SET etag = excluded.etag, last_modified = excluded.last_modified, updated_at = excluded.updated_at`,
+		url, validators.ETag, validators.LastModified, time.Now().UTC())
+	return err
+}
@@ -0,0 +1,19 @@
+package incidents
+
+import (
+	"database/sql"
+	"log/slog"
+)
+
+// IncidentsClient is the client for the incidents detected in the app reviews.
+type IncidentsClient struct {
+	logger *slog.Logger
+	db     *sql.DB
+}
+
+func New(logger *slog.Logger, db *sql.DB) *IncidentsClient {
+	return &IncidentsClient{
+		logger: logger,
+		db:     db,
+	}
+}
@@ -0,0 +1,56 @@
+package incidents
+
+import (
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// AddIncident adds a detected incident and returns it with its ID.
+func (i *IncidentsClient) AddIncident(incident models.Incident) (models.Incident, error) {
+	res, err := i.db.Exec(`INSERT INTO incidents (app_id, country, kind, keyword, message, value, baseline, window_start, window_end, detected_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		incident.AppID, incident.Country, incident.Kind, incident.Keyword, incident.Message, incident.Value, incident.Baseline,
+		incident.WindowStart.UTC(), incident.WindowEnd.UTC(), incident.DetectedAt.UTC())
+	if err != nil {
+		return models.Incident{}, err
+	}
+
+	incident.ID, err = res.LastInsertId()
+	return incident, err
+}
+
+// HasIncidentSince returns true if an incident of the kind and keyword was detected for the app storefront since the given time.
+func (i *IncidentsClient) HasIncidentSince(appID string, country string, kind models.IncidentKind, keyword string, since time.Time) (bool, error) {
+	var exists bool
+	err := i.db.QueryRow("SELECT EXISTS (SELECT 1 FROM incidents WHERE app_id = ? AND country = ? AND kind = ? AND keyword = ? AND detected_at > ?)",
+		appID, country, kind, keyword, since.UTC()).Scan(&exists)
+	return exists, err
+}
+
+// FindIncidentsByAppID returns the latest incidents of the app storefronts the workspace watches, most recent first.
+func (i *IncidentsClient) FindIncidentsByAppID(workspaceID int64, appID string, limit int) ([]models.Incident, error) {
+	incidents := []models.Incident{}
+
+	// the countries of a workspace app are comma separated, they are wrapped in commas to match whole countries
+	rows, err := i.db.Query(`SELECT id, app_id, country, kind, keyword, message, value, baseline, window_start, window_end, detected_at
+		FROM incidents WHERE app_id = ? AND EXISTS (SELECT 1 FROM workspace_apps w WHERE w.workspace_id = ? AND w.app_id = incidents.app_id
+			AND instr(',' || w.countries || ',', ',' || incidents.country || ',') > 0)
+		ORDER BY detected_at DESC, id DESC LIMIT ?`, appID, workspaceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var incident models.Incident
+		err := rows.Scan(&incident.ID, &incident.AppID, &incident.Country, &incident.Kind, &incident.Keyword, &incident.Message,
+			&incident.Value, &incident.Baseline, &incident.WindowStart, &incident.WindowEnd, &incident.DetectedAt)
+		if err != nil {
+			return nil, err
+		}
+		incidents = append(incidents, incident)
+	}
+
+	return incidents, rows.Err()
+}
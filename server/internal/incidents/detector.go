@@ -0,0 +1,111 @@
+package incidents
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/notify"
+	"github.com/renantatsuo/app-review/server/internal/reviews"
+	"github.com/renantatsuo/app-review/server/internal/workspaces"
+)
+
+// Detector detects anomalies in the recent reviews of an app storefront:
+// rating drops, one-star spikes and watched keyword surges.
+type Detector struct {
+	l                *slog.Logger
+	incidentsClient  *IncidentsClient
+	reviewsClient    *reviews.ReviewsClient
+	appsClient       *apps.AppsClient
+	workspacesClient *workspaces.WorkspacesClient
+	config           config.Config
+}
+
+func NewDetector(l *slog.Logger, incidentsClient *IncidentsClient, reviewsClient *reviews.ReviewsClient, appsClient *apps.AppsClient, workspacesClient *workspaces.WorkspacesClient, config config.Config) *Detector {
+	return &Detector{
+		l:                l,
+		incidentsClient:  incidentsClient,
+		reviewsClient:    reviewsClient,
+		appsClient:       appsClient,
+		workspacesClient: workspacesClient,
+		config:           config,
+	}
+}
+
+// Detect runs the anomaly rules over the reviews of the app on the country storefront of the last anomaly window and baseline.
+// The storefronts are checked separately, so the incidents are only shown to the workspaces watching their storefront.
+// New incidents are stored, logged and sent to the incident channels of the workspaces watching the storefront.
+// An incident is not reported again while the same anomaly was already reported in the last window.
+func (d *Detector) Detect(appID string, country string) ([]models.Incident, error) {
+	now := time.Now().UTC()
+
+	query := reviews.NewQuery(appID).Country(country).Since(now.Add(-d.config.AnomalyWindow - d.config.AnomalyBaseline))
+	recent, _, err := d.reviewsClient.FindReviewsByAppID(query)
+	if err != nil {
+		return nil, fmt.Errorf("error finding recent reviews: %w", err)
+	}
+
+	detected := []models.Incident{}
+	var errs []error
+	for _, incident := range detect(appID, country, recent, now, d.config) {
+		reported, err := d.incidentsClient.HasIncidentSince(appID, country, incident.Kind, incident.Keyword, now.Add(-d.config.AnomalyWindow))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if reported {
+			continue
+		}
+
+		incident, err = d.incidentsClient.AddIncident(incident)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error adding incident: %w", err))
+			continue
+		}
+
+		d.l.Warn("incident detected", "incidentID", incident.ID, "appID", appID, "country", country, "kind", incident.Kind,
+			"keyword", incident.Keyword, "message", incident.Message, "value", incident.Value, "baseline", incident.Baseline)
+
+		d.notify(incident)
+		detected = append(detected, incident)
+	}
+
+	return detected, errors.Join(errs...)
+}
+
+// notify sends the incident to the incident channels of every workspace that has the app active on its storefront,
+// logging the failures.
+func (d *Detector) notify(incident models.Incident) {
+	watchers, err := d.workspacesClient.GetWorkspacesWatching(incident.AppID, incident.Country)
+	if err != nil {
+		d.l.Error("error getting the workspaces to notify", "incidentID", incident.ID, "error", err)
+		return
+	}
+
+	name := incident.AppID
+	if appName, err := d.appsClient.GetAppName(incident.AppID); err == nil {
+		name = appName
+	}
+
+	subject := fmt.Sprintf("[Incident] %s (%s): %s", name, strings.ToUpper(incident.Country), incident.Message)
+	details := fmt.Sprintf("Detected at %s, over the reviews sent since %s.",
+		incident.DetectedAt.Format("2006-01-02 15:04 MST"), incident.WindowStart.Format("2006-01-02 15:04 MST"))
+	msg := notify.Message{
+		Subject:  subject,
+		Text:     subject + "\n" + details + "\n",
+		Markdown: "*" + subject + "*\n" + details + "\n",
+	}
+
+	for _, workspace := range watchers {
+		for _, channel := range notify.IncidentChannels(workspace, d.config) {
+			if err := channel.Send(msg); err != nil {
+				d.l.Error("error notifying incident", "incidentID", incident.ID, "workspaceID", workspace.ID, "channel", channel.Name(), "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package incidents
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/dbtest"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/reviews"
+	"github.com/renantatsuo/app-review/server/internal/sources"
+	"github.com/renantatsuo/app-review/server/internal/workspaces"
+)
+
+func TestDetectReportsTheStorefrontIncidentsOncePerWindow(t *testing.T) {
+	db := dbtest.New(t)
+	config, err := config.LoadConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.AnomalyWindow, config.AnomalyBaseline = testConfig.AnomalyWindow, testConfig.AnomalyBaseline
+	config.AnomalyMinReviews, config.AnomalySpikeFactor = testConfig.AnomalyMinReviews, testConfig.AnomalySpikeFactor
+	config.IncidentSlackWebhookURL, config.IncidentEmailTo = "", nil
+	l := slog.New(slog.DiscardHandler)
+
+	sources := sources.New()
+	reviewsClient := reviews.New(l, sources, db, config)
+	appsClient := apps.New(db, sources)
+	detector := NewDetector(l, New(l, db), reviewsClient, appsClient, workspaces.New(l, db), config)
+
+	app := models.App{ID: "com.example.app", Platform: models.PlatformAndroid, Name: "Example", Countries: []string{"us", "gb"}}
+	if err := appsClient.AddApp(models.DefaultWorkspaceID, app); err != nil {
+		t.Fatal(err)
+	}
+	// a one-star spike on the us storefront only
+	for i := range 5 {
+		review := models.Review{ID: fmt.Sprint(i), AppID: app.ID, Country: "us", Author: "author", Content: "content", Rating: 1, SentAt: time.Now().Add(-time.Hour)}
+		if _, err := reviewsClient.UpsertReview(review); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		country string
+		want    int
+	}{
+		{"new spike", "us", 1},
+		{"spike already reported in the window", "us", 0},
+		{"storefront without the spike", "gb", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incidents, err := detector.Detect(app.ID, tt.country)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(incidents) != tt.want {
+				t.Errorf("got incidents %+v, want %d", incidents, tt.want)
+			}
+			for _, incident := range incidents {
+				if incident.Kind != models.IncidentOneStarSpike || incident.Country != tt.country {
+					t.Errorf("got incident %+v, want a one-star spike of the %s storefront", incident, tt.country)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package incidents
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// detect runs every rule over the reviews of the app storefront sent in the window ending now
+// and in the trailing baseline before it.
+func detect(appID string, country string, reviews []models.Review, now time.Time, config config.Config) []models.Incident {
+	windowStart := now.Add(-config.AnomalyWindow)
+	baselineStart := windowStart.Add(-config.AnomalyBaseline)
+
+	window, baseline := []models.Review{}, []models.Review{}
+	for _, review := range reviews {
+		switch {
+		case !review.SentAt.Before(windowStart) && review.SentAt.Before(now):
+			window = append(window, review)
+		case !review.SentAt.Before(baselineStart) && review.SentAt.Before(windowStart):
+			baseline = append(baseline, review)
+		}
+	}
+
+	newIncident := func(kind models.IncidentKind, keyword string, message string, value float64, baseline float64) models.Incident {
+		return models.Incident{
+			AppID:       appID,
+			Country:     country,
+			Kind:        kind,
+			Keyword:     keyword,
+			Message:     message,
+			Value:       value,
+			Baseline:    baseline,
+			WindowStart: windowStart,
+			WindowEnd:   now,
+			DetectedAt:  now,
+		}
+	}
+
+	incidents := []models.Incident{}
+
+	// the average rating dropped more than the threshold
+	if len(window) >= config.AnomalyMinReviews && len(baseline) >= config.AnomalyMinReviews {
+		average, baselineAverage := averageRating(window), averageRating(baseline)
+		if baselineAverage-average > config.AnomalyRatingDrop {
+			incidents = append(incidents, newIncident(models.IncidentRatingDrop, "",
+				fmt.Sprintf("average rating dropped from %.2f to %.2f", baselineAverage, average),
+				average, baselineAverage))
+		}
+	}
+
+	isOneStar := func(review models.Review) bool { return review.Rating == 1 }
+	if rate, baselineRate, ok := spike(window, baseline, isOneStar, config); ok {
+		incidents = append(incidents, newIncident(models.IncidentOneStarSpike, "",
+			fmt.Sprintf("one-star reviews spiked to %.1f per day from %.1f per day", rate, baselineRate),
+			rate, baselineRate))
+	}
+
+	for _, keyword := range config.AnomalyKeywords {
+		keyword = strings.ToLower(keyword)
+		mentions := func(review models.Review) bool {
+			return strings.Contains(strings.ToLower(review.Title), keyword) ||
+				strings.Contains(strings.ToLower(review.Content), keyword)
+		}
+		if rate, baselineRate, ok := spike(window, baseline, mentions, config); ok {
+			incidents = append(incidents, newIncident(models.IncidentKeywordSurge, keyword,
+				fmt.Sprintf("reviews mentioning %q surged to %.1f per day from %.1f per day", keyword, rate, baselineRate),
+				rate, baselineRate))
+		}
+	}
+
+	return incidents
+}
+
+// spike returns the daily rate of the window and baseline reviews matching the filter,
+// and whether the window rate is at least the spike factor times the baseline rate.
+// Windows with less than the minimum number of matching reviews never spike.
+func spike(window []models.Review, baseline []models.Review, match func(models.Review) bool, config config.Config) (float64, float64, bool) {
+	count, baselineCount := countMatching(window, match), countMatching(baseline, match)
+
+	rate := float64(count) / config.AnomalyWindow.Hours() * 24
+	baselineRate := float64(baselineCount) / config.AnomalyBaseline.Hours() * 24
+
+	if count < config.AnomalyMinReviews {
+		return rate, baselineRate, false
+	}
+
+	return rate, baselineRate, rate >= config.AnomalySpikeFactor*baselineRate
+}
+
+func countMatching(reviews []models.Review, match func(models.Review) bool) int {
+	count := 0
+	for _, review := range reviews {
+		if match(review) {
+			count++
+		}
+	}
+	return count
+}
+
+func averageRating(reviews []models.Review) float64 {
+	total := 0
+	for _, review := range reviews {
+		total += review.Rating
+	}
+	return float64(total) / float64(len(reviews))
+}
@@ -0,0 +1,180 @@
+package incidents
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+var (
+	testNow = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	// testConfig has a day window after a week baseline, so a baseline rate of one review per day
+	// is 7 reviews, and a window rate of one review per day is 1 review.
+	testConfig = config.Config{
+		AnomalyWindow:      24 * time.Hour,
+		AnomalyBaseline:    7 * 24 * time.Hour,
+		AnomalyMinReviews:  5,
+		AnomalyRatingDrop:  0.5,
+		AnomalySpikeFactor: 3,
+		AnomalyKeywords:    []string{"Crash"},
+	}
+
+	inWindow   = testNow.Add(-time.Hour)
+	inBaseline = testNow.Add(-48 * time.Hour)
+)
+
+// ratings returns a review with each of the ratings sent at the given time.
+func ratings(sentAt time.Time, ratings ...int) []models.Review {
+	reviews := []models.Review{}
+	for _, rating := range ratings {
+		reviews = append(reviews, models.Review{Content: "works fine", Rating: rating, SentAt: sentAt})
+	}
+	return reviews
+}
+
+// repeat returns n times the rating.
+func repeat(rating int, n int) []int {
+	res := []int{}
+	for range n {
+		res = append(res, rating)
+	}
+	return res
+}
+
+// mentions returns n five-star reviews mentioning the content, sent at the given time.
+func mentions(sentAt time.Time, n int, content string) []models.Review {
+	reviews := ratings(sentAt, repeat(5, n)...)
+	for i := range reviews {
+		reviews[i].Content = content
+	}
+	return reviews
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   []models.Review
+		baseline []models.Review
+		want     []string
+	}{
+		{
+			name: "no reviews",
+			want: []string{},
+		},
+		{
+			name:     "rating drop under the threshold",
+			window:   ratings(inWindow, 4, 4, 4, 3, 3),
+			baseline: ratings(inBaseline, repeat(4, 5)...),
+			want:     []string{},
+		},
+		{
+			name:     "rating drop at the threshold",
+			window:   ratings(inWindow, 4, 4, 4, 3, 3, 3),
+			baseline: ratings(inBaseline, repeat(4, 5)...),
+			want:     []string{},
+		},
+		{
+			name:     "rating drop over the threshold",
+			window:   ratings(inWindow, 4, 4, 3, 3, 3),
+			baseline: ratings(inBaseline, repeat(4, 5)...),
+			want:     []string{"rating_drop"},
+		},
+		{
+			name:     "rating drop with too few window reviews",
+			window:   ratings(inWindow, 2, 2, 2, 2),
+			baseline: ratings(inBaseline, repeat(4, 5)...),
+			want:     []string{},
+		},
+		{
+			name:     "rating drop with too few baseline reviews",
+			window:   ratings(inWindow, 2, 2, 2, 2, 2),
+			baseline: ratings(inBaseline, 4, 4, 4, 4),
+			want:     []string{},
+		},
+		{
+			name:   "one-star spike with too few window reviews and an empty baseline",
+			window: ratings(inWindow, repeat(1, 4)...),
+			want:   []string{},
+		},
+		{
+			name:   "one-star spike with an empty baseline",
+			window: ratings(inWindow, repeat(1, 5)...),
+			want:   []string{"one_star_spike"},
+		},
+		{
+			// 2 per day in the baseline, 5 per day in the window is under 3 times that
+			name:     "one-star spike under the spike factor",
+			window:   ratings(inWindow, repeat(1, 5)...),
+			baseline: ratings(inBaseline, repeat(1, 14)...),
+			want:     []string{},
+		},
+		{
+			name:     "one-star spike at the spike factor",
+			window:   ratings(inWindow, repeat(1, 6)...),
+			baseline: ratings(inBaseline, repeat(1, 14)...),
+			want:     []string{"one_star_spike"},
+		},
+		{
+			name:     "one-star spike with a rating drop",
+			window:   ratings(inWindow, repeat(1, 5)...),
+			baseline: ratings(inBaseline, repeat(5, 5)...),
+			want:     []string{"rating_drop", "one_star_spike"},
+		},
+		{
+			name:   "keyword surge with too few mentions",
+			window: append(mentions(inWindow, 4, "it keeps crashing"), ratings(inWindow, 5)...),
+			want:   []string{},
+		},
+		{
+			name:   "keyword surge ignoring the case",
+			window: mentions(inWindow, 5, "CRASHES on launch"),
+			want:   []string{"keyword_surge:crash"},
+		},
+		{
+			name:     "keyword surge under the spike factor",
+			window:   mentions(inWindow, 5, "crash"),
+			baseline: mentions(inBaseline, 14, "crash"),
+			want:     []string{},
+		},
+		{
+			name:   "window start is in the window",
+			window: ratings(testNow.Add(-testConfig.AnomalyWindow), repeat(1, 5)...),
+			want:   []string{"one_star_spike"},
+		},
+		{
+			name:   "reviews sent after now are ignored",
+			window: ratings(testNow, repeat(1, 5)...),
+			want:   []string{},
+		},
+		{
+			// in the baseline, the 14 one-star reviews would be 2 per day, over a third of the 5 per day of the window
+			name:     "reviews sent before the baseline are ignored",
+			window:   ratings(inWindow, repeat(1, 5)...),
+			baseline: ratings(testNow.Add(-testConfig.AnomalyWindow-testConfig.AnomalyBaseline-time.Second), repeat(1, 14)...),
+			want:     []string{"one_star_spike"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reviews := append(append([]models.Review{}, tt.window...), tt.baseline...)
+
+			got := []string{}
+			for _, incident := range detect("com.example.app", "us", reviews, testNow, testConfig) {
+				kind := string(incident.Kind)
+				if incident.Keyword != "" {
+					kind += ":" + incident.Keyword
+				}
+				got = append(got, kind)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got incidents %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Scope is a permission granted to an API key.
+type Scope string
+
+const (
+	ScopeAppsRead    Scope = "apps:read"
+	ScopeAppsWrite   Scope = "apps:write"
+	ScopeReviewsRead Scope = "reviews:read"
+	// ScopeReviewsWrite grants the triage of the reviews.
+	ScopeReviewsWrite Scope = "reviews:write"
+	// ScopeAdmin grants every other scope, and the management of the API keys.
+	ScopeAdmin Scope = "admin"
+)
+
+// Scopes are the known scopes.
+var Scopes = []Scope{ScopeAppsRead, ScopeAppsWrite, ScopeReviewsRead, ScopeReviewsWrite, ScopeAdmin}
+
+// Valid reports whether the scope is a known scope.
+func (s Scope) Valid() bool {
+	return slices.Contains(Scopes, s)
+}
+
+// ParseScopes parses a list of scopes, it fails if any of them is unknown or the list is empty.
+func ParseScopes(raw []string) ([]Scope, error) {
+	scopes := []Scope{}
+	for _, s := range raw {
+		scope := Scope(s)
+		if !scope.Valid() {
+			return nil, fmt.Errorf("unknown scope %q, must be one of %v", s, Scopes)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required, one of %v", Scopes)
+	}
+	return scopes, nil
+}
+
+// APIKey is a key authenticating the requests to the API.
+// Only the hash of the key is stored, the key itself is only known when it is created.
+type APIKey struct {
+	ID          int64  `json:"id"`
+	WorkspaceID int64  `json:"workspace_id"`
+	Name        string `json:"name"`
+	// Prefix is the start of the key, to recognize it.
+	Prefix     string     `json:"prefix"`
+	Scopes     []Scope    `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// HasScope returns true if the key is granted the scope, admin keys are granted every scope.
+func (k APIKey) HasScope(scope Scope) bool {
+	return slices.Contains(k.Scopes, scope) || slices.Contains(k.Scopes, ScopeAdmin)
+}
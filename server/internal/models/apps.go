@@ -2,22 +2,128 @@ package models
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/renantatsuo/app-review/server/pkg/apple"
+	"github.com/renantatsuo/app-review/server/pkg/googleplay"
 )
 
+// AppStatus is the monitoring status of an app.
+type AppStatus string
+
+const (
+	// AppStatusActive apps are polled for new reviews.
+	AppStatusActive AppStatus = "active"
+	// AppStatusPaused apps are not polled until they are resumed.
+	AppStatusPaused AppStatus = "paused"
+	// AppStatusArchived apps were deleted but their reviews were kept.
+	AppStatusArchived AppStatus = "archived"
+)
+
+// Valid returns true if the status is one of the known statuses.
+func (s AppStatus) Valid() bool {
+	return s == AppStatusActive || s == AppStatusPaused || s == AppStatusArchived
+}
+
+// Platform is the store an app is published on.
+type Platform string
+
+const (
+	// PlatformIOS apps are fetched from the App Store.
+	PlatformIOS Platform = "ios"
+	// PlatformAndroid apps are fetched from Google Play.
+	PlatformAndroid Platform = "android"
+)
+
+// Valid returns true if the platform is one of the known platforms.
+func (p Platform) Valid() bool {
+	return p == PlatformIOS || p == PlatformAndroid
+}
+
+// App is an app watched by a workspace, with the monitoring settings of that workspace.
+// The apps polled for every workspace have a zero WorkspaceID.
 type App struct {
-	ID           string `json:"id"`
-	Name         string `json:"name"`
-	ThumbnailURL string `json:"thumbnail_url"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
+	ID           string    `json:"id"`
+	WorkspaceID  int64     `json:"workspace_id"`
+	Platform     Platform  `json:"platform"`
+	Name         string    `json:"name"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	Countries    []string  `json:"countries"`
+	Status       AppStatus `json:"status"`
+	// PollingInterval is how often the app is polled, nil uses the default interval.
+	PollingInterval *Duration `json:"polling_interval"`
+	// AdaptivePolling shortens or lengthens the polling interval
+	// depending on how many new reviews the last poll found.
+	AdaptivePolling bool       `json:"adaptive_polling"`
+	NextPollAt      *time.Time `json:"next_poll_at"`
+	LastPolledAt    *time.Time `json:"last_polled_at"`
+	// AddedBy is the username or the API key that added the app.
+	AddedBy string `json:"added_by"`
+	// StatusUpdatedBy is the username or the API key that last paused, resumed or archived the app.
+	StatusUpdatedBy string `json:"status_updated_by"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+// Interval returns the app polling interval, or the given default if it has none.
+func (a App) Interval(defaultInterval time.Duration) time.Duration {
+	if a.PollingInterval == nil {
+		return defaultInterval
+	}
+	return time.Duration(*a.PollingInterval)
+}
+
+// AppSearchResult is an app found searching a store, a candidate to be added with its ID.
+type AppSearchResult struct {
+	ID        string   `json:"id"`
+	Platform  Platform `json:"platform"`
+	Name      string   `json:"name"`
+	BundleID  string   `json:"bundle_id"`
+	Developer string   `json:"developer"`
+	IconURL   string   `json:"icon_url"`
+	StoreURL  string   `json:"store_url"`
+	// Rating is the average rating of every version of the app, zero if it has no ratings.
+	Rating      float64 `json:"rating"`
+	RatingCount int     `json:"rating_count"`
 }
 
 func AppFromAppleApp(app apple.App) App {
 	return App{
 		ID:           strconv.Itoa(app.TrackID),
+		Platform:     PlatformIOS,
 		Name:         app.TrackName,
 		ThumbnailURL: app.ArtworkURL512,
+		Countries:    []string{apple.DefaultCountry},
+		Status:       AppStatusActive,
+	}
+}
+
+func AppSearchResultFromAppleApp(app apple.App) AppSearchResult {
+	iconURL := app.ArtworkURL512
+	if iconURL == "" {
+		iconURL = app.ArtworkURL100
+	}
+
+	return AppSearchResult{
+		ID:          strconv.Itoa(app.TrackID),
+		Platform:    PlatformIOS,
+		Name:        app.TrackName,
+		BundleID:    app.BundleID,
+		Developer:   app.ArtistName,
+		IconURL:     iconURL,
+		StoreURL:    app.TrackViewURL,
+		Rating:      app.AverageUserRating,
+		RatingCount: app.UserRatingCount,
+	}
+}
+
+func AppFromGooglePlayApp(app googleplay.App) App {
+	return App{
+		ID:           app.PackageName,
+		Platform:     PlatformAndroid,
+		Name:         app.Title,
+		ThumbnailURL: app.IconURL,
+		Countries:    []string{apple.DefaultCountry},
+		Status:       AppStatusActive,
 	}
 }
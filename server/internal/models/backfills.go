@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// BackfillStatus is the progress of the backfill of an app storefront.
+type BackfillStatus string
+
+const (
+	// BackfillPending is a backfill waiting for the consumer.
+	BackfillPending BackfillStatus = "pending"
+	// BackfillRunning is a backfill with some pages already stored.
+	BackfillRunning BackfillStatus = "running"
+	// BackfillCompleted is a backfill that stored every available page.
+	BackfillCompleted BackfillStatus = "completed"
+	// BackfillRetrying is a backfill that stopped on an error, its job is retried from its last stored page.
+	BackfillRetrying BackfillStatus = "retrying"
+	// BackfillFailed is a backfill whose job exhausted its retries, it resumes from its last stored page once requested again.
+	BackfillFailed BackfillStatus = "failed"
+)
+
+// Backfill is the import of the back catalog of reviews of an app on a country storefront.
+// It walks the reviews feed pages in order, so Page is the last page stored.
+type Backfill struct {
+	AppID       string         `json:"app_id"`
+	Country     string         `json:"country"`
+	Status      BackfillStatus `json:"status"`
+	Page        int            `json:"page"`
+	Created     int            `json:"created"`
+	Error       string         `json:"error,omitempty"`
+	RequestedAt time.Time      `json:"requested_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Digest summarizes the reviews of an app sent in a window, compared with the previous window.
+type Digest struct {
+	App         App       `json:"app"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+
+	Count         int `json:"count"`
+	PreviousCount int `json:"previous_count"`
+	// AverageRating is zero if there are no reviews in the window.
+	AverageRating         float64 `json:"average_rating"`
+	PreviousAverageRating float64 `json:"previous_average_rating"`
+	// RatingDropped is true if the average rating dropped more than the alert threshold.
+	RatingDropped bool `json:"rating_dropped"`
+
+	LowestRated      []Review       `json:"lowest_rated"`
+	TrendingKeywords []KeywordCount `json:"trending_keywords"`
+}
+
+// KeywordCount is how many reviews of a digest window mention a keyword.
+type KeywordCount struct {
+	Keyword string `json:"keyword"`
+	Count   int    `json:"count"`
+}
+
+// RatingChange returns how much the average rating changed from the previous window,
+// zero if either window has no reviews.
+func (d Digest) RatingChange() float64 {
+	if d.Count == 0 || d.PreviousCount == 0 {
+		return 0
+	}
+	return d.AverageRating - d.PreviousAverageRating
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// IncidentKind is the kind of anomaly an incident is about.
+type IncidentKind string
+
+const (
+	// IncidentRatingDrop is a drop of the average rating from the baseline.
+	IncidentRatingDrop IncidentKind = "rating_drop"
+	// IncidentOneStarSpike is a spike of the one-star reviews rate from the baseline.
+	IncidentOneStarSpike IncidentKind = "one_star_spike"
+	// IncidentKeywordSurge is a surge of the reviews mentioning a watched keyword from the baseline.
+	IncidentKeywordSurge IncidentKind = "keyword_surge"
+)
+
+// Incident is an anomaly detected in the reviews of an app on a country storefront sent in a window,
+// compared with the trailing baseline before the window.
+type Incident struct {
+	ID      int64        `json:"id"`
+	AppID   string       `json:"app_id"`
+	Country string       `json:"country"`
+	Kind    IncidentKind `json:"kind"`
+	Keyword string       `json:"keyword,omitempty"`
+	Message string       `json:"message"`
+	// Value and Baseline are the measures of the window and of the baseline:
+	// the average rating for rating drops, the reviews per day for spikes and surges.
+	Value       float64   `json:"value"`
+	Baseline    float64   `json:"baseline"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+	DetectedAt  time.Time `json:"detected_at"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/renantatsuo/app-review/server/pkg/apple"
+)
+
+// JobType is the kind of work a job asks the consumer for.
+type JobType string
+
+const (
+	// JobTypePoll fetches the reviews sent since the last ones stored, it is the type of the jobs without type.
+	JobTypePoll JobType = "poll"
+	// JobTypeBackfill walks every page of the reviews feed to store the back catalog of reviews.
+	JobTypeBackfill JobType = "backfill"
+)
+
+// Job is the item exchanged through the queue.
+// It asks the consumer to fetch the reviews of an app on a country storefront.
+type Job struct {
+	AppID   string  `json:"app_id"`
+	Country string  `json:"country"`
+	Type    JobType `json:"type,omitempty"`
+	// Platform is the store of the app, jobs without platform are from the App Store.
+	Platform Platform `json:"platform,omitempty"`
+}
+
+// Encode encodes the job to be enqueued.
+func (j Job) Encode() []byte {
+	item, _ := json.Marshal(j)
+	return item
+}
+
+// DecodeJob decodes a dequeued item.
+// Items that are not JSON are plain app IDs enqueued before storefronts
+// were supported, they are fetched from the default storefront.
+func DecodeJob(item []byte) (Job, error) {
+	if len(item) == 0 || item[0] != '{' {
+		return Job{AppID: string(item), Country: apple.DefaultCountry, Type: JobTypePoll, Platform: PlatformIOS}, nil
+	}
+
+	var job Job
+	if err := json.Unmarshal(item, &job); err != nil {
+		return Job{}, err
+	}
+
+	if job.Country == "" {
+		job.Country = apple.DefaultCountry
+	}
+	if job.Type == "" {
+		job.Type = JobTypePoll
+	}
+	if job.Platform == "" {
+		job.Platform = PlatformIOS
+	}
+
+	return job, nil
+}
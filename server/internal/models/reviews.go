@@ -5,22 +5,51 @@ import (
 	"time"
 
 	"github.com/renantatsuo/app-review/server/pkg/apple"
+	"github.com/renantatsuo/app-review/server/pkg/googleplay"
 )
 
 type Review struct {
-	ID        string    `json:"id"`
-	AppID     string    `json:"app_id"`
-	Author    string    `json:"author"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Rating    int       `json:"rating"`
-	SentAt    time.Time `json:"sent_at"`
-	CreatedAt time.Time `json:"-"`
-	UpdatedAt time.Time `json:"-"`
-}
-
-// ReviewFromAppleReview transforms the apple review to the models.Review.
-func ReviewFromAppleReview(review apple.Review, appID string) (Review, error) {
+	ID      string    `json:"id"`
+	AppID   string    `json:"app_id"`
+	Country string    `json:"country"`
+	Author  string    `json:"author"`
+	Title   string    `json:"title"`
+	Content string    `json:"content"`
+	Rating  int       `json:"rating"`
+	SentAt  time.Time `json:"sent_at"`
+	// Triage is how the workspace of the request triaged the review, nil until it is triaged.
+	Triage    *ReviewTriage `json:"triage,omitempty"`
+	CreatedAt time.Time     `json:"-"`
+	UpdatedAt time.Time     `json:"-"`
+}
+
+// TriageStatus is where a workspace stands on a review.
+type TriageStatus string
+
+const (
+	// TriageAcknowledged reviews are being looked into.
+	TriageAcknowledged TriageStatus = "acknowledged"
+	// TriageResolved reviews were answered or their issue was fixed.
+	TriageResolved TriageStatus = "resolved"
+	// TriageIgnored reviews need no action.
+	TriageIgnored TriageStatus = "ignored"
+)
+
+// Valid reports whether the status is a known triage status.
+func (s TriageStatus) Valid() bool {
+	return s == TriageAcknowledged || s == TriageResolved || s == TriageIgnored
+}
+
+// ReviewTriage is the triage of a review by a workspace.
+type ReviewTriage struct {
+	Status TriageStatus `json:"status"`
+	// TriagedBy is the username or the API key that last triaged the review.
+	TriagedBy string    `json:"triaged_by"`
+	TriagedAt time.Time `json:"triaged_at"`
+}
+
+// ReviewFromAppleReview transforms the apple review from the given country storefront to the models.Review.
+func ReviewFromAppleReview(review apple.Review, appID string, country string) (Review, error) {
 	rating, err := strconv.Atoi(review.Rating.Label)
 	if err != nil {
 		return Review{}, err
@@ -34,6 +63,7 @@ func ReviewFromAppleReview(review apple.Review, appID string) (Review, error) {
 	res := Review{
 		ID:      review.ID.Label,
 		AppID:   appID,
+		Country: country,
 		Author:  review.Author.Name.Label,
 		Title:   review.Title.Label,
 		Content: review.Content.Label,
@@ -43,3 +73,60 @@ func ReviewFromAppleReview(review apple.Review, appID string) (Review, error) {
 
 	return res, nil
 }
+
+// ReviewFromGooglePlayReview transforms the Google Play review from the given country storefront to the models.Review.
+// Google Play reviews have no title.
+func ReviewFromGooglePlayReview(review googleplay.Review, appID string, country string) Review {
+	return Review{
+		ID:      review.ID,
+		AppID:   appID,
+		Country: country,
+		Author:  review.Author,
+		Content: review.Content,
+		Rating:  review.Rating,
+		SentAt:  review.UpdatedAt,
+	}
+}
+
+// ReviewSearchResult is a review matching a full-text search.
+type ReviewSearchResult struct {
+	Review
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+	Relevance      float64 `json:"relevance"`
+}
+
+// ReviewChange is how an upsert changed a stored review.
+type ReviewChange int
+
+const (
+	// ReviewUnchanged reviews were already stored with the same rating and text.
+	ReviewUnchanged ReviewChange = iota
+	// ReviewCreated reviews were not stored before.
+	ReviewCreated
+	// ReviewEdited reviews were stored with a different rating or text.
+	ReviewEdited
+)
+
+// ReviewRevision is a version of a review, as it was at SentAt.
+type ReviewRevision struct {
+	Author  string    `json:"author"`
+	Title   string    `json:"title"`
+	Content string    `json:"content"`
+	Rating  int       `json:"rating"`
+	SentAt  time.Time `json:"sent_at"`
+	// Current is true for the version of the review currently stored.
+	Current bool `json:"current"`
+}
+
+// Edited returns true if the revision has a different rating or text from the review.
+func (r ReviewRevision) Edited(review Review) bool {
+	return r.Title != review.Title || r.Content != review.Content || r.Rating != review.Rating
+}
+
+// ReviewEvent is a review stored by the consumer, at its position in the sequence of the stored reviews.
+// The ids only increase, so a stream of the events can resume after the last one it received.
+type ReviewEvent struct {
+	ID int64
+	Review
+}
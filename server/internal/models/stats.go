@@ -0,0 +1,76 @@
+package models
+
+// StatsBucket is the period the rating stats are grouped by.
+type StatsBucket string
+
+const (
+	StatsBucketDay   StatsBucket = "day"
+	StatsBucketWeek  StatsBucket = "week"
+	StatsBucketMonth StatsBucket = "month"
+)
+
+// Valid returns true if the bucket is one of the known buckets.
+func (b StatsBucket) Valid() bool {
+	return b == StatsBucketDay || b == StatsBucketWeek || b == StatsBucketMonth
+}
+
+// RatingStats are the rating aggregates of a set of reviews.
+type RatingStats struct {
+	Count int `json:"count"`
+	// Mean and Median are zero if there are no reviews.
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	// Distribution counts the reviews by rating, from 1 to 5.
+	Distribution map[int]int `json:"distribution"`
+}
+
+// NewRatingStats computes the stats of the given reviews count by rating.
+func NewRatingStats(distribution map[int]int) RatingStats {
+	stats := RatingStats{Distribution: map[int]int{}}
+
+	total := 0
+	for rating := 1; rating <= 5; rating++ {
+		count := distribution[rating]
+		stats.Distribution[rating] = count
+		stats.Count += count
+		total += rating * count
+	}
+
+	if stats.Count == 0 {
+		return stats
+	}
+
+	stats.Mean = float64(total) / float64(stats.Count)
+	stats.Median = (float64(nthRating(distribution, (stats.Count-1)/2)) + float64(nthRating(distribution, stats.Count/2))) / 2
+
+	return stats
+}
+
+// nthRating returns the rating of the nth review, zero indexed, if the reviews were sorted by rating.
+func nthRating(distribution map[int]int, n int) int {
+	for rating := 1; rating <= 5; rating++ {
+		if n < distribution[rating] {
+			return rating
+		}
+		n -= distribution[rating]
+	}
+	return 0
+}
+
+// BucketStats are the rating stats of the reviews sent in a bucket.
+type BucketStats struct {
+	// Start is the first day of the bucket, as 2006-01-02.
+	Start string `json:"start"`
+	RatingStats
+}
+
+// AppStats are the rating stats of an app reviews over a date range.
+type AppStats struct {
+	AppID  string      `json:"app_id"`
+	Bucket StatsBucket `json:"bucket"`
+	// Since and Until are the first and last days of the range, as 2006-01-02.
+	Since   string        `json:"since"`
+	Until   string        `json:"until"`
+	Total   RatingStats   `json:"total"`
+	Buckets []BucketStats `json:"buckets"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestNewRatingStats(t *testing.T) {
+	tests := []struct {
+		name         string
+		distribution map[int]int
+		count        int
+		mean         float64
+		median       float64
+	}{
+		{name: "empty", distribution: map[int]int{}},
+		{name: "no reviews of any rating", distribution: map[int]int{1: 0, 5: 0}},
+		{name: "one review", distribution: map[int]int{4: 1}, count: 1, mean: 4, median: 4},
+		{name: "odd count", distribution: map[int]int{1: 1, 5: 2}, count: 3, mean: 11.0 / 3, median: 5},
+		{name: "even count between ratings", distribution: map[int]int{1: 1, 4: 1}, count: 2, mean: 2.5, median: 2.5},
+		{name: "even count within a rating", distribution: map[int]int{2: 1, 3: 2, 5: 1}, count: 4, mean: 3.25, median: 3},
+		{name: "even count across the middle ratings", distribution: map[int]int{1: 3, 4: 2, 5: 1}, count: 6, mean: 16.0 / 6, median: 2.5},
+		{name: "ratings out of range are ignored", distribution: map[int]int{0: 3, 3: 1, 6: 2}, count: 1, mean: 3, median: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := NewRatingStats(tt.distribution)
+			if stats.Count != tt.count || stats.Mean != tt.mean || stats.Median != tt.median {
+				t.Errorf("got count %d, mean %v, median %v, want %d, %v, %v", stats.Count, stats.Mean, stats.Median, tt.count, tt.mean, tt.median)
+			}
+			if len(stats.Distribution) != 5 {
+				t.Errorf("got distribution %v, want every rating from 1 to 5", stats.Distribution)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// SyncState is where the incremental fetch of the reviews of an app on a country storefront stands.
+type SyncState struct {
+	AppID   string `json:"app_id"`
+	Country string `json:"country"`
+	// HighWaterMark is the sent_at of the latest review fetched, the next fetch resumes from it.
+	// LastReviewIDs are the reviews sent at that time, so they are not fetched again.
+	HighWaterMark *time.Time `json:"high_water_mark"`
+	LastReviewIDs []string   `json:"last_review_ids"`
+	// ResumeSince is set while a fetch truncated by the source is resumed from ResumeCursor:
+	// the next fetches go on with the reviews older than the ones fetched so far, down to ResumeSince,
+	// before resuming from the high water mark.
+	ResumeSince   *time.Time `json:"resume_since,omitempty"`
+	ResumeCursor  string     `json:"-"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Seen returns true if the review was already fetched, i.e. it is one of the reviews sent at the high water mark.
+func (s SyncState) Seen(reviewID string, sentAt time.Time) bool {
+	if s.HighWaterMark == nil || !sentAt.Equal(*s.HighWaterMark) {
+		return false
+	}
+
+	return slices.Contains(s.LastReviewIDs, reviewID)
+}
+
+// Advance returns the state moved to the latest of the given reviews, if newer than the high water mark.
+func (s SyncState) Advance(reviews []Review) SyncState {
+	for _, review := range reviews {
+		switch {
+		case s.HighWaterMark == nil || review.SentAt.After(*s.HighWaterMark):
+			sentAt := review.SentAt
+			s.HighWaterMark = &sentAt
+			s.LastReviewIDs = []string{review.ID}
+		case review.SentAt.Equal(*s.HighWaterMark) && !s.Seen(review.ID, review.SentAt):
+			// clipped so the ids of the original state are not modified
+			s.LastReviewIDs = append(slices.Clip(s.LastReviewIDs), review.ID)
+		}
+	}
+
+	return s
+}
+
+// Truncate returns the state resuming a fetch of the reviews sent since the given time from the cursor,
+// after the source truncated it.
+func (s SyncState) Truncate(since time.Time, cursor string) SyncState {
+	s.ResumeSince = &since
+	s.ResumeCursor = cursor
+	return s
+}
+
+// Resumed returns the state once the fetch it was resuming reached its since time.
+func (s SyncState) Resumed() SyncState {
+	s.ResumeSince = nil
+	s.ResumeCursor = ""
+	return s
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSyncStateSeen(t *testing.T) {
+	mark := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	state := SyncState{HighWaterMark: &mark, LastReviewIDs: []string{"1", "2"}}
+
+	tests := []struct {
+		name     string
+		state    SyncState
+		reviewID string
+		sentAt   time.Time
+		want     bool
+	}{
+		{name: "no high water mark", state: SyncState{}, reviewID: "1", sentAt: mark, want: false},
+		{name: "id at the mark", state: state, reviewID: "2", sentAt: mark, want: true},
+		{name: "id at the mark in another location", state: state, reviewID: "1", sentAt: mark.In(time.FixedZone("UTC+2", 2*60*60)), want: true},
+		{name: "other id at the mark", state: state, reviewID: "3", sentAt: mark, want: false},
+		{name: "id before the mark", state: state, reviewID: "1", sentAt: mark.Add(-time.Second), want: false},
+		{name: "id after the mark", state: state, reviewID: "1", sentAt: mark.Add(time.Second), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.state.Seen(tt.reviewID, tt.sentAt); got != tt.want {
+				t.Errorf("Seen(%q, %v) = %v, want %v", tt.reviewID, tt.sentAt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncStateAdvance(t *testing.T) {
+	mark := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	review := func(id string, offset time.Duration) Review {
+		return Review{ID: id, SentAt: mark.Add(offset)}
+	}
+
+	tests := []struct {
+		name    string
+		state   SyncState
+		reviews []Review
+		// wantMark is the offset of the high water mark from mark, wantIDs are the ids at it
+		wantMark time.Duration
+		wantIDs  []string
+	}{
+		{
+			name:     "first fetch",
+			reviews:  []Review{review("1", -time.Minute), review("2", 0)},
+			wantMark: 0,
+			wantIDs:  []string{"2"},
+		},
+		{
+			name:     "first fetch with equal timestamps",
+			reviews:  []Review{review("1", 0), review("2", 0), review("3", -time.Minute)},
+			wantMark: 0,
+			wantIDs:  []string{"1", "2"},
+		},
+		{
+			name:     "no reviews",
+			state:    SyncState{HighWaterMark: &mark, LastReviewIDs: []string{"1"}},
+			wantMark: 0,
+			wantIDs:  []string{"1"},
+		},
+		{
+			name:     "newer reviews",
+			state:    SyncState{HighWaterMark: &mark, LastReviewIDs: []string{"1"}},
+			reviews:  []Review{review("3", time.Minute), review("2", time.Second)},
+			wantMark: time.Minute,
+			wantIDs:  []string{"3"},
+		},
+		{
+			name:     "new reviews at the mark",
+			state:    SyncState{HighWaterMark: &mark, LastReviewIDs: []string{"1"}},
+			reviews:  []Review{review("2", 0)},
+			wantMark: 0,
+			wantIDs:  []string{"1", "2"},
+		},
+		{
+			name:     "ids already seen at the mark",
+			state:    SyncState{HighWaterMark: &mark, LastReviewIDs: []string{"1", "2"}},
+			reviews:  []Review{review("2", 0), review("1", 0)},
+			wantMark: 0,
+			wantIDs:  []string{"1", "2"},
+		},
+		{
+			name:     "older reviews",
+			state:    SyncState{HighWaterMark: &mark, LastReviewIDs: []string{"1"}},
+			reviews:  []Review{review("0", -time.Minute)},
+			wantMark: 0,
+			wantIDs:  []string{"1"},
+		},
+		{
+			name:     "equal timestamps after the mark",
+			state:    SyncState{HighWaterMark: &mark, LastReviewIDs: []string{"1"}},
+			reviews:  []Review{review("2", time.Minute), review("3", time.Minute), review("2", time.Minute)},
+			wantMark: time.Minute,
+			wantIDs:  []string{"2", "3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := fmt.Sprint(tt.state.LastReviewIDs)
+
+			got := tt.state.Advance(tt.reviews)
+			if got.HighWaterMark == nil || !got.HighWaterMark.Equal(mark.Add(tt.wantMark)) || fmt.Sprint(got.LastReviewIDs) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("got high water mark %v and ids %v, want %v and %v", got.HighWaterMark, got.LastReviewIDs, mark.Add(tt.wantMark), tt.wantIDs)
+			}
+			if fmt.Sprint(tt.state.LastReviewIDs) != ids {
+				t.Errorf("got the ids of the original state modified to %v, want %s", tt.state.LastReviewIDs, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Role is the access level of a user.
+type Role string
+
+const (
+	// RoleViewer users read the apps and their reviews.
+	RoleViewer Role = "viewer"
+	// RoleEditor users also add, update, pause and delete the apps, and triage the reviews.
+	RoleEditor Role = "editor"
+	// RoleAdmin users also manage the users, the API keys and the webhooks.
+	RoleAdmin Role = "admin"
+)
+
+// roleScopes are the API key scopes granted to each role.
+var roleScopes = map[Role][]Scope{
+	RoleViewer: {ScopeAppsRead, ScopeReviewsRead},
+	RoleEditor: {ScopeAppsRead, ScopeAppsWrite, ScopeReviewsRead, ScopeReviewsWrite},
+	RoleAdmin:  {ScopeAdmin},
+}
+
+// Valid returns true if the role is one of the known roles.
+func (r Role) Valid() bool {
+	_, ok := roleScopes[r]
+	return ok
+}
+
+// HasScope returns true if the role is granted the scope, as an API key would be.
+func (r Role) HasScope(scope Scope) bool {
+	scopes := roleScopes[r]
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ScopeAdmin)
+}
+
+// User is a named user of the web app.
+type User struct {
+	ID          int64      `json:"id"`
+	WorkspaceID int64      `json:"workspace_id"`
+	Username    string     `json:"username"`
+	Role        Role       `json:"role"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// Session is a logged in session of a user, identified by the token of its cookie.
+type Session struct {
+	UserID int64 `json:"user_id"`
+	// CSRFToken must be sent back in the X-CSRF-Token header of the requests changing anything.
+	CSRFToken string    `json:"csrf_token"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// WebhookEventReviewsCreated is the event sent when new reviews are stored.
+const WebhookEventReviewsCreated = "reviews.created"
+
+// WebhookSubscription is an URL notified of the new reviews matching its filters.
+type WebhookSubscription struct {
+	ID          int64  `json:"id"`
+	WorkspaceID int64  `json:"workspace_id"`
+	URL         string `json:"url"`
+	// Secret signs the payloads, it is only returned when the subscription is created.
+	Secret string `json:"secret,omitempty"`
+	// AppID only matches the reviews of this app, empty matches every app.
+	AppID string `json:"app_id"`
+	// MaxRating only matches the reviews rated up to this rating, zero matches every rating.
+	MaxRating int `json:"max_rating"`
+	// Keyword only matches the reviews with this keyword in the title or content, case insensitive.
+	Keyword   string    `json:"keyword"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Matches returns true if the review passes the subscription filters.
+func (s WebhookSubscription) Matches(review Review) bool {
+	if s.AppID != "" && s.AppID != review.AppID {
+		return false
+	}
+	if s.MaxRating > 0 && review.Rating > s.MaxRating {
+		return false
+	}
+	if s.Keyword != "" {
+		keyword := strings.ToLower(s.Keyword)
+		if !strings.Contains(strings.ToLower(review.Title), keyword) &&
+			!strings.Contains(strings.ToLower(review.Content), keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+// WebhookPayload is the body posted to the subscriptions.
+type WebhookPayload struct {
+	Event   string   `json:"event"`
+	AppID   string   `json:"app_id"`
+	Reviews []Review `json:"reviews"`
+}
+
+// WebhookDelivery is an attempt to deliver an event to a subscription.
+type WebhookDelivery struct {
+	ID             int64  `json:"id"`
+	SubscriptionID int64  `json:"subscription_id"`
+	EventID        string `json:"event_id"`
+	Attempt        int    `json:"attempt"`
+	// StatusCode is zero if the request failed before getting a response.
+	StatusCode int `json:"status_code"`
+	// Success is true if the subscription acknowledged the event, otherwise Error tells why.
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookJob is the item exchanged through the webhooks queue.
+// It asks the dispatcher to deliver an event to a subscription.
+type WebhookJob struct {
+	// EventID is the same on every retry so the subscription can ignore duplicates.
+	EventID        string          `json:"event_id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// Encode encodes the job to be enqueued.
+func (j WebhookJob) Encode() []byte {
+	item, _ := json.Marshal(j)
+	return item
+}
+
+// DecodeWebhookJob decodes a dequeued webhook item.
+func DecodeWebhookJob(item []byte) (WebhookJob, error) {
+	var job WebhookJob
+	err := json.Unmarshal(item, &job)
+	return job, err
+}
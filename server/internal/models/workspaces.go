@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// DefaultWorkspaceID is the workspace the apps, users, keys and webhooks created before workspaces belong to.
+const DefaultWorkspaceID int64 = 1
+
+// Workspace is a team sharing the deployment, it only sees its own apps and their reviews.
+type Workspace struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// Digest and Incident are where the digests of the workspace apps and their incidents are sent,
+	// they are left out of the responses as the webhooks are secrets.
+	Digest    NotificationTargets `json:"-"`
+	Incident  NotificationTargets `json:"-"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// NotificationTargets are the Slack compatible incoming webhook and the email recipients notifications are sent to.
+// Empty ones are skipped.
+type NotificationTargets struct {
+	SlackWebhookURL string   `json:"slack_webhook_url,omitempty"`
+	EmailTo         []string `json:"email_to,omitempty"`
+}
+
+// Empty returns true if there is no target.
+func (t NotificationTargets) Empty() bool {
+	return t.SlackWebhookURL == "" && len(t.EmailTo) == 0
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EmailSender sends the notifications by email through a SMTP server.
+type EmailSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewEmailSender creates an email sender through the SMTP server at addr.
+// Without username the server is used without authentication, like a local SMTP stand-in.
+func NewEmailSender(addr string, username string, password string, from string, to []string) *EmailSender {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &EmailSender{addr: addr, auth: auth, from: from, to: to}
+}
+
+func (e *EmailSender) Name() string {
+	return "email"
+}
+
+// Send sends the message as a plain text email.
+func (e *EmailSender) Send(msg Message) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&b, "Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+
+	return smtp.SendMail(e.addr, e.auth, e.from, e.to, b.Bytes())
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// Message is a notification, formatted as plain text and as Slack mrkdwn.
+type Message struct {
+	Subject  string
+	Text     string
+	Markdown string
+}
+
+// Channel delivers the notifications, e.g. to Slack or by email.
+type Channel interface {
+	Name() string
+	Send(msg Message) error
+}
+
+// NewChannels creates the channels to the given Slack compatible incoming webhook
+// and email recipients, through the configured SMTP server. Empty ones are skipped.
+func NewChannels(slackWebhookURL string, emailTo []string, config config.Config) []Channel {
+	channels := []Channel{}
+	if slackWebhookURL != "" {
+		channels = append(channels, NewSlackSender(slackWebhookURL))
+	}
+	if len(emailTo) > 0 {
+		channels = append(channels, NewEmailSender(config.SMTPAddr, config.SMTPUsername, config.SMTPPassword, config.SMTPFrom, emailTo))
+	}
+	return channels
+}
+
+// DigestChannels creates the channels the digests of the workspace apps are sent to.
+// The default workspace without targets falls back to DIGEST_SLACK_WEBHOOK_URL and DIGEST_EMAIL_TO,
+// it holds the apps of the deployments from before the workspaces.
+func DigestChannels(workspace models.Workspace, config config.Config) []Channel {
+	targets := workspace.Digest
+	if workspace.ID == models.DefaultWorkspaceID && targets.Empty() {
+		targets = models.NotificationTargets{SlackWebhookURL: config.DigestSlackWebhookURL, EmailTo: config.DigestEmailTo}
+	}
+	return NewChannels(targets.SlackWebhookURL, targets.EmailTo, config)
+}
+
+// IncidentChannels creates the channels the incidents of the workspace apps are sent to.
+// The default workspace without targets falls back to INCIDENT_SLACK_WEBHOOK_URL and INCIDENT_EMAIL_TO.
+func IncidentChannels(workspace models.Workspace, config config.Config) []Channel {
+	targets := workspace.Incident
+	if workspace.ID == models.DefaultWorkspaceID && targets.Empty() {
+		targets = models.NotificationTargets{SlackWebhookURL: config.IncidentSlackWebhookURL, EmailTo: config.IncidentEmailTo}
+	}
+	return NewChannels(targets.SlackWebhookURL, targets.EmailTo, config)
+}
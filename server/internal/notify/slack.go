@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const slackTimeout = 10 * time.Second
+
+// SlackSender posts the notifications to a Slack compatible incoming webhook.
+type SlackSender struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewSlackSender(url string) *SlackSender {
+	return &SlackSender{url: url, httpClient: &http.Client{Timeout: slackTimeout}}
+}
+
+func (s *SlackSender) Name() string {
+	return "slack"
+}
+
+// Send posts the message as a mrkdwn text message.
+func (s *SlackSender) Send(msg Message) error {
+	body, err := json.Marshal(map[string]string{"text": msg.Markdown})
+	if err != nil {
+		return err
+	}
+
+	res, err := s.httpClient.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+
+	return nil
+}
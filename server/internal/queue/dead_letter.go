@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const deadLetterCreateTableQuery = `
+	CREATE TABLE IF NOT EXISTS dead_letters (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		item BLOB NOT NULL,
+		reason TEXT NOT NULL,
+		attempts INTEGER NOT NULL,
+		failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+`
+
+// DeadLetter stores the messages that exhausted their retries,
+// so they can be inspected and replayed.
+type DeadLetter struct {
+	db *sql.DB
+}
+
+// DeadLetterItem is a message stored in the dead letter queue.
+type DeadLetterItem struct {
+	ID       int64     `json:"id"`
+	Item     string    `json:"item"`
+	Reason   string    `json:"reason"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+func NewDeadLetter(connStr string) *DeadLetter {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=5000", connStr))
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err := db.Exec(deadLetterCreateTableQuery); err != nil {
+		panic(err)
+	}
+
+	return &DeadLetter{db: db}
+}
+
+// Add stores a failed message.
+func (d *DeadLetter) Add(item []byte, reason string, attempts int) error {
+	_, err := d.db.Exec("INSERT INTO dead_letters (item, reason, attempts) VALUES (?, ?, ?)", item, reason, attempts)
+	return err
+}
+
+// List returns all the failed messages, oldest first.
+func (d *DeadLetter) List() ([]DeadLetterItem, error) {
+	items := []DeadLetterItem{}
+
+	rows, err := d.db.Query("SELECT id, item, reason, attempts, failed_at FROM dead_letters ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item DeadLetterItem
+		if err := rows.Scan(&item.ID, &item.Item, &item.Reason, &item.Attempts, &item.FailedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// Replay enqueues a failed message back to the queue and removes it from the dead letter queue.
+// If the queue already holds the same item it is only removed.
+func (d *DeadLetter) Replay(q Queue, id int64) error {
+	var item []byte
+	if err := d.db.QueryRow("SELECT item FROM dead_letters WHERE id = ?", id).Scan(&item); err != nil {
+		return err
+	}
+
+	if err := q.Enqueue(item); err != nil && !errors.Is(err, ErrDuplicate) {
+		return err
+	}
+
+	_, err := d.db.Exec("DELETE FROM dead_letters WHERE id = ?", id)
+	return err
+}
+
+// Close the dead letter queue
+func (d *DeadLetter) Close() error {
+	return d.db.Close()
+}
@@ -1,49 +1,325 @@
 package queue
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/mattdeak/gopq"
 )
 
+const (
+	defaultMaxRetries   = 5
+	defaultRetryBackoff = 30 * time.Second
+	defaultAckTimeout   = time.Minute
+	maxRetryBackoff     = time.Hour
+
+	// ackTable and uniqueAckTable are the tables gopq stores the queues in, by mode.
+	ackTable       = "ack_queue"
+	uniqueAckTable = "unique_ack_queue"
+)
+
+// ErrDuplicate is returned by Enqueue on a unique queue
+// when the item is already pending or in flight.
+var ErrDuplicate = errors.New("item is already in the queue")
+
+// ErrLeaseLost is returned by Extend, Ack and Nack when the ack deadline of the message expired,
+// it may then have been dequeued again, by another worker.
+var ErrLeaseLost = errors.New("ack deadline of the message expired")
+
 // Queue is the interface for the queue
 type Queue interface {
 	// Enqueue an item to the queue
 	Enqueue(item []byte) error
-	// Dequeue an item from the queue
-	Dequeue() ([]byte, error)
+	// Dequeue an item from the queue, blocking until one is available or the context is done
+	Dequeue(ctx context.Context) (Message, error)
+	// Extend pushes the ack deadline of a dequeued message back by the ack timeout, for the messages processed longer than it
+	Extend(id int64) error
+	// Ack marks a dequeued message as successfully processed
+	Ack(id int64) error
+	// Nack marks a dequeued message as failed so it is retried later.
+	// It reports whether the message exhausted its retries instead, and was moved to the dead letter queue or dropped
+	Nack(id int64, reason error) (exhausted bool, err error)
+	// Release makes a dequeued message available again right away, without counting a retry,
+	// for the messages whose processing was interrupted rather than failed
+	Release(id int64) error
 	// Close the queue
 	Close() error
 }
 
+// Message is an item dequeued from the queue.
+// Its ID must be used to Ack or Nack it once processed.
+type Message struct {
+	ID   int64
+	Item []byte
+}
+
+// Option configures the queue.
+type Option func(*queue)
+
+// WithMaxRetries sets how many times a failed message is retried
+// before being moved to the dead letter queue.
+func WithMaxRetries(maxRetries int) Option {
+	return func(q *queue) {
+		q.maxRetries = maxRetries
+	}
+}
+
+// WithRetryBackoff sets the base backoff of a failed message.
+// The backoff doubles on every retry.
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(q *queue) {
+		q.retryBackoff = backoff
+	}
+}
+
+// WithAckTimeout sets how long a dequeued message has to be acked
+// before it becomes available again.
+func WithAckTimeout(timeout time.Duration) Option {
+	return func(q *queue) {
+		q.ackTimeout = timeout
+	}
+}
+
+// WithUnique makes the queue ignore items that are already pending or in flight.
+// Every service sharing the queue file must use the same mode.
+func WithUnique(unique bool) Option {
+	return func(q *queue) {
+		q.unique = unique
+	}
+}
+
+// WithDeadLetter sets where messages that exhausted their retries are stored.
+// Without it they are dropped.
+func WithDeadLetter(deadLetter *DeadLetter) Option {
+	return func(q *queue) {
+		q.deadLetter = deadLetter
+	}
+}
+
 type queue struct {
-	queue *gopq.Queue
+	queue        *gopq.AcknowledgeableQueue
+	maxRetries   int
+	retryBackoff time.Duration
+	ackTimeout   time.Duration
+	deadLetter   *DeadLetter
+	unique       bool
+
+	// lookup is a separate connection to the queue database, used to extend the ack deadlines,
+	// which gopq does not support, and by unique queues to tell whether an item was enqueued or ignored as a duplicate.
+	lookup *sql.DB
+	// table is the table of the queue, named by gopq after the queue mode.
+	table string
+
+	// mu guards the attempts and reasons maps and the retry backoff
+	// of the underlying queue, which is changed on every nack.
+	mu       sync.Mutex
+	attempts map[int64]int
+	reasons  map[int64]string
+	// exhausted is set by onFailure when the message being nacked exhausted its retries.
+	exhausted bool
 }
 
-func New(connStr string) Queue {
-	return &queue{
-		queue: connect(connStr),
+// New opens the queue stored in the connStr database file, shared by the services.
+// It panics if connStr is empty, gopq would keep the queue in memory under a table name it does not expose.
+func New(connStr string, opts ...Option) Queue {
+	if connStr == "" {
+		panic("queue: a database file is required")
+	}
+
+	q := &queue{
+		maxRetries:   defaultMaxRetries,
+		retryBackoff: defaultRetryBackoff,
+		ackTimeout:   defaultAckTimeout,
+		attempts:     make(map[int64]int),
+		reasons:      make(map[int64]string),
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	q.queue = connect(connStr, q.unique, gopq.AckOpts{
+		AckTimeout:   q.ackTimeout,
+		MaxRetries:   q.maxRetries,
+		RetryBackoff: q.retryBackoff,
+	})
+	q.queue.RegisterOnFailureCallback(q.onFailure)
+
+	q.table = ackTable
+	if q.unique {
+		q.table = uniqueAckTable
 	}
+	q.lookup = connectLookup(connStr, q.table)
+
+	return q
 }
 
-func connect(connStr string) *gopq.Queue {
-	queue, err := gopq.NewSimpleQueue(connStr)
+func connect(connStr string, unique bool, opts gopq.AckOpts) *gopq.AcknowledgeableQueue {
+	newQueue := gopq.NewAckQueue
+	if unique {
+		newQueue = gopq.NewUniqueAckQueue
+	}
+
+	queue, err := newQueue(connStr, opts)
 	if err != nil {
 		panic(err)
 	}
 	return queue
 }
 
+// connectLookup opens the lookup connection, checking the table gopq created is the expected one.
+func connectLookup(connStr string, table string) *sql.DB {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=5000", connStr))
+	if err != nil {
+		panic(err)
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("SELECT 1 FROM %s LIMIT 0", table)); err != nil {
+		panic(fmt.Errorf("queue: table %s not found: %w", table, err))
+	}
+	return db
+}
+
+// Enqueue adds an item to the queue.
+// On a unique queue it returns ErrDuplicate if the item is already pending or in flight.
+// The check is best effort, the queue itself guarantees the uniqueness.
 func (q *queue) Enqueue(item []byte) error {
+	if q.unique {
+		var exists bool
+		err := q.lookup.QueryRow(fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE item = ?)", q.table), item).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrDuplicate
+		}
+	}
+
 	return q.queue.Enqueue(item)
 }
 
-func (q *queue) Dequeue() ([]byte, error) {
-	msg, err := q.queue.Dequeue()
+func (q *queue) Dequeue(ctx context.Context) (Message, error) {
+	msg, err := q.queue.DequeueCtx(ctx)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{ID: msg.ID, Item: msg.Item}, nil
+}
+
+// Extend pushes the ack deadline of the message back by the ack timeout.
+// It returns ErrLeaseLost if the deadline already expired or the message was acked.
+func (q *queue) Extend(id int64) error {
+	// acked messages are kept in the ack queue with their processed time, and deleted from the unique one
+	query := fmt.Sprintf("UPDATE %s SET ack_deadline = ? WHERE id = ? AND ack_deadline >= ?", q.table)
+	if !q.unique {
+		query += " AND processed_at IS NULL"
+	}
+
+	now := time.Now()
+	res, err := q.lookup.Exec(query, now.Add(q.ackTimeout).Unix(), id, now.Unix())
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// Ack removes the message from the queue.
+// It returns ErrLeaseLost if the ack deadline expired, gopq would ignore the ack.
+func (q *queue) Ack(id int64) error {
+	q.mu.Lock()
+	delete(q.attempts, id)
+	delete(q.reasons, id)
+	q.mu.Unlock()
+
+	if err := q.Extend(id); err != nil {
+		return err
+	}
+	return q.queue.Ack(id)
+}
+
+// Nack requeues the message with an exponential backoff.
+// Once the max retries are exhausted the message is moved to the dead letter queue.
+// Attempts are tracked in memory, so the backoff restarts from the base
+// value if the process restarts, but the max retries are still enforced.
+// The effective backoff is never shorter than the ack timeout.
+// It returns ErrLeaseLost if the ack deadline expired, the message is then retried without counting the attempt.
+func (q *queue) Nack(id int64, reason error) (bool, error) {
+	if err := q.Extend(id); err != nil {
+		q.mu.Lock()
+		delete(q.attempts, id)
+		delete(q.reasons, id)
+		q.mu.Unlock()
+		return false, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	attempt := q.attempts[id]
+	q.attempts[id] = attempt + 1
+	if reason != nil {
+		q.reasons[id] = reason.Error()
+	}
+
+	q.exhausted = false
+	q.queue.AckOpts.RetryBackoff = backoff(q.retryBackoff, attempt)
+	err := q.queue.Nack(id)
+
 	if err != nil {
-		return nil, err
+		delete(q.attempts, id)
+		delete(q.reasons, id)
+	}
+
+	return q.exhausted, err
+}
+
+// Release expires the ack deadline of the message, so it is dequeued again first.
+// The attempts of the message are kept, its retries are only counted by Nack.
+func (q *queue) Release(id int64) error {
+	return q.queue.ExpireAck(id)
+}
+
+// onFailure is called by the underlying queue from within Nack,
+// when a message exhausted its retries, so q.mu is already held.
+func (q *queue) onFailure(msg gopq.Msg) error {
+	q.exhausted = true
+	reason := q.reasons[msg.ID]
+	delete(q.attempts, msg.ID)
+	delete(q.reasons, msg.ID)
+
+	if q.deadLetter == nil {
+		return nil
 	}
-	return msg.Item, nil
+
+	return q.deadLetter.Add(msg.Item, reason, q.maxRetries+1)
 }
 
 func (q *queue) Close() error {
+	q.lookup.Close()
 	return q.queue.Close()
 }
+
+// backoff returns the base backoff doubled for every previous attempt.
+func backoff(base time.Duration, attempt int) time.Duration {
+	d := base
+	for range attempt {
+		d *= 2
+		if d >= maxRetryBackoff {
+			return maxRetryBackoff
+		}
+	}
+	return d
+}
@@ -0,0 +1,151 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUniqueEnqueue(t *testing.T) {
+	q := New(filepath.Join(t.TempDir(), "queue.db"), WithUnique(true))
+	defer q.Close()
+
+	if err := q.Enqueue([]byte("1458862350")); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if err := q.Enqueue([]byte("1458862350")); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("Enqueue() of a duplicate error = %v, want %v", err, ErrDuplicate)
+	}
+	if err := q.Enqueue([]byte("389801252")); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+}
+
+func TestNackReportsExhaustedRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		want       bool
+	}{
+		{name: "retries left", maxRetries: 1, want: false},
+		{name: "no retries left", maxRetries: 0, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadLetter := NewDeadLetter(filepath.Join(t.TempDir(), "dead_letter.db"))
+			defer deadLetter.Close()
+			q := New(filepath.Join(t.TempDir(), "queue.db"), WithMaxRetries(tt.maxRetries), WithDeadLetter(deadLetter))
+			defer q.Close()
+
+			if err := q.Enqueue([]byte("1458862350")); err != nil {
+				t.Fatalf("Enqueue() error = %v", err)
+			}
+			msg, err := q.Dequeue(context.Background())
+			if err != nil {
+				t.Fatalf("Dequeue() error = %v", err)
+			}
+
+			exhausted, err := q.Nack(msg.ID, errors.New("failed"))
+			if err != nil {
+				t.Fatalf("Nack() error = %v", err)
+			}
+			if exhausted != tt.want {
+				t.Errorf("Nack() exhausted = %v, want %v", exhausted, tt.want)
+			}
+
+			items, err := deadLetter.List()
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if got := len(items) == 1; got != tt.want {
+				t.Errorf("got %d dead letters, want the message dead lettered: %v", len(items), tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseDoesNotCountARetry(t *testing.T) {
+	q := New(filepath.Join(t.TempDir(), "queue.db"), WithMaxRetries(1))
+	defer q.Close()
+
+	if err := q.Enqueue([]byte("1458862350")); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	msg, err := q.Dequeue(context.Background())
+	if err != nil {
+		t.Fatalf("Dequeue() error = %v", err)
+	}
+	if err := q.Release(msg.ID); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, err = q.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("Dequeue() after Release() error = %v", err)
+	}
+
+	exhausted, err := q.Nack(msg.ID, errors.New("failed"))
+	if err != nil {
+		t.Fatalf("Nack() error = %v", err)
+	}
+	if exhausted {
+		t.Errorf("Nack() exhausted = true, want the released attempt not counted")
+	}
+}
+
+func TestLeaseLost(t *testing.T) {
+	tests := []struct {
+		name   string
+		unique bool
+		// settle acks or releases the dequeued message before the call
+		settle func(q Queue, id int64) error
+		call   func(q Queue, id int64) error
+		want   error
+	}{
+		{name: "extend in flight", call: Queue.Extend},
+		{name: "extend in flight unique", unique: true, call: Queue.Extend},
+		{name: "extend expired", settle: Queue.Release, call: Queue.Extend, want: ErrLeaseLost},
+		{name: "extend acked", settle: Queue.Ack, call: Queue.Extend, want: ErrLeaseLost},
+		{name: "extend acked unique", unique: true, settle: Queue.Ack, call: Queue.Extend, want: ErrLeaseLost},
+		{name: "ack expired", settle: Queue.Release, call: Queue.Ack, want: ErrLeaseLost},
+		{name: "ack expired unique", unique: true, settle: Queue.Release, call: Queue.Ack, want: ErrLeaseLost},
+		{
+			name:   "nack expired",
+			settle: Queue.Release,
+			call: func(q Queue, id int64) error {
+				_, err := q.Nack(id, errors.New("failed"))
+				return err
+			},
+			want: ErrLeaseLost,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := New(filepath.Join(t.TempDir(), "queue.db"), WithUnique(tt.unique))
+			defer q.Close()
+
+			if err := q.Enqueue([]byte("1458862350")); err != nil {
+				t.Fatalf("Enqueue() error = %v", err)
+			}
+			msg, err := q.Dequeue(context.Background())
+			if err != nil {
+				t.Fatalf("Dequeue() error = %v", err)
+			}
+			if tt.settle != nil {
+				if err := tt.settle(q, msg.ID); err != nil {
+					t.Fatalf("settling the message error = %v", err)
+				}
+			}
+
+			if err := tt.call(q, msg.ID); !errors.Is(err, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
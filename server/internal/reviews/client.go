@@ -5,22 +5,22 @@ import (
 	"log/slog"
 
 	"github.com/renantatsuo/app-review/server/internal/config"
-	"github.com/renantatsuo/app-review/server/pkg/apple"
+	"github.com/renantatsuo/app-review/server/internal/sources"
 )
 
 // ReviewsClient is the client for the reviews.
 type ReviewsClient struct {
-	logger *slog.Logger
-	apple  *apple.AppleClient
-	db     *sql.DB
-	config config.Config
+	logger  *slog.Logger
+	sources sources.Sources
+	db      *sql.DB
+	config  config.Config
 }
 
-func New(logger *slog.Logger, apple *apple.AppleClient, db *sql.DB, config config.Config) *ReviewsClient {
+func New(logger *slog.Logger, sources sources.Sources, db *sql.DB, config config.Config) *ReviewsClient {
 	return &ReviewsClient{
-		logger: logger,
-		apple:  apple,
-		db:     db,
-		config: config,
+		logger:  logger,
+		sources: sources,
+		db:      db,
+		config:  config,
 	}
 }
@@ -1,56 +1,213 @@
 package reviews
 
 import (
-	"time"
+	"database/sql"
+	"errors"
 
 	"github.com/renantatsuo/app-review/server/internal/models"
 )
 
-// FindReviewsByAppID returns all the reviews for a given app ID.
-func (r *ReviewsClient) FindReviewsByAppID(appID string, since time.Time) ([]models.Review, error) {
+// reviewColumns are the columns scanned by scanReview.
+const reviewColumns = "id, app_id, country, author, title, content, rating, sent_at, created_at, updated_at"
+
+// FindReviewsByAppID returns the reviews matching the query, most recent first.
+// If the query is limited and more reviews match, it also returns the cursor of the next page.
+// The reviews of a workspace query have the triage of the workspace.
+func (r *ReviewsClient) FindReviewsByAppID(q Query) ([]models.Review, *Cursor, error) {
 	reviews := []models.Review{}
 
-	rows, err := r.db.Query("SELECT id, app_id, author, title, content, rating, sent_at, created_at, updated_at FROM reviews WHERE app_id = ? AND sent_at > ? ORDER BY sent_at DESC", appID, since)
+	where, args := q.where()
+	query := "SELECT " + reviewColumns + " FROM reviews WHERE " + where + " ORDER BY sent_at DESC, id DESC"
+	if q.limit > 0 {
+		// fetch one more review to know if there is a next page
+		query += " LIMIT ?"
+		args = append(args, q.limit+1)
+	}
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var review models.Review
-		err := rows.Scan(&review.ID, &review.AppID, &review.Author, &review.Title,
-			&review.Content, &review.Rating, &review.SentAt,
-			&review.CreatedAt, &review.UpdatedAt)
+		review, err := scanReview(rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		reviews = append(reviews, review)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var next *Cursor
+	if q.limit > 0 && len(reviews) > q.limit {
+		reviews = reviews[:q.limit]
+		last := reviews[len(reviews)-1]
+		next = &Cursor{SentAt: last.SentAt, ID: last.ID}
+	}
+
+	if q.workspaceID != 0 {
+		if err := r.addTriage(q.workspaceID, reviews); err != nil {
+			return nil, nil, err
+		}
+	}
 
-	return reviews, nil
+	return reviews, next, nil
 }
 
-// FindLatestReviewByAppID finds the latest review for a given app ID.
-func (r *ReviewsClient) FindLatestReviewByAppID(appID string) (models.Review, error) {
-	review := models.Review{}
+// EachReview calls fn with every review matching the query, most recent first.
+// The reviews are streamed from the database one at a time instead of loaded in memory,
+// so it can go through all the reviews of an app. It stops at the first error returned by fn.
+func (r *ReviewsClient) EachReview(q Query, fn func(models.Review) error) error {
+	where, args := q.where()
+	query := "SELECT " + reviewColumns + " FROM reviews WHERE " + where + " ORDER BY sent_at DESC, id DESC"
+	if q.limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.limit)
+	}
 
-	row := r.db.QueryRow("SELECT id, app_id, author, title, content, rating, sent_at, created_at, updated_at FROM reviews WHERE app_id = ? ORDER BY created_at DESC LIMIT 1", appID)
-	if err := row.Scan(&review.ID, &review.AppID, &review.Author, &review.Title,
-		&review.Content, &review.Rating, &review.SentAt,
-		&review.CreatedAt, &review.UpdatedAt); err != nil {
-		return models.Review{}, err
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
 	}
+	defer rows.Close()
 
-	return review, nil
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(review); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
 }
 
-// AddReview adds a new review to the database.
-func (r *ReviewsClient) AddReview(review models.Review) error {
-	_, err := r.db.Exec(
-		"INSERT INTO reviews (id, app_id, author, title, content, rating, sent_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		review.ID, review.AppID, review.Author, review.Title, review.Content, review.Rating, review.SentAt)
+// UpsertReview adds the review to the database, or updates it if it was edited.
+// The previous version of an edited review is kept as a revision,
+// and the review_daily_stats rollup is updated along with the review.
+// A created review is also appended to the review_events sequence the reviews streams tail,
+// and to the webhook_outbox the dispatcher enqueues to the webhook subscriptions.
+// Reviews that are already stored unchanged are ignored, so a retried job can upsert the same reviews again,
+// as are versions older than the stored one.
+// The sent_at is stored in UTC so it can be compared and paginated on.
+func (r *ReviewsClient) UpsertReview(review models.Review) (models.ReviewChange, error) {
+	return r.upsertReview(review, true)
+}
+
+// UpsertBackfilledReview upserts the review as UpsertReview does,
+// but the webhook subscriptions are not notified of it as it is not new.
+func (r *ReviewsClient) UpsertBackfilledReview(review models.Review) (models.ReviewChange, error) {
+	return r.upsertReview(review, false)
+}
+
+// upsertReview upserts the review, adding it to the webhook_outbox if it is created and notify is true.
+func (r *ReviewsClient) upsertReview(review models.Review, notify bool) (models.ReviewChange, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return err
+		return models.ReviewUnchanged, err
+	}
+	defer tx.Rollback()
+
+	var stored models.ReviewRevision
+	err = tx.QueryRow("SELECT author, title, content, rating, sent_at FROM reviews WHERE id = ?", review.ID).
+		Scan(&stored.Author, &stored.Title, &stored.Content, &stored.Rating, &stored.SentAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err := tx.Exec(
+			"INSERT INTO reviews (id, app_id, country, author, title, content, rating, sent_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			review.ID, review.AppID, review.Country, review.Author, review.Title, review.Content, review.Rating, review.SentAt.UTC())
+		if err != nil {
+			return models.ReviewUnchanged, err
+		}
+		if err := addDailyStats(tx, review.AppID, review.Country, review.SentAt, review.Rating, 1); err != nil {
+			return models.ReviewUnchanged, err
+		}
+		if err := addReviewEvent(tx, review); err != nil {
+			return models.ReviewUnchanged, err
+		}
+		if notify {
+			if err := addWebhookOutbox(tx, review); err != nil {
+				return models.ReviewUnchanged, err
+			}
+		}
+		return models.ReviewCreated, tx.Commit()
+	}
+	if err != nil {
+		return models.ReviewUnchanged, err
+	}
+
+	if !stored.Edited(review) || review.SentAt.Before(stored.SentAt) {
+		return models.ReviewUnchanged, nil
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO review_revisions (review_id, app_id, author, title, content, rating, sent_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		review.ID, review.AppID, stored.Author, stored.Title, stored.Content, stored.Rating, stored.SentAt.UTC())
+	if err != nil {
+		return models.ReviewUnchanged, err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE reviews SET author = ?, title = ?, content = ?, rating = ?, sent_at = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		review.Author, review.Title, review.Content, review.Rating, review.SentAt.UTC(), review.ID)
+	if err != nil {
+		return models.ReviewUnchanged, err
+	}
+
+	// move the review from its previous day and rating to the new ones
+	if err := addDailyStats(tx, review.AppID, review.Country, stored.SentAt, stored.Rating, -1); err != nil {
+		return models.ReviewUnchanged, err
+	}
+	if err := addDailyStats(tx, review.AppID, review.Country, review.SentAt, review.Rating, 1); err != nil {
+		return models.ReviewUnchanged, err
+	}
+
+	return models.ReviewEdited, tx.Commit()
+}
+
+// FindReviewHistory returns every version of the review of the given app watched by the workspace, oldest first.
+// The last one is the current version of the review.
+func (r *ReviewsClient) FindReviewHistory(workspaceID int64, appID string, reviewID string) ([]models.ReviewRevision, error) {
+	var current models.ReviewRevision
+	err := r.db.QueryRow("SELECT author, title, content, rating, sent_at FROM reviews WHERE id = ? AND app_id = ? AND "+watchedBy("reviews.app_id", "reviews.country"),
+		reviewID, appID, workspaceID).
+		Scan(&current.Author, &current.Title, &current.Content, &current.Rating, &current.SentAt)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	current.Current = true
+
+	revisions := []models.ReviewRevision{}
+
+	rows, err := r.db.Query("SELECT author, title, content, rating, sent_at FROM review_revisions WHERE review_id = ? ORDER BY sent_at, id", reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision models.ReviewRevision
+		if err := rows.Scan(&revision.Author, &revision.Title, &revision.Content, &revision.Rating, &revision.SentAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return append(revisions, current), nil
+}
+
+// scanReview scans a row of reviewColumns into a review.
+func scanReview(row interface{ Scan(...any) error }) (models.Review, error) {
+	var review models.Review
+	err := row.Scan(&review.ID, &review.AppID, &review.Country, &review.Author, &review.Title,
+		&review.Content, &review.Rating, &review.SentAt,
+		&review.CreatedAt, &review.UpdatedAt)
+	return review, err
 }
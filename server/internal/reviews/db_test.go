@@ -0,0 +1,107 @@
+package reviews
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+func TestUpsertReviewEdits(t *testing.T) {
+	r := newTestClient(t)
+
+	sentAt := time.Date(2026, 10, 17, 23, 0, 0, 0, time.UTC)
+	review := models.Review{ID: "1", AppID: testAppID, Country: "us", Author: "author", Content: "crashes on launch", Rating: 2, SentAt: sentAt}
+	edited := review
+	edited.Content, edited.Rating, edited.SentAt = "fixed, thanks", 5, sentAt.Add(2*time.Hour)
+
+	outbox := func() int {
+		var count int
+		if err := r.db.QueryRow("SELECT COUNT(*) FROM webhook_outbox WHERE app_id = ?", testAppID).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	tests := []struct {
+		name   string
+		review models.Review
+		want   models.ReviewChange
+		// wantRatings are the ratings of the history of the review, oldest first
+		wantRatings []int
+		// wantDays are the count of the reviews with the rating by day
+		wantDays map[string]map[int]int
+	}{
+		{
+			name:        "created",
+			review:      review,
+			want:        models.ReviewCreated,
+			wantRatings: []int{2},
+			wantDays:    map[string]map[int]int{"2026-10-17": {2: 1}},
+		},
+		{
+			name:        "edited",
+			review:      edited,
+			want:        models.ReviewEdited,
+			wantRatings: []int{2, 5},
+			wantDays:    map[string]map[int]int{"2026-10-17": {2: 0}, "2026-10-18": {5: 1}},
+		},
+		{
+			name:        "older version",
+			review:      review,
+			want:        models.ReviewUnchanged,
+			wantRatings: []int{2, 5},
+			wantDays:    map[string]map[int]int{"2026-10-17": {2: 0}, "2026-10-18": {5: 1}},
+		},
+		{
+			name:        "same version",
+			review:      edited,
+			want:        models.ReviewUnchanged,
+			wantRatings: []int{2, 5},
+			wantDays:    map[string]map[int]int{"2026-10-17": {2: 0}, "2026-10-18": {5: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, err := r.UpsertReview(tt.review)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if change != tt.want {
+				t.Errorf("got change %v, want %v", change, tt.want)
+			}
+
+			history, err := r.FindReviewHistory(models.DefaultWorkspaceID, testAppID, review.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ratings := []int{}
+			for _, revision := range history {
+				ratings = append(ratings, revision.Rating)
+			}
+			if fmt.Sprint(ratings) != fmt.Sprint(tt.wantRatings) {
+				t.Errorf("got history ratings %v, want %v", ratings, tt.wantRatings)
+			}
+
+			for day, counts := range tt.wantDays {
+				for rating, want := range counts {
+					var count int
+					err := r.db.QueryRow("SELECT COALESCE(SUM(count), 0) FROM review_daily_stats WHERE app_id = ? AND day = ? AND rating = ?", testAppID, day, rating).Scan(&count)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if count != want {
+						t.Errorf("got %d reviews rated %d on %s, want %d", count, rating, day, want)
+					}
+				}
+			}
+
+			// only the creation of the review is posted to the webhooks
+			if got := outbox(); got != 1 {
+				t.Errorf("got %d webhook outbox rows, want 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package reviews
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// addReviewEvent appends the created review to the review_events sequence, in the transaction storing it,
+// so the server tailing the sequence sees every review once it is committed.
+func addReviewEvent(tx *sql.Tx, review models.Review) error {
+	_, err := tx.Exec("INSERT INTO review_events (review_id, app_id, created_at) VALUES (?, ?, ?)", review.ID, review.AppID, time.Now().UTC())
+	return err
+}
+
+// addWebhookOutbox adds the created review to the webhook_outbox, in the transaction storing it,
+// so the review is notified once it is committed even if the job storing it fails afterwards.
+func addWebhookOutbox(tx *sql.Tx, review models.Review) error {
+	_, err := tx.Exec("INSERT INTO webhook_outbox (review_id, app_id, created_at) VALUES (?, ?, ?)", review.ID, review.AppID, time.Now().UTC())
+	return err
+}
+
+// FindReviewEvents returns up to limit events after the given event id, oldest first,
+// of the apps watched by the workspace, or only of the given app if it is not empty.
+// The events of the deleted reviews are skipped.
+func (r *ReviewsClient) FindReviewEvents(workspaceID int64, appID string, afterID int64, limit int) ([]models.ReviewEvent, error) {
+	events := []models.ReviewEvent{}
+
+	rows, err := r.db.Query(`SELECT e.id, r.id, r.app_id, r.country, r.author, r.title, r.content, r.rating, r.sent_at, r.created_at, r.updated_at
+		FROM review_events e JOIN reviews r ON r.id = e.review_id
+		WHERE e.id > ? AND (? = '' OR e.app_id = ?) AND `+watchedBy("e.app_id", "r.country")+` ORDER BY e.id LIMIT ?`,
+		afterID, appID, appID, workspaceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event models.ReviewEvent
+		err := rows.Scan(&event.ID, &event.Review.ID, &event.AppID, &event.Country, &event.Author, &event.Title,
+			&event.Content, &event.Rating, &event.SentAt, &event.CreatedAt, &event.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// LastReviewEventID returns the id of the last review event, zero if there is none.
+func (r *ReviewsClient) LastReviewEventID() (int64, error) {
+	var id int64
+	err := r.db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM review_events").Scan(&id)
+	return id, err
+}
+
+// PruneReviewEvents deletes the review events created before the given time and returns how many were deleted.
+// The reviews themselves are kept, only a stream resuming from a pruned event misses them.
+func (r *ReviewsClient) PruneReviewEvents(before time.Time) (int64, error) {
+	res, err := r.db.Exec("DELETE FROM review_events WHERE created_at < ?", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
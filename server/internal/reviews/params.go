@@ -0,0 +1,87 @@
+package reviews
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseQuery builds the query over the reviews of the app watched by the workspace from URL query parameters:
+//   - country: only the reviews from this country storefront
+//   - min_rating, max_rating: only the reviews rated in this range
+//   - since, until: only the reviews sent in this range, as RFC 3339 or 2006-01-02 dates.
+//     Without them only the reviews newer than timeLimit are matched, zero matches all of them.
+//   - author: only the reviews of this author
+//   - cursor: the next_cursor of the previous page
+func ParseQuery(workspaceID int64, appID string, params url.Values, timeLimit time.Duration) (Query, error) {
+	query := NewQuery(appID).
+		Workspace(workspaceID).
+		Country(strings.ToLower(params.Get("country"))).
+		Author(params.Get("author"))
+
+	minRating, err := ParseRating(params.Get("min_rating"))
+	if err != nil {
+		return Query{}, fmt.Errorf("invalid min_rating: %w", err)
+	}
+	maxRating, err := ParseRating(params.Get("max_rating"))
+	if err != nil {
+		return Query{}, fmt.Errorf("invalid max_rating: %w", err)
+	}
+	if minRating > 0 && maxRating > 0 && minRating > maxRating {
+		return Query{}, errors.New("min_rating must not be greater than max_rating")
+	}
+	query = query.Rating(minRating, maxRating)
+
+	since, err := ParseDate(params.Get("since"))
+	if err != nil {
+		return Query{}, fmt.Errorf("invalid since: %w", err)
+	}
+	until, err := ParseDate(params.Get("until"))
+	if err != nil {
+		return Query{}, fmt.Errorf("invalid until: %w", err)
+	}
+	if since.IsZero() && until.IsZero() && timeLimit > 0 {
+		since = time.Now().Add(-timeLimit)
+	}
+	query = query.Since(since).Until(until)
+
+	if raw := params.Get("cursor"); raw != "" {
+		cursor, err := DecodeCursor(raw)
+		if err != nil {
+			return Query{}, err
+		}
+		query = query.After(cursor)
+	}
+
+	return query, nil
+}
+
+// ParseRating parses a rating between 1 and 5, zero if empty.
+func ParseRating(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+
+	rating, err := strconv.Atoi(raw)
+	if err != nil || rating < 1 || rating > 5 {
+		return 0, errors.New("rating must be a number between 1 and 5")
+	}
+
+	return rating, nil
+}
+
+// ParseDate parses a RFC 3339 time or a 2006-01-02 date, zero if empty.
+func ParseDate(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.DateOnly, raw)
+}
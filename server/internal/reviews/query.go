@@ -0,0 +1,164 @@
+package reviews
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points to the last review of a page.
+// Reviews are ordered by (sent_at, id) descending, so the next page starts right after it.
+type Cursor struct {
+	SentAt time.Time `json:"s"`
+	ID     string    `json:"i"`
+}
+
+// Encode encodes the cursor to an opaque string.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor decodes a cursor encoded with Cursor.Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// Query is a query over the reviews of an app.
+// It is built by chaining its methods, e.g.
+//
+//	reviews.NewQuery(appID).Workspace(workspaceID).Country("us").Rating(1, 2).Limit(50)
+type Query struct {
+	appID       string
+	workspaceID int64
+	country     string
+	minRating   int
+	maxRating   int
+	since       time.Time
+	until       time.Time
+	author      string
+	after       *Cursor
+	limit       int
+}
+
+// NewQuery creates a query over all the reviews of the given app, whichever workspaces watch it.
+// The queries of a workspace must be restricted with Workspace.
+func NewQuery(appID string) Query {
+	return Query{appID: appID}
+}
+
+// Workspace only matches the reviews if the workspace watches the app.
+func (q Query) Workspace(workspaceID int64) Query {
+	q.workspaceID = workspaceID
+	return q
+}
+
+// Country only matches the reviews from the given country storefront.
+func (q Query) Country(country string) Query {
+	q.country = country
+	return q
+}
+
+// Rating only matches the reviews rated between minRating and maxRating, inclusive.
+// A zero value leaves that side of the range open.
+func (q Query) Rating(minRating, maxRating int) Query {
+	q.minRating = minRating
+	q.maxRating = maxRating
+	return q
+}
+
+// Since only matches the reviews sent after the given time.
+func (q Query) Since(since time.Time) Query {
+	q.since = since
+	return q
+}
+
+// Until only matches the reviews sent before the given time.
+func (q Query) Until(until time.Time) Query {
+	q.until = until
+	return q
+}
+
+// Author only matches the reviews of the given author, case insensitive.
+func (q Query) Author(author string) Query {
+	q.author = author
+	return q
+}
+
+// After only matches the reviews after the given cursor.
+func (q Query) After(cursor Cursor) Query {
+	q.after = &cursor
+	return q
+}
+
+// Limit limits the number of reviews returned, zero means no limit.
+func (q Query) Limit(limit int) Query {
+	q.limit = limit
+	return q
+}
+
+// where builds the WHERE clause of the query and its arguments.
+func (q Query) where() (string, []any) {
+	conditions := []string{"app_id = ?"}
+	args := []any{q.appID}
+
+	if q.workspaceID != 0 {
+		conditions = append(conditions, watchedBy("reviews.app_id", "reviews.country"))
+		args = append(args, q.workspaceID)
+	}
+
+	if q.country != "" {
+		conditions = append(conditions, "country = ?")
+		args = append(args, q.country)
+	}
+	if q.minRating > 0 {
+		conditions = append(conditions, "rating >= ?")
+		args = append(args, q.minRating)
+	}
+	if q.maxRating > 0 {
+		conditions = append(conditions, "rating <= ?")
+		args = append(args, q.maxRating)
+	}
+	if !q.since.IsZero() {
+		conditions = append(conditions, "sent_at > ?")
+		args = append(args, q.since.UTC())
+	}
+	if !q.until.IsZero() {
+		conditions = append(conditions, "sent_at < ?")
+		args = append(args, q.until.UTC())
+	}
+	if q.author != "" {
+		conditions = append(conditions, "author = ? COLLATE NOCASE")
+		args = append(args, q.author)
+	}
+	if q.after != nil {
+		conditions = append(conditions, "(sent_at < ? OR (sent_at = ? AND id < ?))")
+		args = append(args, q.after.SentAt.UTC(), q.after.SentAt.UTC(), q.after.ID)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// watchedBy returns the condition matching the rows of the app storefronts the workspace watches,
+// on the app id and country columns, so a workspace does not see the storefronts of an app only another one watches.
+// The columns must be qualified with their table, workspace_apps also has an app_id column. Its argument is the workspace id.
+func watchedBy(appColumn string, countryColumn string) string {
+	// the countries of a workspace app are comma separated, they are wrapped in commas to match whole countries
+	return "EXISTS (SELECT 1 FROM workspace_apps w WHERE w.workspace_id = ? AND w.app_id = " + appColumn +
+		" AND instr(',' || w.countries || ',', ',' || " + countryColumn + " || ',') > 0)"
+}
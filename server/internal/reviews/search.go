@@ -0,0 +1,123 @@
+package reviews
+
+import (
+	"errors"
+	"html"
+	"strings"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+const (
+	highlightStart  = "<mark>"
+	highlightEnd    = "</mark>"
+	snippetEllipsis = "…"
+
+	// highlightStartSentinel and highlightEndSentinel delimit the matches in the FTS5 output,
+	// they are private use characters replaced by the highlight tags once the text is HTML escaped.
+	highlightStartSentinel = "\ue000"
+	highlightEndSentinel   = "\ue001"
+
+	snippetTokens = 16
+
+	// titleWeight makes a match in the title count more than one in the content.
+	titleWeight   = 10.0
+	contentWeight = 1.0
+)
+
+// ErrEmptySearch is returned when the search query has no terms.
+var ErrEmptySearch = errors.New("search query is empty")
+
+// SearchReviews searches the reviews title and content of the apps watched by the workspace, most relevant first.
+// If appID is not empty only the reviews of that app are searched.
+//
+// The query is a list of terms that must all match:
+//   - "quoted text" matches the exact phrase
+//   - term* matches every word starting with term
+func (r *ReviewsClient) SearchReviews(workspaceID int64, query string, appID string, limit int, offset int) ([]models.ReviewSearchResult, error) {
+	match, err := buildMatchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.ReviewSearchResult{}
+
+	rows, err := r.db.Query(`SELECT r.id, r.app_id, r.country, r.author, r.title, r.content, r.rating, r.sent_at, r.created_at, r.updated_at,
+		highlight(reviews_fts, 0, ?, ?), snippet(reviews_fts, 1, ?, ?, ?, ?), bm25(reviews_fts, ?, ?) AS score
+		FROM reviews_fts JOIN reviews r ON r.seq = reviews_fts.rowid
+		WHERE reviews_fts MATCH ? AND `+watchedBy("r.app_id", "r.country")+` AND (? = '' OR r.app_id = ?)
+		ORDER BY score LIMIT ? OFFSET ?`,
+		highlightStartSentinel, highlightEndSentinel, highlightStartSentinel, highlightEndSentinel, snippetEllipsis, snippetTokens,
+		titleWeight, contentWeight, match, workspaceID, appID, appID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result models.ReviewSearchResult
+		var score float64
+		err := rows.Scan(&result.ID, &result.AppID, &result.Country, &result.Author, &result.Title,
+			&result.Content, &result.Rating, &result.SentAt,
+			&result.CreatedAt, &result.UpdatedAt,
+			&result.TitleHighlight, &result.Snippet, &score)
+		if err != nil {
+			return nil, err
+		}
+		result.TitleHighlight = escapeHighlight(result.TitleHighlight)
+		result.Snippet = escapeHighlight(result.Snippet)
+		// bm25 scores are lower for better matches
+		result.Relevance = -score
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// escapeHighlight HTML escapes the FTS5 output, then marks its matches with the highlight tags,
+// so the reviews text cannot inject markup in the clients rendering the highlights as HTML.
+func escapeHighlight(text string) string {
+	return strings.NewReplacer(highlightStartSentinel, highlightStart, highlightEndSentinel, highlightEnd).
+		Replace(html.EscapeString(text))
+}
+
+// buildMatchQuery converts the user query to a FTS5 MATCH expression.
+// Every term is quoted, so the FTS5 operators and column filters
+// cannot be used, only phrases and prefixes.
+func buildMatchQuery(query string) (string, error) {
+	terms := []string{}
+
+	for i, part := range strings.Split(query, `"`) {
+		// odd parts are between quotes
+		if i%2 == 1 {
+			if phrase := strings.TrimSpace(part); phrase != "" {
+				terms = append(terms, quoteTerm(phrase))
+			}
+			continue
+		}
+
+		for _, word := range strings.Fields(part) {
+			prefix := strings.HasSuffix(word, "*")
+			word = strings.TrimRight(word, "*")
+			if word == "" {
+				continue
+			}
+			term := quoteTerm(word)
+			if prefix {
+				term += "*"
+			}
+			terms = append(terms, term)
+		}
+	}
+
+	if len(terms) == 0 {
+		return "", ErrEmptySearch
+	}
+
+	return strings.Join(terms, " "), nil
+}
+
+// quoteTerm quotes a FTS5 string, escaping its double quotes.
+func quoteTerm(term string) string {
+	return `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+}
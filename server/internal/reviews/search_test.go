@@ -0,0 +1,45 @@
+package reviews
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEscapeHighlight(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"great \ue000app\ue001", "great <mark>app</mark>"},
+		{"<script>alert(1)</script> \ue000crash\ue001", "&lt;script&gt;alert(1)&lt;/script&gt; <mark>crash</mark>"},
+		{"<img src=x onerror=\"alert(1)\"> \ue000bug\ue001 & more", "&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>bug</mark> &amp; more"},
+	}
+
+	for _, tt := range tests {
+		if got := escapeHighlight(tt.text); got != tt.want {
+			t.Errorf("escapeHighlight(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBuildMatchQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+		err   error
+	}{
+		{"crash", `"crash"`, nil},
+		{"crash login*", `"crash" "login"*`, nil},
+		{`"does not open" ios`, `"does not open" "ios"`, nil},
+		{`title:crash OR NEAR(a b)`, `"title:crash" "OR" "NEAR(a" "b)"`, nil},
+		{`say "hi""`, `"say" "hi"`, nil},
+		{"  * \"\" ", "", ErrEmptySearch},
+	}
+
+	for _, tt := range tests {
+		got, err := buildMatchQuery(tt.query)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("buildMatchQuery(%q) = %q, %v, want %q, %v", tt.query, got, err, tt.want, tt.err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package reviews
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/sources"
+)
+
+// ErrBackfillUnsupported is returned when the reviews of the platform cannot be fetched by page.
+var ErrBackfillUnsupported = errors.New("backfill is not supported on this platform")
+
+// FetchReviewsSince fetches the latest reviews for a given app ID on the given country storefront
+// from the source of the platform.
+// It returns the reviews that were updated at or after the since time,
+// the reviews updated exactly at the since time may already be stored,
+// and the commit to call once they are persisted, see sources.Commit.
+// The reviews are also returned with a sources.TruncatedError when the older ones could not be fetched,
+// its cursor resumes the fetch after them. The cursor is empty to fetch from the latest reviews.
+func (c *ReviewsClient) FetchReviewsSince(ctx context.Context, platform models.Platform, appID string, country string, since time.Time, cursor string) ([]models.Review, sources.Commit, error) {
+	source, err := c.sources.Get(platform)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return source.FetchReviewsSince(ctx, appID, country, since, cursor)
+}
+
+// FetchReviewsPage fetches a page of the reviews for a given app ID on the given country storefront
+// from the source of the platform, and whether there is a next page. Pages start at 1, the latest reviews.
+func (c *ReviewsClient) FetchReviewsPage(ctx context.Context, platform models.Platform, appID string, country string, page int) ([]models.Review, bool, error) {
+	source, err := c.sources.Get(platform)
+	if err != nil {
+		return nil, false, err
+	}
+
+	pager, ok := source.(sources.Pager)
+	if !ok {
+		return nil, false, ErrBackfillUnsupported
+	}
+
+	return pager.FetchReviewsPage(ctx, appID, country, page)
+}
+
+// SupportsBackfill returns true if the reviews of the platform can be fetched by page.
+func (c *ReviewsClient) SupportsBackfill(platform models.Platform) bool {
+	source, err := c.sources.Get(platform)
+	if err != nil {
+		return false
+	}
+
+	_, ok := source.(sources.Pager)
+	return ok
+}
@@ -0,0 +1,76 @@
+package reviews
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// bucketExpressions are the SQL expressions of the first day of the bucket of a review_daily_stats day.
+// Weeks start on monday.
+var bucketExpressions = map[models.StatsBucket]string{
+	models.StatsBucketDay:   "day",
+	models.StatsBucketWeek:  "date(day, 'weekday 0', '-6 days')",
+	models.StatsBucketMonth: "strftime('%Y-%m-01', day)",
+}
+
+// GetAppStats returns the rating stats of the reviews of the app watched by the workspace sent between the since and until days, inclusive,
+// grouped by bucket. Buckets without reviews are omitted.
+// The stats are computed from the review_daily_stats rollup, so they do not scan the reviews.
+func (r *ReviewsClient) GetAppStats(workspaceID int64, appID string, bucket models.StatsBucket, since time.Time, until time.Time) (models.AppStats, error) {
+	stats := models.AppStats{
+		AppID:   appID,
+		Bucket:  bucket,
+		Since:   since.UTC().Format(time.DateOnly),
+		Until:   until.UTC().Format(time.DateOnly),
+		Buckets: []models.BucketStats{},
+	}
+
+	rows, err := r.db.Query("SELECT "+bucketExpressions[bucket]+" AS bucket, rating, SUM(count) FROM review_daily_stats"+
+		" WHERE app_id = ? AND "+watchedBy("review_daily_stats.app_id", "review_daily_stats.country")+" AND day >= ? AND day <= ? AND count > 0 GROUP BY bucket, rating ORDER BY bucket",
+		appID, workspaceID, stats.Since, stats.Until)
+	if err != nil {
+		return models.AppStats{}, err
+	}
+	defer rows.Close()
+
+	total := map[int]int{}
+	var start string
+	var distribution map[int]int
+	for rows.Next() {
+		var bucketStart string
+		var rating, count int
+		if err := rows.Scan(&bucketStart, &rating, &count); err != nil {
+			return models.AppStats{}, err
+		}
+
+		if bucketStart != start {
+			if distribution != nil {
+				stats.Buckets = append(stats.Buckets, models.BucketStats{Start: start, RatingStats: models.NewRatingStats(distribution)})
+			}
+			start, distribution = bucketStart, map[int]int{}
+		}
+
+		distribution[rating] += count
+		total[rating] += count
+	}
+	if err := rows.Err(); err != nil {
+		return models.AppStats{}, err
+	}
+
+	if distribution != nil {
+		stats.Buckets = append(stats.Buckets, models.BucketStats{Start: start, RatingStats: models.NewRatingStats(distribution)})
+	}
+	stats.Total = models.NewRatingStats(total)
+
+	return stats, nil
+}
+
+// addDailyStats adds delta reviews with the rating sent at sentAt to the daily stats of the app storefront.
+func addDailyStats(tx *sql.Tx, appID string, country string, sentAt time.Time, rating int, delta int) error {
+	_, err := tx.Exec(`INSERT INTO review_daily_stats (app_id, country, day, rating, count) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (app_id, country, day, rating) DO UPDATE SET count = count + excluded.count`,
+		appID, country, sentAt.UTC().Format(time.DateOnly), rating, delta)
+	return err
+}
@@ -0,0 +1,93 @@
+package reviews
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/dbtest"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/sources"
+)
+
+const testAppID = "com.example.app"
+
+// newTestClient returns a client of a temporary database where the default workspace watches the us storefront of the test app.
+func newTestClient(t *testing.T) *ReviewsClient {
+	t.Helper()
+
+	db := dbtest.New(t)
+	config, err := config.LoadConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := sources.New()
+	app := models.App{ID: testAppID, Platform: models.PlatformAndroid, Name: "Example", Countries: []string{"us"}}
+	if err := apps.New(db, sources).AddApp(models.DefaultWorkspaceID, app); err != nil {
+		t.Fatal(err)
+	}
+
+	return New(slog.New(slog.DiscardHandler), sources, db, config)
+}
+
+func TestGetAppStatsBuckets(t *testing.T) {
+	r := newTestClient(t)
+
+	// from a sunday to a monday, across the end of september
+	days := map[string]int{
+		"2026-09-27": 1, // sunday
+		"2026-09-28": 2, // monday
+		"2026-09-30": 3,
+		"2026-10-01": 4,
+		"2026-10-04": 5, // sunday
+		"2026-10-05": 5, // monday
+	}
+	i := 0
+	for day, rating := range days {
+		sentAt, _ := time.Parse(time.DateOnly, day)
+		review := models.Review{ID: fmt.Sprint(i), AppID: testAppID, Country: "us", Author: "author", Content: "content", Rating: rating, SentAt: sentAt.Add(23 * time.Hour)}
+		if _, err := r.UpsertReview(review); err != nil {
+			t.Fatal(err)
+		}
+		i++
+	}
+
+	tests := []struct {
+		bucket models.StatsBucket
+		// want are the starts of the buckets and their count and mean
+		want string
+	}{
+		{models.StatsBucketDay, "[2026-09-27 1 1] [2026-09-28 1 2] [2026-09-30 1 3] [2026-10-01 1 4] [2026-10-04 1 5] [2026-10-05 1 5]"},
+		{models.StatsBucketWeek, "[2026-09-21 1 1] [2026-09-28 4 3.5] [2026-10-05 1 5]"},
+		{models.StatsBucketMonth, "[2026-09-01 3 2] [2026-10-01 3 4.666666666666667]"},
+	}
+
+	since, _ := time.Parse(time.DateOnly, "2026-09-01")
+	until, _ := time.Parse(time.DateOnly, "2026-10-31")
+	for _, tt := range tests {
+		t.Run(string(tt.bucket), func(t *testing.T) {
+			stats, err := r.GetAppStats(models.DefaultWorkspaceID, testAppID, tt.bucket, since, until)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := ""
+			for i, bucket := range stats.Buckets {
+				if i > 0 {
+					got += " "
+				}
+				got += fmt.Sprintf("[%s %d %v]", bucket.Start, bucket.Count, bucket.Mean)
+			}
+			if got != tt.want {
+				t.Errorf("got buckets %s, want %s", got, tt.want)
+			}
+			if stats.Total.Count != len(days) {
+				t.Errorf("got %d reviews in total, want %d", stats.Total.Count, len(days))
+			}
+		})
+	}
+}
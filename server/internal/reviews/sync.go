@@ -0,0 +1,117 @@
+package reviews
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+const (
+	// syncStateColumns are the columns scanned by scanSyncState.
+	syncStateColumns = "app_id, country, high_water_mark, last_review_ids, resume_since, resume_cursor, last_fetched_at, last_error, last_error_at, updated_at"
+
+	// reviewIDsSeparator separates the review ids stored in the last_review_ids column.
+	reviewIDsSeparator = ","
+)
+
+// GetSyncState returns the sync state of the app on the country storefront.
+// An app storefront that was never fetched has an empty state.
+func (r *ReviewsClient) GetSyncState(appID string, country string) (models.SyncState, error) {
+	row := r.db.QueryRow("SELECT "+syncStateColumns+" FROM sync_state WHERE app_id = ? AND country = ?", appID, country)
+	state, err := scanSyncState(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.SyncState{AppID: appID, Country: country, LastReviewIDs: []string{}}, nil
+	}
+	return state, err
+}
+
+// GetSyncStates returns the sync state of every fetched storefront of the app watched by the workspace.
+// The storefronts fetched for the other workspaces watching the app are included, they share its reviews.
+func (r *ReviewsClient) GetSyncStates(workspaceID int64, appID string) ([]models.SyncState, error) {
+	states := []models.SyncState{}
+
+	rows, err := r.db.Query("SELECT "+syncStateColumns+" FROM sync_state WHERE app_id = ? AND "+watchedBy("sync_state.app_id", "sync_state.country")+" ORDER BY country",
+		appID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		state, err := scanSyncState(rows)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+
+	return states, rows.Err()
+}
+
+// SaveSyncSuccess records a successful fetch at the given time, moving the high water mark of the state
+// and saving the truncated fetch it resumes, if any. It clears the last error.
+func (r *ReviewsClient) SaveSyncSuccess(state models.SyncState, fetchedAt time.Time) error {
+	_, err := r.db.Exec(`INSERT INTO sync_state (app_id, country, high_water_mark, last_review_ids, resume_since, resume_cursor, last_fetched_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (app_id, country) DO UPDATE SET
+			high_water_mark = excluded.high_water_mark, last_review_ids = excluded.last_review_ids,
+			resume_since = excluded.resume_since, resume_cursor = excluded.resume_cursor,
+			last_fetched_at = excluded.last_fetched_at, last_error = '', last_error_at = NULL, updated_at = excluded.updated_at`,
+		state.AppID, state.Country, utcTime(state.HighWaterMark), strings.Join(state.LastReviewIDs, reviewIDsSeparator),
+		utcTime(state.ResumeSince), state.ResumeCursor, fetchedAt.UTC(), time.Now().UTC())
+	return err
+}
+
+// SaveSyncError records the error of a failed fetch of the app on the country storefront.
+// The high water mark and the truncated fetch to resume are kept, so the next fetch resumes from the same point.
+func (r *ReviewsClient) SaveSyncError(appID string, country string, cause error) error {
+	now := time.Now().UTC()
+	_, err := r.db.Exec(`INSERT INTO sync_state (app_id, country, last_error, last_error_at, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (app_id, country) DO UPDATE SET
+			last_error = excluded.last_error, last_error_at = excluded.last_error_at, updated_at = excluded.updated_at`,
+		appID, country, cause.Error(), now, now)
+	return err
+}
+
+// scanSyncState scans a row of syncStateColumns into a sync state.
+func scanSyncState(row interface{ Scan(...any) error }) (models.SyncState, error) {
+	var state models.SyncState
+	var highWaterMark, resumeSince, lastFetchedAt, lastErrorAt sql.NullTime
+	var lastReviewIDs string
+
+	err := row.Scan(&state.AppID, &state.Country, &highWaterMark, &lastReviewIDs, &resumeSince, &state.ResumeCursor, &lastFetchedAt,
+		&state.LastError, &lastErrorAt, &state.UpdatedAt)
+	if err != nil {
+		return models.SyncState{}, err
+	}
+
+	state.HighWaterMark = nullTime(highWaterMark)
+	state.ResumeSince = nullTime(resumeSince)
+	state.LastFetchedAt = nullTime(lastFetchedAt)
+	state.LastErrorAt = nullTime(lastErrorAt)
+	state.LastReviewIDs = []string{}
+	if lastReviewIDs != "" {
+		state.LastReviewIDs = strings.Split(lastReviewIDs, reviewIDsSeparator)
+	}
+
+	return state, nil
+}
+
+// nullTime returns the time, or nil if it is NULL.
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// utcTime returns the time in UTC, or nil to store NULL.
+func utcTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
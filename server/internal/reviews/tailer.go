@@ -0,0 +1,127 @@
+package reviews
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/config"
+)
+
+// pruneInterval is how often the tailer deletes the review events older than the stream retention.
+const pruneInterval = time.Hour
+
+// Tailer tails the review_events sequence the consumer appends to, and wakes its subscribers when new events are committed.
+// The consumer is another process, so the shared database is the only way it tells the server about the new reviews.
+// Every subscriber then reads the events after the last one it sent, so the tailer only polls the last event id.
+type Tailer struct {
+	l             *slog.Logger
+	reviewsClient *ReviewsClient
+	config        config.Config
+	mu            sync.Mutex
+	subscribers   map[chan struct{}]struct{}
+	stopped       bool
+}
+
+func NewTailer(l *slog.Logger, reviewsClient *ReviewsClient, config config.Config) *Tailer {
+	return &Tailer{
+		l:             l,
+		reviewsClient: reviewsClient,
+		config:        config,
+		subscribers:   map[chan struct{}]struct{}{},
+	}
+}
+
+// Start polls the last review event id every stream poll interval and prunes the old events,
+// until the context is done. The subscribers channels are then closed.
+func (t *Tailer) Start(ctx context.Context) {
+	t.l.Info("starting review events tailer", "pollInterval", t.config.StreamPollInterval.String())
+
+	lastID, err := t.reviewsClient.LastReviewEventID()
+	if err != nil {
+		t.l.Error("error getting the last review event", "error", err)
+	}
+
+	ticker := time.NewTicker(t.config.StreamPollInterval)
+	pruneTicker := time.NewTicker(pruneInterval)
+
+	go func() {
+		defer ticker.Stop()
+		defer pruneTicker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				t.stop()
+				return
+			case <-ticker.C:
+				id, err := t.reviewsClient.LastReviewEventID()
+				if err != nil {
+					t.l.Error("error getting the last review event", "error", err)
+					continue
+				}
+				if id != lastID {
+					lastID = id
+					t.notify()
+				}
+			case now := <-pruneTicker.C:
+				pruned, err := t.reviewsClient.PruneReviewEvents(now.Add(-t.config.StreamRetention))
+				if err != nil {
+					t.l.Error("error pruning review events", "error", err)
+					continue
+				}
+				t.l.Debug("pruned review events", "count", pruned)
+			}
+		}
+	}()
+}
+
+// Subscribe returns a channel receiving a value when new review events are committed, and a function to unsubscribe.
+// Notifications coalesce while the subscriber is busy, and the channel is closed when the tailer stops.
+func (t *Tailer) Subscribe() (<-chan struct{}, func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	if t.stopped {
+		close(ch)
+		return ch, func() {}
+	}
+	t.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		if _, ok := t.subscribers[ch]; ok {
+			delete(t.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// notify wakes every subscriber, without blocking on the ones that have not handled the previous notification yet.
+func (t *Tailer) notify() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for ch := range t.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// stop closes the channels of every subscriber.
+func (t *Tailer) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stopped = true
+	for ch := range t.subscribers {
+		delete(t.subscribers, ch)
+		close(ch)
+	}
+}
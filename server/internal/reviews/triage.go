@@ -0,0 +1,76 @@
+package reviews
+
+import (
+	"strings"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// SetTriage sets the triage of the review of the given app watched by the workspace, recording who triaged it.
+// It returns sql.ErrNoRows if the workspace does not watch the review.
+func (r *ReviewsClient) SetTriage(workspaceID int64, appID string, reviewID string, status models.TriageStatus, by string) (models.ReviewTriage, error) {
+	if err := r.checkWatched(workspaceID, appID, reviewID); err != nil {
+		return models.ReviewTriage{}, err
+	}
+
+	triage := models.ReviewTriage{Status: status, TriagedBy: by, TriagedAt: time.Now().UTC().Truncate(time.Second)}
+	_, err := r.db.Exec(`INSERT INTO review_triage (workspace_id, review_id, status, triaged_by, triaged_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (workspace_id, review_id) DO UPDATE SET status = excluded.status, triaged_by = excluded.triaged_by, triaged_at = excluded.triaged_at`,
+		workspaceID, reviewID, triage.Status, triage.TriagedBy, triage.TriagedAt)
+	if err != nil {
+		return models.ReviewTriage{}, err
+	}
+
+	return triage, nil
+}
+
+// ClearTriage removes the triage of the review of the given app watched by the workspace.
+// It returns sql.ErrNoRows if the workspace does not watch the review.
+func (r *ReviewsClient) ClearTriage(workspaceID int64, appID string, reviewID string) error {
+	if err := r.checkWatched(workspaceID, appID, reviewID); err != nil {
+		return err
+	}
+
+	_, err := r.db.Exec("DELETE FROM review_triage WHERE workspace_id = ? AND review_id = ?", workspaceID, reviewID)
+	return err
+}
+
+// checkWatched returns sql.ErrNoRows if the review of the app is not on a storefront the workspace watches.
+func (r *ReviewsClient) checkWatched(workspaceID int64, appID string, reviewID string) error {
+	var id string
+	return r.db.QueryRow("SELECT id FROM reviews WHERE id = ? AND app_id = ? AND "+watchedBy("reviews.app_id", "reviews.country"),
+		reviewID, appID, workspaceID).Scan(&id)
+}
+
+// addTriage sets the triage of the workspace on the reviews it triaged.
+func (r *ReviewsClient) addTriage(workspaceID int64, reviews []models.Review) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+
+	args := []any{workspaceID}
+	byID := map[string]*models.Review{}
+	for i := range reviews {
+		args = append(args, reviews[i].ID)
+		byID[reviews[i].ID] = &reviews[i]
+	}
+
+	rows, err := r.db.Query("SELECT review_id, status, triaged_by, triaged_at FROM review_triage WHERE workspace_id = ? AND review_id IN (?"+
+		strings.Repeat(", ?", len(reviews)-1)+")", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reviewID string
+		var triage models.ReviewTriage
+		if err := rows.Scan(&reviewID, &triage.Status, &triage.TriagedBy, &triage.TriagedAt); err != nil {
+			return err
+		}
+		byID[reviewID].Triage = &triage
+	}
+
+	return rows.Err()
+}
@@ -2,11 +2,13 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"time"
 
 	"github.com/renantatsuo/app-review/server/internal/apps"
 	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/models"
 	"github.com/renantatsuo/app-review/server/internal/queue"
 )
 
@@ -15,37 +17,106 @@ type Scheduler struct {
 	appsClient *apps.AppsClient
 	queue      queue.Queue
 	config     config.Config
+
+	// skipped counts the apps that were not enqueued because they were
+	// already pending or in flight, since the scheduler started.
+	skipped int
 }
 
 func New(l *slog.Logger, appsClient *apps.AppsClient, queue queue.Queue, config config.Config) *Scheduler {
 	return &Scheduler{l: l, appsClient: appsClient, queue: queue, config: config}
 }
 
+// Start checks every scheduler tick for the apps that are due to be polled and enqueues them.
 func (s *Scheduler) Start(ctx context.Context) {
-	s.l.Info("starting scheduler")
+	s.l.Info("starting scheduler", "tick", s.config.SchedulerTick.String())
 
-	ticker := time.NewTicker(s.config.PollingInterval)
+	ticker := time.NewTicker(s.config.SchedulerTick)
 
 	go func() {
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-ticker.C:
-				apps, err := s.appsClient.GetAllApps()
-				if err != nil {
-					s.l.Error("error getting all apps", "error", err)
+			case now := <-ticker.C:
+				s.scheduleDueApps(now)
+			}
+		}
+	}()
+}
+
+// scheduleDueApps enqueues a job for every country of the apps that are due
+// and schedules their next poll.
+// An app is only recorded as polled once one of its jobs is enqueued. An app whose jobs are all still in the queue
+// is only due again after its interval, without being recorded as polled, so its last poll and adaptive interval
+// stay the ones of the poll in flight. An app whose jobs could not be enqueued stays due.
+func (s *Scheduler) scheduleDueApps(now time.Time) {
+	apps, err := s.appsClient.GetDueApps(now, s.config.PollingInterval)
+	if err != nil {
+		s.l.Error("error getting due apps", "error", err)
+		return
+	}
+
+	if len(apps) == 0 {
+		return
+	}
+
+	scheduled, skipped := 0, 0
+	for _, app := range apps {
+		s.l.Info("scheduling app", "app", app)
+		enqueued, duplicates := 0, 0
+		for _, country := range app.Countries {
+			job := models.Job{AppID: app.ID, Country: country, Platform: app.Platform}
+			if err := s.queue.Enqueue(job.Encode()); err != nil {
+				if errors.Is(err, queue.ErrDuplicate) {
+					s.l.Debug("app is already in the queue, skipping", "appID", app.ID, "country", country)
+					duplicates++
 					continue
 				}
+				s.l.Error("error enqueuing app", "error", err)
+				continue
+			}
+			enqueued++
+		}
+		scheduled += enqueued
+		skipped += duplicates
 
-				for _, app := range apps {
-					s.l.Info("scheduling app", "app", app)
-					if err := s.queue.Enqueue([]byte(app.ID)); err != nil {
-						s.l.Error("error enqueuing app", "error", err)
-						continue
-					}
-				}
+		switch {
+		case enqueued > 0:
+			interval, adapted := s.nextInterval(app)
+			if err := s.appsClient.SchedulePoll(app.ID, now, now.Add(interval), adapted); err != nil {
+				s.l.Error("error scheduling next poll", "appID", app.ID, "error", err)
+			}
+		case duplicates > 0:
+			if err := s.appsClient.DeferPoll(app.ID, now.Add(app.Interval(s.config.PollingInterval))); err != nil {
+				s.l.Error("error deferring next poll", "appID", app.ID, "error", err)
 			}
 		}
-	}()
+	}
+
+	s.skipped += skipped
+	s.l.Info("scheduled apps", "scheduled", scheduled, "skipped", skipped, "totalSkipped", s.skipped)
+}
+
+// nextInterval returns the interval until the next poll of the app.
+// For adaptive apps it is adapted to the reviews found since the last poll
+// and also returned as the new app polling interval.
+func (s *Scheduler) nextInterval(app models.App) (time.Duration, *time.Duration) {
+	interval := app.Interval(s.config.PollingInterval)
+	if !app.AdaptivePolling || app.LastPolledAt == nil {
+		return interval, nil
+	}
+
+	newReviews, err := s.appsClient.CountReviewsSince(app.ID, *app.LastPolledAt)
+	if err != nil {
+		s.l.Error("error counting new reviews, keeping the polling interval", "appID", app.ID, "error", err)
+		return interval, nil
+	}
+
+	adapted := apps.AdaptInterval(interval, newReviews, s.config.MinPollingInterval, s.config.MaxPollingInterval)
+	if adapted != interval {
+		s.l.Info("adapted polling interval", "appID", app.ID, "newReviews", newReviews, "from", interval.String(), "to", adapted.String())
+	}
+
+	return adapted, &adapted
 }
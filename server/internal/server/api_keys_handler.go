@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/renantatsuo/app-review/server/internal/apikeys"
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// apiKeyRequest is the body of the POST /api-keys endpoint.
+type apiKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// createdAPIKey is the response of the POST /api-keys endpoint, the only one with the key.
+type createdAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// getAPIKeysHandler is the handler for the GET /api-keys endpoint.
+func (s *server) getAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	apiKeys, err := s.apiKeysClient.GetAPIKeys(principalFromContext(r.Context()).workspaceID())
+	if err != nil {
+		s.logger.Error("error getting api keys", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[[]models.APIKey]{
+		Data: apiKeys,
+	})
+}
+
+// postAPIKeysHandler is the handler for the POST /api-keys endpoint.
+// The key is only returned in this response.
+func (s *server) postAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	var req apiKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("error decoding request", "error", err)
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	scopes, err := models.ParseScopes(req.Scopes)
+	if err != nil {
+		s.logger.Error("error validating scopes", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	apiKey, key, err := s.apiKeysClient.CreateAPIKey(principalFromContext(r.Context()).workspaceID(), req.Name, scopes)
+	if err != nil {
+		s.logger.Error("error creating api key", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Info("api key created", "id", apiKey.ID, "name", apiKey.Name, "scopes", apiKey.Scopes, "createdBy", principalFromContext(r.Context()).actor())
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ResponseData[createdAPIKey]{
+		Data: createdAPIKey{APIKey: apiKey, Key: key},
+	})
+}
+
+// deleteAPIKeyHandler is the handler for the DELETE /api-keys/{keyID} endpoint.
+// It revokes the key, which stays listed.
+func (s *server) deleteAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("keyID"), 10, 64)
+	if err != nil {
+		http.Error(w, "keyID must be a number", http.StatusBadRequest)
+		return
+	}
+
+	apiKey, err := s.apiKeysClient.RevokeAPIKey(principalFromContext(r.Context()).workspaceID(), id)
+	if err != nil {
+		if errors.As(err, &apikeys.ErrAPIKeyNotFound{}) {
+			s.logger.Error("api key not found", "id", id)
+			http.Error(w, "api key not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error revoking api key", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Info("api key revoked", "id", apiKey.ID, "name", apiKey.Name, "revokedBy", principalFromContext(r.Context()).actor())
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,16 +3,51 @@ package server
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/renantatsuo/app-review/server/internal/apps"
 	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/queue"
+	"github.com/renantatsuo/app-review/server/pkg/apple"
 )
 
-// getAppsHandler is the handler for the /apps endpoint.
+var (
+	// countryRegexp matches the two letter country code of a store storefront.
+	countryRegexp = regexp.MustCompile(`^[a-z]{2}$`)
+	// packageNameRegexp matches the package name of an Android app, its Google Play ID.
+	packageNameRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*(\.[a-zA-Z][a-zA-Z0-9_]*)+$`)
+	// bundleIDRegexp matches the bundle ID of an ios app, reverse DNS segments which can hold hyphens and start with a digit.
+	bundleIDRegexp = regexp.MustCompile(`^[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)+$`)
+	// appStoreIDRegexp matches the path segment of an apps.apple.com URL holding the App Store ID.
+	appStoreIDRegexp = regexp.MustCompile(`^id(\d+)$`)
+)
+
+// patchAppRequest is the body of the PATCH /apps/{appID} endpoint.
+// Every field is optional, only the given ones are updated.
+type patchAppRequest struct {
+	Countries []string `json:"countries"`
+	// PollingInterval is a duration like "15m", empty resets it to the default interval.
+	PollingInterval *string `json:"polling_interval"`
+	AdaptivePolling *bool   `json:"adaptive_polling"`
+}
+
+// getAppsHandler is the handler for the /apps endpoint, it lists the apps of the workspace of the request.
+// The status query parameter only lists the active, paused or archived apps.
 func (s *server) getAppsHandler(w http.ResponseWriter, r *http.Request) {
-	apps, err := s.appsClient.GetAllApps()
+	status := models.AppStatus(r.URL.Query().Get("status"))
+	if status != "" && !status.Valid() {
+		s.logger.Error("invalid status", "status", status)
+		http.Error(w, "status must be active, paused or archived", http.StatusBadRequest)
+		return
+	}
+
+	apps, err := s.appsClient.GetAllApps(principalFromContext(r.Context()).workspaceID(), status)
 	if err != nil {
 		s.logger.Error("error getting apps", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -26,9 +61,25 @@ func (s *server) getAppsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // getAppHandler is the handler for the /apps/:appID endpoint.
+// It looks the app up in the store of the platform query parameter,
+// defaulting to the platform of the app if the workspace monitors it, or ios.
 func (s *server) getAppHandler(w http.ResponseWriter, r *http.Request) {
 	appID := r.PathValue("appID")
-	app, err := s.appsClient.GetAppData(appID)
+
+	platform := models.Platform(r.URL.Query().Get("platform"))
+	if platform == "" {
+		platform = models.PlatformIOS
+		if stored, err := s.appsClient.GetApp(principalFromContext(r.Context()).workspaceID(), appID); err == nil {
+			platform = stored.Platform
+		}
+	}
+	if !platform.Valid() {
+		s.logger.Error("invalid platform", "platform", platform)
+		http.Error(w, "platform must be ios or android", http.StatusBadRequest)
+		return
+	}
+
+	app, err := s.appsClient.GetAppData(r.Context(), platform, appID)
 	if err != nil {
 		s.logger.Error("error getting app data", "error", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
@@ -41,21 +92,113 @@ func (s *server) getAppHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// postAppsHandler is the handler for the /apps endpoint.
-// It creates a new app.
+// searchAppsHandler is the handler for the GET /apps/search endpoint.
+// It searches the store of the platform query parameter, ios by default, for the apps matching the q query parameter,
+// so they can be added without knowing their ID. The country query parameter is the storefront searched, us by default.
+func (s *server) searchAppsHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	term := strings.TrimSpace(params.Get("q"))
+	if term == "" {
+		s.logger.Error("missing search term")
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	platform := models.PlatformIOS
+	if raw := params.Get("platform"); raw != "" {
+		platform = models.Platform(raw)
+	}
+	if !platform.Valid() {
+		s.logger.Error("invalid platform", "platform", platform)
+		http.Error(w, "platform must be ios or android", http.StatusBadRequest)
+		return
+	}
+
+	country := apple.DefaultCountry
+	if raw := params.Get("country"); raw != "" {
+		countries, err := validateCountries([]string{raw})
+		if err != nil {
+			s.logger.Error("error validating country", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		country = countries[0]
+	}
+
+	limit, err := parseLimit(params.Get("limit"))
+	if err != nil {
+		s.logger.Error("error parsing limit", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := s.appsClient.SearchApps(r.Context(), platform, term, country, limit)
+	if err != nil {
+		if errors.Is(err, apps.ErrSearchUnsupported) {
+			s.logger.Error("search not supported", "platform", platform)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.logger.Error("error searching apps", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[[]models.AppSearchResult]{
+		Data: results,
+	})
+}
+
+// postAppsHandler is the handler for the POST /apps and POST /apps/{appID} endpoints.
+// It adds an app to the workspace of the request, monitoring the comma separated countries query parameter storefronts.
+// An app another workspace already monitors is fetched once for both, with the union of their storefronts.
+// The platform query parameter is the store of the app, ios (the default) or android.
+// An ios app can also be given by its apps.apple.com URL or its bundle ID, in the app query parameter
+// of POST /apps as a URL is not a path segment, and it is resolved to its App Store ID.
 func (s *server) postAppsHandler(w http.ResponseWriter, r *http.Request) {
-	appID := r.PathValue("appID")
-	appID, err := validateAppID(appID)
+	platform := models.PlatformIOS
+	if raw := r.URL.Query().Get("platform"); raw != "" {
+		platform = models.Platform(raw)
+	}
+	if !platform.Valid() {
+		s.logger.Error("invalid platform", "platform", platform)
+		http.Error(w, "platform must be ios or android", http.StatusBadRequest)
+		return
+	}
+
+	ref := r.PathValue("appID")
+	if ref == "" {
+		ref = r.URL.Query().Get("app")
+	}
+	appID, bundleID, err := parseAppRef(ref, platform)
 	if err != nil {
 		s.logger.Error("error validating appID", "error", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	app, err := s.appsClient.GetAppData(appID)
+	countries := []string{apple.DefaultCountry}
+	if raw := r.URL.Query().Get("countries"); raw != "" {
+		countries, err = validateCountries(strings.Split(raw, ","))
+		if err != nil {
+			s.logger.Error("error validating countries", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var app models.App
+	if bundleID != "" {
+		app, err = s.appsClient.GetAppDataByBundleID(r.Context(), platform, bundleID)
+	} else {
+		app, err = s.appsClient.GetAppData(r.Context(), platform, appID)
+	}
 	if err != nil {
 		if errors.As(err, &apps.ErrAppNotFound{}) {
-			s.logger.Error("app not found", "appID", appID)
+			s.logger.Error("app not found", "appID", appID, "bundleID", bundleID)
 			http.Error(w, "app not found", http.StatusNotFound)
 			return
 		}
@@ -64,35 +207,278 @@ func (s *server) postAppsHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
+	appID = app.ID
 
-	err = s.appsClient.AddApp(app)
+	app.Countries = countries
+	app.AddedBy = principalFromContext(r.Context()).actor()
+	err = s.appsClient.AddApp(principalFromContext(r.Context()).workspaceID(), app)
 	if err != nil {
 		s.logger.Error("error creating app", "error", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	err = s.queue.Enqueue([]byte(appID))
+	for _, country := range countries {
+		job := models.Job{AppID: appID, Country: country, Platform: platform}
+		err = s.queue.Enqueue(job.Encode())
+		if err != nil && !errors.Is(err, queue.ErrDuplicate) {
+			s.logger.Error("error enqueuing appID", "error", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(appID)
+}
+
+// patchAppHandler is the handler for the PATCH /apps/{appID} endpoint.
+// It updates the country storefronts and the polling schedule of the app.
+func (s *server) patchAppHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.PathValue("appID")
+
+	var req patchAppRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("error decoding request", "error", err)
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	update := apps.Update{AdaptivePolling: req.AdaptivePolling}
+
+	if req.Countries != nil {
+		countries, err := validateCountries(req.Countries)
+		if err != nil {
+			s.logger.Error("error validating countries", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		update.Countries = countries
+	}
+
+	if req.PollingInterval != nil {
+		interval, err := s.validatePollingInterval(*req.PollingInterval)
+		if err != nil {
+			s.logger.Error("error validating polling interval", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		update.PollingInterval = &interval
+	}
+
+	err := s.appsClient.UpdateApp(principalFromContext(r.Context()).workspaceID(), appID, update)
 	if err != nil {
-		s.logger.Error("error enqueuing appID", "error", err)
+		if errors.As(err, &apps.ErrAppNotFound{}) {
+			s.logger.Error("app not found", "appID", appID)
+			http.Error(w, "app not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error updating app", "error", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(appID)
 }
 
-// validateAppID is a helper function to validate the appID.
-func validateAppID(appID string) (string, error) {
+// deleteAppHandler is the handler for the DELETE /apps/{appID} endpoint.
+// The reviews query parameter chooses what happens to the app reviews:
+// "archive" (the default) keeps them and archives the app,
+// "delete" removes the app from the workspace, and deletes them along with the app when no other workspace watches it.
+func (s *server) deleteAppHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.PathValue("appID")
+	principal := principalFromContext(r.Context())
+
+	var err error
+	switch r.URL.Query().Get("reviews") {
+	case "", "archive":
+		err = s.appsClient.SetAppStatus(principal.workspaceID(), appID, models.AppStatusArchived, principal.actor())
+	case "delete":
+		err = s.appsClient.DeleteApp(principal.workspaceID(), appID)
+	default:
+		http.Error(w, "reviews must be archive or delete", http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		if errors.As(err, &apps.ErrAppNotFound{}) {
+			s.logger.Error("app not found", "appID", appID)
+			http.Error(w, "app not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error deleting app", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pauseAppHandler is the handler for the POST /apps/{appID}/pause endpoint.
+// It stops polling the app for new reviews.
+func (s *server) pauseAppHandler(w http.ResponseWriter, r *http.Request) {
+	s.setAppStatus(w, r, models.AppStatusPaused)
+}
+
+// resumeAppHandler is the handler for the POST /apps/{appID}/resume endpoint.
+// It polls a paused or archived app for new reviews again.
+func (s *server) resumeAppHandler(w http.ResponseWriter, r *http.Request) {
+	s.setAppStatus(w, r, models.AppStatusActive)
+}
+
+// setAppStatus changes the status of the app of the request in its workspace,
+// the app is polled as long as any workspace has it active.
+// An archived app cannot be paused.
+func (s *server) setAppStatus(w http.ResponseWriter, r *http.Request, status models.AppStatus) {
+	appID := r.PathValue("appID")
+	principal := principalFromContext(r.Context())
+
+	app, err := s.appsClient.GetApp(principal.workspaceID(), appID)
+	if err != nil {
+		if errors.As(err, &apps.ErrAppNotFound{}) {
+			s.logger.Error("app not found", "appID", appID)
+			http.Error(w, "app not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error getting app", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if status == models.AppStatusPaused && app.Status == models.AppStatusArchived {
+		http.Error(w, "archived apps cannot be paused", http.StatusConflict)
+		return
+	}
+
+	by := principal.actor()
+	if err := s.appsClient.SetAppStatus(principal.workspaceID(), appID, status, by); err != nil {
+		s.logger.Error("error setting app status", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	app.Status = status
+	app.StatusUpdatedBy = by
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[models.App]{
+		Data: app,
+	})
+}
+
+// validateAppID is a helper function to validate the appID of the platform:
+// a number for ios apps, a package name for android apps, any of them if the platform is empty.
+func validateAppID(appID string, platform models.Platform) (string, error) {
 	if appID == "" {
 		return "", errors.New("appID is required")
 	}
 
-	// only numbers are allowed
-	if _, err := strconv.ParseInt(appID, 10, 64); err != nil {
+	_, err := strconv.ParseInt(appID, 10, 64)
+	isNumber := err == nil
+	isPackageName := packageNameRegexp.MatchString(appID)
+
+	switch {
+	case platform == models.PlatformIOS && !isNumber:
 		return "", errors.New("appID must be a number")
+	case platform == models.PlatformAndroid && !isPackageName:
+		return "", errors.New("appID must be a package name")
+	case !isNumber && !isPackageName:
+		return "", errors.New("appID must be a number or a package name")
 	}
 
 	return appID, nil
 }
+
+// parseAppRef is a helper function to parse the app of the POST /apps endpoint into its appID,
+// or the bundle ID of an ios app that is not a number, which still has to be looked up.
+// An ios app can be a number, an apps.apple.com URL like https://apps.apple.com/us/app/name/id123 or a bundle ID,
+// an android app is a package name.
+func parseAppRef(ref string, platform models.Platform) (string, string, error) {
+	ref = strings.TrimSpace(ref)
+	if platform != models.PlatformIOS {
+		appID, err := validateAppID(ref, platform)
+		return appID, "", err
+	}
+
+	switch {
+	case ref == "":
+		return "", "", errors.New("appID is required")
+	case strings.Contains(ref, "/"):
+		appID, err := parseAppStoreURL(ref)
+		return appID, "", err
+	case bundleIDRegexp.MatchString(ref):
+		return "", ref, nil
+	}
+
+	appID, err := validateAppID(ref, platform)
+	return appID, "", err
+}
+
+// parseAppStoreURL returns the App Store ID of an apps.apple.com URL, the number of its id123 path segment.
+// The scheme is optional.
+func parseAppStoreURL(raw string) (string, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Host != "apps.apple.com" && u.Host != "itunes.apple.com") {
+		return "", errors.New("appID must be a number, an apps.apple.com URL or a bundle ID")
+	}
+
+	for _, segment := range strings.Split(u.Path, "/") {
+		if match := appStoreIDRegexp.FindStringSubmatch(segment); match != nil {
+			return match[1], nil
+		}
+	}
+
+	return "", errors.New("the apps.apple.com URL has no app ID")
+}
+
+// validatePollingInterval is a helper function to validate the polling interval of an app.
+// An empty interval is zero, meaning the default interval.
+func (s *server) validatePollingInterval(raw string) (time.Duration, error) {
+	if raw == "" {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid polling_interval: %w", err)
+	}
+
+	if interval < s.config.MinPollingInterval || interval > s.config.MaxPollingInterval {
+		return 0, fmt.Errorf("polling_interval must be between %s and %s", s.config.MinPollingInterval, s.config.MaxPollingInterval)
+	}
+
+	return interval, nil
+}
+
+// validateCountries is a helper function to validate the country storefronts.
+// It lowercases and deduplicates them.
+func validateCountries(countries []string) ([]string, error) {
+	res := []string{}
+	seen := map[string]bool{}
+
+	for _, country := range countries {
+		country = strings.ToLower(strings.TrimSpace(country))
+		if !countryRegexp.MatchString(country) {
+			return nil, fmt.Errorf("invalid country: %q", country)
+		}
+		if seen[country] {
+			continue
+		}
+		seen[country] = true
+		res = append(res, country)
+	}
+
+	if len(res) == 0 {
+		return nil, errors.New("at least one country is required")
+	}
+
+	return res, nil
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/users"
+)
+
+// loginRequest is the body of the POST /auth/login endpoint.
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// whoami is the response of the login and whoami endpoints, with the user or the API key of the request and their workspace.
+// CSRFToken must be sent in the X-CSRF-Token header of the session requests changing anything.
+type whoami struct {
+	User      *models.User      `json:"user,omitempty"`
+	APIKey    *models.APIKey    `json:"api_key,omitempty"`
+	Workspace *models.Workspace `json:"workspace,omitempty"`
+	CSRFToken string            `json:"csrf_token,omitempty"`
+}
+
+// loginHandler is the handler for the POST /auth/login endpoint.
+// It sets the session cookie of the user. Only JSON bodies are accepted,
+// so a form of another site cannot log the browser in without a CORS preflight.
+// The attempts are limited per address and per username, see allowLogin.
+func (s *server) loginHandler(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("error decoding request", "error", err)
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.allowLogin(w, r, req.Username) {
+		return
+	}
+
+	user, err := s.usersClient.Authenticate(req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, users.ErrInvalidCredentials) {
+			s.authFailed(w, r, http.StatusUnauthorized, "invalid username or password", "username", req.Username)
+			return
+		}
+		s.logger.Error("error authenticating user", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	session, token, err := s.usersClient.CreateSession(user.ID, s.config.SessionTTL)
+	if err != nil {
+		s.logger.Error("error creating session", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	workspace, err := s.workspacesClient.GetWorkspace(user.WorkspaceID)
+	if err != nil {
+		s.logger.Error("error getting workspace", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Info("user logged in", "userID", user.ID, "username", user.Username, "workspaceID", user.WorkspaceID, "remoteAddr", r.RemoteAddr)
+
+	http.SetCookie(w, s.sessionCookie(token, session.ExpiresAt))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[whoami]{
+		Data: whoami{User: &user, Workspace: &workspace, CSRFToken: session.CSRFToken},
+	})
+}
+
+// logoutHandler is the handler for the POST /auth/logout endpoint.
+// It deletes the session of the request and clears its cookie.
+func (s *server) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	p := principalFromContext(r.Context())
+	if p.session == nil {
+		http.Error(w, "the request is not authenticated with a session", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.usersClient.DeleteSession(p.sessionToken); err != nil {
+		s.logger.Error("error deleting session", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Info("user logged out", "userID", p.user.ID, "username", p.user.Username)
+
+	http.SetCookie(w, s.sessionCookie("", time.Unix(0, 0)))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// whoamiHandler is the handler for the GET /auth/whoami endpoint.
+// It returns the user or the API key of the request, so the web app knows who is logged in.
+func (s *server) whoamiHandler(w http.ResponseWriter, r *http.Request) {
+	p := principalFromContext(r.Context())
+
+	workspace, err := s.workspacesClient.GetWorkspace(p.workspaceID())
+	if err != nil {
+		s.logger.Error("error getting workspace", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	res := whoami{User: p.user, APIKey: p.apiKey, Workspace: &workspace}
+	if p.session != nil {
+		res.CSRFToken = p.session.CSRFToken
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[whoami]{
+		Data: res,
+	})
+}
+
+// sessionCookie returns the session cookie with the token, an expired cookie clears it.
+func (s *server) sessionCookie(token string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   s.config.SessionCookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
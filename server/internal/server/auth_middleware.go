@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/renantatsuo/app-review/server/internal/apikeys"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/users"
+)
+
+const (
+	// apiKeyHeader is the header the API key can be sent in, instead of the Authorization bearer token.
+	apiKeyHeader = "X-API-Key"
+	// sessionCookie is the cookie holding the session token of a logged in user.
+	sessionCookie = "app_review_session"
+	// csrfHeader is the header the session CSRF token is sent back in, on the requests changing anything.
+	csrfHeader = "X-CSRF-Token"
+)
+
+type principalContextKey struct{}
+
+// principal is who a request is authenticated as, either an API key or a logged in user.
+type principal struct {
+	apiKey  *models.APIKey
+	user    *models.User
+	session *models.Session
+	// sessionToken is the token of the session cookie, to log it out.
+	sessionToken string
+}
+
+// hasScope returns true if the principal is granted the scope, an empty scope only requires to be authenticated.
+func (p principal) hasScope(scope models.Scope) bool {
+	switch {
+	case scope == "":
+		return true
+	case p.apiKey != nil:
+		return p.apiKey.HasScope(scope)
+	case p.user != nil:
+		return p.user.Role.HasScope(scope)
+	}
+	return false
+}
+
+// actor is the name the changes of the principal are attributed to, the username or the API key name.
+func (p principal) actor() string {
+	switch {
+	case p.user != nil:
+		return p.user.Username
+	case p.apiKey != nil:
+		return "api-key:" + p.apiKey.Name
+	}
+	return ""
+}
+
+// workspaceID is the workspace the principal belongs to, which scopes everything it sees and changes.
+func (p principal) workspaceID() int64 {
+	switch {
+	case p.user != nil:
+		return p.user.WorkspaceID
+	case p.apiKey != nil:
+		return p.apiKey.WorkspaceID
+	}
+	return 0
+}
+
+// logArgs are the log attributes identifying the principal.
+func (p principal) logArgs() []any {
+	switch {
+	case p.user != nil:
+		return []any{"userID", p.user.ID}
+	case p.apiKey != nil:
+		return []any{"apiKeyID", p.apiKey.ID}
+	}
+	return nil
+}
+
+// authMiddleware only lets the requests authenticated with an API key or a user session granted the scope through.
+// The key is sent as a bearer token of the Authorization header, or in the X-API-Key header.
+// The session is sent in its cookie, and the requests with an unsafe method must also send its CSRF token
+// in the X-CSRF-Token header, so another site cannot make the browser of a logged in user change anything.
+// The failed attempts are logged with the remote address of the request.
+func (s *server) authMiddleware(scope models.Scope, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := s.authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		if !p.hasScope(scope) {
+			reason := "missing the " + string(scope) + " scope"
+			if p.user != nil {
+				reason = "the " + string(p.user.Role) + " role is " + reason
+			} else {
+				reason = "api key is " + reason
+			}
+			s.authFailed(w, r, http.StatusForbidden, reason, p.logArgs()...)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalContextKey{}, p)))
+	})
+}
+
+// authenticate returns the principal of the request, it writes the error and returns false if there is none.
+// An API key takes precedence over the session cookie.
+func (s *server) authenticate(w http.ResponseWriter, r *http.Request) (principal, bool) {
+	if key := requestAPIKey(r); key != "" {
+		apiKey, err := s.apiKeysClient.Authenticate(key)
+		if err != nil {
+			if errors.Is(err, apikeys.ErrInvalidAPIKey) {
+				s.authFailed(w, r, http.StatusUnauthorized, "invalid api key")
+				return principal{}, false
+			}
+			s.logger.Error("error authenticating api key", "error", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return principal{}, false
+		}
+		return principal{apiKey: &apiKey}, true
+	}
+
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || cookie.Value == "" {
+		s.authFailed(w, r, http.StatusUnauthorized, "missing api key or session")
+		return principal{}, false
+	}
+
+	session, user, err := s.usersClient.GetSession(cookie.Value)
+	if err != nil {
+		if errors.Is(err, users.ErrSessionNotFound) {
+			s.authFailed(w, r, http.StatusUnauthorized, "invalid or expired session")
+			return principal{}, false
+		}
+		s.logger.Error("error getting session", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return principal{}, false
+	}
+
+	if !isSafeMethod(r.Method) {
+		token := r.Header.Get(csrfHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(session.CSRFToken)) != 1 {
+			s.authFailed(w, r, http.StatusForbidden, "missing or invalid csrf token", "userID", user.ID)
+			return principal{}, false
+		}
+	}
+
+	return principal{user: &user, session: &session, sessionToken: cookie.Value}, true
+}
+
+// authFailed logs the failed authentication attempt and writes the error.
+func (s *server) authFailed(w http.ResponseWriter, r *http.Request, status int, reason string, args ...any) {
+	s.logger.Warn("authentication failed", append([]any{"reason", reason, "remoteAddr", r.RemoteAddr, "method", r.Method, "path", r.URL.Path}, args...)...)
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="app-review"`)
+	}
+	http.Error(w, reason, status)
+}
+
+// requestAPIKey returns the API key sent with the request, empty if there is none.
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return key
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// isSafeMethod returns true if the method does not change anything, so it does not need a CSRF token.
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// principalFromContext returns who the request was authenticated as.
+func principalFromContext(ctx context.Context) principal {
+	p, _ := ctx.Value(principalContextKey{}).(principal)
+	return p
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/queue"
+)
+
+// postAppBackfillHandler is the handler for the POST /apps/{appID}/backfill endpoint.
+// It enqueues the backfill of the back catalog of reviews of every storefront of the app.
+// Completed backfills start over, failed ones resume where they stopped.
+func (s *server) postAppBackfillHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.PathValue("appID")
+
+	app, err := s.appsClient.GetApp(principalFromContext(r.Context()).workspaceID(), appID)
+	if err != nil {
+		if errors.As(err, &apps.ErrAppNotFound{}) {
+			s.logger.Error("app not found", "appID", appID)
+			http.Error(w, "app not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error getting app", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !s.reviewsClient.SupportsBackfill(app.Platform) {
+		s.logger.Error("backfill is not supported", "appID", appID, "platform", app.Platform)
+		http.Error(w, fmt.Sprintf("backfill is not supported for %s apps", app.Platform), http.StatusBadRequest)
+		return
+	}
+
+	backfills, err := s.backfillsClient.StartBackfill(appID, app.Countries)
+	if err != nil {
+		s.logger.Error("error starting backfill", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	for _, country := range app.Countries {
+		job := models.Job{AppID: appID, Country: country, Type: models.JobTypeBackfill, Platform: app.Platform}
+		err = s.queue.Enqueue(job.Encode())
+		if err != nil && !errors.Is(err, queue.ErrDuplicate) {
+			s.logger.Error("error enqueuing backfill", "error", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(ResponseData[[]models.Backfill]{
+		Data: backfills,
+	})
+}
+
+// getAppBackfillHandler is the handler for the GET /apps/{appID}/backfill endpoint.
+// It returns the progress of the backfill of every storefront of the app.
+func (s *server) getAppBackfillHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.PathValue("appID")
+
+	if _, err := s.appsClient.GetApp(principalFromContext(r.Context()).workspaceID(), appID); err != nil {
+		if errors.As(err, &apps.ErrAppNotFound{}) {
+			s.logger.Error("app not found", "appID", appID)
+			http.Error(w, "app not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error getting app", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	backfills, err := s.backfillsClient.GetBackfills(appID)
+	if err != nil {
+		s.logger.Error("error getting backfills", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if len(backfills) == 0 {
+		s.logger.Error("backfill not found", "appID", appID)
+		http.Error(w, "backfill not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[[]models.Backfill]{
+		Data: backfills,
+	})
+}
@@ -1,11 +1,19 @@
 package server
 
-import "net/http"
+import (
+	"net/http"
+	"slices"
+)
 
-// corsMiddleware is a middleware that adds CORS headers to the response
-func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// corsMiddleware is a middleware that allows the origins of the CORS_ALLOWED_ORIGINS config to read the response.
+// The other origins get no CORS headers, so the browsers block their cross origin requests.
+func (s *server) corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		// the response depends on the origin, it must not be cached for another one
+		w.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); origin != "" && slices.Contains(s.config.CORSAllowedOrigins, origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
 		next.ServeHTTP(w, r)
 	})
 }
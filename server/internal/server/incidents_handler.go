@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// getAppIncidentsHandler is the handler for the /apps/{appID}/incidents endpoint.
+// It returns the latest anomalies detected in the app reviews of the storefronts the workspace watches, most recent first.
+func (s *server) getAppIncidentsHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.PathValue("appID")
+
+	limit, err := parseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		s.logger.Error("error parsing limit", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	workspaceID := principalFromContext(r.Context()).workspaceID()
+	if _, err := s.appsClient.GetApp(workspaceID, appID); err != nil {
+		if errors.As(err, &apps.ErrAppNotFound{}) {
+			s.logger.Error("app not found", "appID", appID)
+			http.Error(w, "app not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error getting app", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	incidents, err := s.incidentsClient.FindIncidentsByAppID(workspaceID, appID, limit)
+	if err != nil {
+		s.logger.Error("error getting incidents", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[[]models.Incident]{
+		Data: incidents,
+	})
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxLoginBuckets is how many buckets a loginLimiter holds before dropping the full ones,
+// so a flood of usernames or addresses does not grow it without bound.
+const maxLoginBuckets = 10_000
+
+// loginLimiter is a token bucket per key, like the address or the username of the login attempts.
+// A bucket holds up to perMinute tokens and is refilled at perMinute tokens per minute,
+// every attempt takes a token or is refused until the next one.
+type loginLimiter struct {
+	mu        sync.Mutex
+	perMinute float64
+	buckets   map[string]*loginBucket
+}
+
+type loginBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newLoginLimiter creates a limiter of perMinute attempts per key, or nil if it is zero.
+func newLoginLimiter(perMinute int) *loginLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+
+	return &loginLimiter{perMinute: float64(perMinute), buckets: map[string]*loginBucket{}}
+}
+
+// Allow takes a token of the key, or returns how long until the next one if there is none.
+// A nil limiter allows every attempt.
+func (l *loginLimiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxLoginBuckets {
+			l.prune(now)
+		}
+		bucket = &loginBucket{tokens: l.perMinute, last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = min(l.perMinute, bucket.tokens+now.Sub(bucket.last).Minutes()*l.perMinute)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / l.perMinute * float64(time.Minute))
+	}
+
+	bucket.tokens--
+	return true, 0
+}
+
+// prune drops the buckets refilled by now, they are the same as new ones.
+func (l *loginLimiter) prune(now time.Time) {
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Minutes()*l.perMinute >= l.perMinute {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// allowLogin checks the login attempt against the limits of its address and of its username,
+// answering 429 Too Many Requests with a Retry-After if one of them is exceeded.
+// It runs before the password is hashed, so the attempts over the limits cost nothing.
+func (s *server) allowLogin(w http.ResponseWriter, r *http.Request, username string) bool {
+	now := time.Now()
+
+	ok, retryAfter := s.loginAddrLimiter.Allow(remoteHost(r), now)
+	if ok {
+		// the usernames are unique ignoring the case
+		ok, retryAfter = s.loginUsernameLimiter.Allow(strings.ToLower(username), now)
+	}
+	if ok {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	s.authFailed(w, r, http.StatusTooManyRequests, "too many login attempts", "username", username)
+	return false
+}
+
+// remoteHost returns the address of the client of the request, without its port.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
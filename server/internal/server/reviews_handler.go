@@ -4,20 +4,42 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/renantatsuo/app-review/server/internal/export"
 	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/reviews"
+)
+
+const (
+	defaultReviewsLimit = 50
+	maxReviewsLimit     = 200
 )
 
 // getReviewsHandler is the handler for the /reviews/{appID} endpoint.
-// It returns the reviews for the given appID.
-// TODO: support pagination.
+// It returns a page of the reviews for the given appID, most recent first.
+// See parseReviewsQuery for the supported filters.
 func (s *server) getReviewsHandler(w http.ResponseWriter, r *http.Request) {
 	appID := r.PathValue("appID")
 
-	since := time.Now().Add(-s.config.ReviewsTimeLimit)
-	reviews, err := s.reviewsClient.FindReviewsByAppID(appID, since)
+	query, err := s.parseReviewsQuery(r, appID)
+	if err != nil {
+		s.logger.Error("error parsing reviews query", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit, err := parseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		s.logger.Error("error parsing limit", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reviews, next, err := s.reviewsClient.FindReviewsByAppID(query.Limit(limit))
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		s.logger.Error("error getting reviews", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -30,8 +52,225 @@ func (s *server) getReviewsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(ResponseData[[]models.Review]{
+	res := ResponseData[[]models.Review]{
 		Data: reviews,
+	}
+	if next != nil {
+		res.NextCursor = next.Encode()
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// getReviewHistoryHandler is the handler for the /reviews/{appID}/{reviewID}/history endpoint.
+// It returns every version of the review, oldest first, to show how its rating and text changed.
+func (s *server) getReviewHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.PathValue("appID")
+	reviewID := r.PathValue("reviewID")
+
+	history, err := s.reviewsClient.FindReviewHistory(principalFromContext(r.Context()).workspaceID(), appID, reviewID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("review not found", "appID", appID, "reviewID", reviewID)
+			http.Error(w, "review not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error getting review history", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[[]models.ReviewRevision]{
+		Data: history,
+	})
+}
+
+// putReviewTriageHandler is the handler for the PUT /reviews/{appID}/{reviewID}/triage endpoint.
+// It sets the triage status of the review in the workspace of the request, recording who triaged it.
+func (s *server) putReviewTriageHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.PathValue("appID")
+	reviewID := r.PathValue("reviewID")
+	principal := principalFromContext(r.Context())
+
+	var req struct {
+		Status models.TriageStatus `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("error decoding request", "error", err)
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !req.Status.Valid() {
+		s.logger.Error("invalid review triage status", "status", req.Status)
+		http.Error(w, "status must be acknowledged, resolved or ignored", http.StatusBadRequest)
+		return
+	}
+
+	triage, err := s.reviewsClient.SetTriage(principal.workspaceID(), appID, reviewID, req.Status, principal.actor())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("review not found", "appID", appID, "reviewID", reviewID)
+			http.Error(w, "review not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error setting review triage", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[models.ReviewTriage]{
+		Data: triage,
+	})
+}
+
+// deleteReviewTriageHandler is the handler for the DELETE /reviews/{appID}/{reviewID}/triage endpoint.
+// It clears the triage of the review in the workspace of the request.
+func (s *server) deleteReviewTriageHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.PathValue("appID")
+	reviewID := r.PathValue("reviewID")
+
+	if err := s.reviewsClient.ClearTriage(principalFromContext(r.Context()).workspaceID(), appID, reviewID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("review not found", "appID", appID, "reviewID", reviewID)
+			http.Error(w, "review not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error clearing review triage", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// exportReviewsHandler is the handler for the /reviews/{appID}/export endpoint.
+// It downloads every review matching the filters of the reviews listing, most recent first,
+// in the format query parameter: csv (the default), ndjson or xlsx.
+// The reviews are streamed from the database as they are written, so exports are not limited in size.
+func (s *server) exportReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	appID, err := validateAppID(r.PathValue("appID"), "")
+	if err != nil {
+		s.logger.Error("error validating appID", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format := export.FormatCSV
+	if raw := r.URL.Query().Get("format"); raw != "" {
+		format = export.Format(raw)
+	}
+	if !format.Valid() {
+		s.logger.Error("invalid export format", "format", format)
+		http.Error(w, "format must be csv, ndjson or xlsx", http.StatusBadRequest)
+		return
+	}
+
+	query, err := s.parseReviewsQuery(r, appID)
+	if err != nil {
+		s.logger.Error("error parsing reviews query", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filename := fmt.Sprintf("reviews-%s-%s.%s", appID, time.Now().UTC().Format("20060102"), format)
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	body := &startedWriter{ResponseWriter: w}
+	writer, err := export.NewWriter(format, body)
+	if err == nil {
+		err = s.reviewsClient.EachReview(query, writer.Write)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		s.logger.Error("error exporting reviews", "appID", appID, "format", format, "error", err)
+		if !body.started {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		// the response has already started, abort it so the client does not keep a truncated file
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// startedWriter is a response writer recording whether the body has started,
+// after which the status code can no longer be changed.
+type startedWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *startedWriter) Write(p []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(p)
+}
+
+// parseReviewsQuery builds the reviews query of the workspace of the request from its query parameters.
+// See reviews.ParseQuery for the supported filters.
+func (s *server) parseReviewsQuery(r *http.Request, appID string) (reviews.Query, error) {
+	return reviews.ParseQuery(principalFromContext(r.Context()).workspaceID(), appID, r.URL.Query(), s.config.ReviewsTimeLimit)
+}
+
+// parseLimit parses the page size, defaulting to defaultReviewsLimit.
+func parseLimit(raw string) (int, error) {
+	if raw == "" {
+		return defaultReviewsLimit, nil
+	}
+
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 || limit > maxReviewsLimit {
+		return 0, fmt.Errorf("limit must be a number between 1 and %d", maxReviewsLimit)
+	}
+
+	return limit, nil
+}
+
+// searchReviewsHandler is the handler for the /reviews/search endpoint.
+// It searches the reviews title and content for the q query parameter, most relevant first,
+// optionally scoped to the app_id query parameter and paginated with limit and offset.
+func (s *server) searchReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	limit, err := parseLimit(params.Get("limit"))
+	if err != nil {
+		s.logger.Error("error parsing limit", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	offset := 0
+	if raw := params.Get("offset"); raw != "" {
+		offset, err = strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			s.logger.Error("error parsing offset", "offset", raw)
+			http.Error(w, "offset must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := s.reviewsClient.SearchReviews(principalFromContext(r.Context()).workspaceID(), params.Get("q"), params.Get("app_id"), limit, offset)
+	if err != nil {
+		if errors.Is(err, reviews.ErrEmptySearch) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.logger.Error("error searching reviews", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[[]models.ReviewSearchResult]{
+		Data: results,
 	})
 }
@@ -6,53 +6,121 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/renantatsuo/app-review/server/internal/apikeys"
 	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/backfills"
 	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/incidents"
+	"github.com/renantatsuo/app-review/server/internal/models"
 	"github.com/renantatsuo/app-review/server/internal/queue"
 	"github.com/renantatsuo/app-review/server/internal/reviews"
+	"github.com/renantatsuo/app-review/server/internal/users"
+	"github.com/renantatsuo/app-review/server/internal/webhooks"
+	"github.com/renantatsuo/app-review/server/internal/workspaces"
 )
 
 type server struct {
-	port          int
-	logger        *slog.Logger
-	server        *http.Server
-	reviewsClient *reviews.ReviewsClient
-	appsClient    *apps.AppsClient
-	queue         queue.Queue
-	config        config.Config
+	port             int
+	logger           *slog.Logger
+	server           *http.Server
+	reviewsClient    *reviews.ReviewsClient
+	tailer           *reviews.Tailer
+	appsClient       *apps.AppsClient
+	webhooksClient   *webhooks.WebhooksClient
+	incidentsClient  *incidents.IncidentsClient
+	backfillsClient  *backfills.BackfillsClient
+	apiKeysClient    *apikeys.APIKeysClient
+	usersClient      *users.UsersClient
+	workspacesClient *workspaces.WorkspacesClient
+	queue            queue.Queue
+	config           config.Config
+
+	loginAddrLimiter     *loginLimiter
+	loginUsernameLimiter *loginLimiter
 }
 
 type ResponseData[T any] struct {
-	Data T `json:"data"`
+	Data       T      `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
-func New(port int, logger *slog.Logger, reviewsClient *reviews.ReviewsClient, appsClient *apps.AppsClient, queue queue.Queue, config config.Config) *server {
+func New(port int, logger *slog.Logger, reviewsClient *reviews.ReviewsClient, tailer *reviews.Tailer, appsClient *apps.AppsClient, webhooksClient *webhooks.WebhooksClient, incidentsClient *incidents.IncidentsClient, backfillsClient *backfills.BackfillsClient, apiKeysClient *apikeys.APIKeysClient, usersClient *users.UsersClient, workspacesClient *workspaces.WorkspacesClient, queue queue.Queue, config config.Config) *server {
 	return &server{
-		port:          port,
-		logger:        logger,
-		reviewsClient: reviewsClient,
-		appsClient:    appsClient,
-		queue:         queue,
-		config:        config,
+		port:             port,
+		logger:           logger,
+		reviewsClient:    reviewsClient,
+		tailer:           tailer,
+		appsClient:       appsClient,
+		webhooksClient:   webhooksClient,
+		incidentsClient:  incidentsClient,
+		backfillsClient:  backfillsClient,
+		apiKeysClient:    apiKeysClient,
+		usersClient:      usersClient,
+		workspacesClient: workspacesClient,
+		queue:            queue,
+		config:           config,
+
+		loginAddrLimiter:     newLoginLimiter(config.LoginAddrRateLimit),
+		loginUsernameLimiter: newLoginLimiter(config.LoginUsernameRateLimit),
 	}
 }
 
 func (s *server) Start() error {
-	router := http.NewServeMux()
-	router.Handle("GET /reviews/{appID}", corsMiddleware(s.getReviewsHandler))
-	router.Handle("GET /apps", corsMiddleware(s.getAppsHandler))
-	router.Handle("POST /apps/{appID}", corsMiddleware(s.postAppsHandler))
-	router.Handle("GET /apps/{appID}", corsMiddleware(s.getAppHandler))
-
 	s.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", s.port),
-		Handler: router,
+		Handler: s.Handler(),
 	}
 
 	s.logger.Info("starting server", "port", s.port)
 	return s.server.ListenAndServe()
 }
 
+// Handler returns the router of the API, to serve it without starting the server.
+// Every route but the login requires an API key or a user session granted its scope.
+func (s *server) Handler() http.Handler {
+	router := http.NewServeMux()
+	router.Handle("GET /reviews/{appID}", s.corsMiddleware(s.authMiddleware(models.ScopeReviewsRead, s.getReviewsHandler)))
+	router.Handle("GET /reviews/search", s.corsMiddleware(s.authMiddleware(models.ScopeReviewsRead, s.searchReviewsHandler)))
+	router.Handle("GET /reviews/stream", s.corsMiddleware(s.authMiddleware(models.ScopeReviewsRead, s.streamReviewsHandler)))
+	router.Handle("GET /reviews/{appID}/stream", s.corsMiddleware(s.authMiddleware(models.ScopeReviewsRead, s.streamAppReviewsHandler)))
+	router.Handle("GET /reviews/{appID}/export", s.corsMiddleware(s.authMiddleware(models.ScopeReviewsRead, s.exportReviewsHandler)))
+	router.Handle("GET /reviews/{appID}/{reviewID}/history", s.corsMiddleware(s.authMiddleware(models.ScopeReviewsRead, s.getReviewHistoryHandler)))
+	router.Handle("PUT /reviews/{appID}/{reviewID}/triage", s.corsMiddleware(s.authMiddleware(models.ScopeReviewsWrite, s.putReviewTriageHandler)))
+	router.Handle("DELETE /reviews/{appID}/{reviewID}/triage", s.corsMiddleware(s.authMiddleware(models.ScopeReviewsWrite, s.deleteReviewTriageHandler)))
+	router.Handle("GET /apps", s.corsMiddleware(s.authMiddleware(models.ScopeAppsRead, s.getAppsHandler)))
+	router.Handle("GET /apps/search", s.corsMiddleware(s.authMiddleware(models.ScopeAppsRead, s.searchAppsHandler)))
+	router.Handle("POST /apps", s.corsMiddleware(s.authMiddleware(models.ScopeAppsWrite, s.postAppsHandler)))
+	router.Handle("POST /apps/{appID}", s.corsMiddleware(s.authMiddleware(models.ScopeAppsWrite, s.postAppsHandler)))
+	router.Handle("GET /apps/{appID}", s.corsMiddleware(s.authMiddleware(models.ScopeAppsRead, s.getAppHandler)))
+	router.Handle("PATCH /apps/{appID}", s.corsMiddleware(s.authMiddleware(models.ScopeAppsWrite, s.patchAppHandler)))
+	router.Handle("DELETE /apps/{appID}", s.corsMiddleware(s.authMiddleware(models.ScopeAppsWrite, s.deleteAppHandler)))
+	router.Handle("POST /apps/{appID}/pause", s.corsMiddleware(s.authMiddleware(models.ScopeAppsWrite, s.pauseAppHandler)))
+	router.Handle("POST /apps/{appID}/resume", s.corsMiddleware(s.authMiddleware(models.ScopeAppsWrite, s.resumeAppHandler)))
+	router.Handle("GET /apps/{appID}/stats", s.corsMiddleware(s.authMiddleware(models.ScopeReviewsRead, s.getAppStatsHandler)))
+	router.Handle("GET /apps/{appID}/incidents", s.corsMiddleware(s.authMiddleware(models.ScopeReviewsRead, s.getAppIncidentsHandler)))
+	router.Handle("GET /apps/{appID}/sync", s.corsMiddleware(s.authMiddleware(models.ScopeAppsRead, s.getAppSyncHandler)))
+	router.Handle("POST /apps/{appID}/backfill", s.corsMiddleware(s.authMiddleware(models.ScopeAppsWrite, s.postAppBackfillHandler)))
+	router.Handle("GET /apps/{appID}/backfill", s.corsMiddleware(s.authMiddleware(models.ScopeAppsRead, s.getAppBackfillHandler)))
+	router.Handle("GET /webhooks", s.corsMiddleware(s.authMiddleware(models.ScopeAdmin, s.getWebhooksHandler)))
+	router.Handle("POST /webhooks", s.corsMiddleware(s.authMiddleware(models.ScopeAdmin, s.postWebhooksHandler)))
+	router.Handle("GET /webhooks/{webhookID}", s.corsMiddleware(s.authMiddleware(models.ScopeAdmin, s.getWebhookHandler)))
+	router.Handle("PATCH /webhooks/{webhookID}", s.corsMiddleware(s.authMiddleware(models.ScopeAdmin, s.patchWebhookHandler)))
+	router.Handle("DELETE /webhooks/{webhookID}", s.corsMiddleware(s.authMiddleware(models.ScopeAdmin, s.deleteWebhookHandler)))
+	router.Handle("GET /webhooks/{webhookID}/deliveries", s.corsMiddleware(s.authMiddleware(models.ScopeAdmin, s.getWebhookDeliveriesHandler)))
+	router.Handle("GET /api-keys", s.corsMiddleware(s.authMiddleware(models.ScopeAdmin, s.getAPIKeysHandler)))
+	router.Handle("POST /api-keys", s.corsMiddleware(s.authMiddleware(models.ScopeAdmin, s.postAPIKeysHandler)))
+	router.Handle("DELETE /api-keys/{keyID}", s.corsMiddleware(s.authMiddleware(models.ScopeAdmin, s.deleteAPIKeyHandler)))
+	router.Handle("POST /auth/login", s.corsMiddleware(s.loginHandler))
+	router.Handle("POST /auth/logout", s.corsMiddleware(s.authMiddleware("", s.logoutHandler)))
+	router.Handle("GET /auth/whoami", s.corsMiddleware(s.authMiddleware("", s.whoamiHandler)))
+	router.Handle("GET /users", s.corsMiddleware(s.authMiddleware(models.ScopeAdmin, s.getUsersHandler)))
+	router.Handle("POST /users", s.corsMiddleware(s.authMiddleware(models.ScopeAdmin, s.postUsersHandler)))
+	router.Handle("PATCH /users/{userID}", s.corsMiddleware(s.authMiddleware(models.ScopeAdmin, s.patchUserHandler)))
+	router.Handle("DELETE /users/{userID}", s.corsMiddleware(s.authMiddleware(models.ScopeAdmin, s.deleteUserHandler)))
+
+	return router
+}
+
 func (s *server) Stop(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
 }
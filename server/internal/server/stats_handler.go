@@ -0,0 +1,107 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/reviews"
+)
+
+const (
+	// defaultStatsDays is the range of the stats when since is not given.
+	defaultStatsDays = 30
+	// maxStatsBuckets limits the range of the stats, e.g. to about 3 years of days.
+	maxStatsBuckets = 1000
+)
+
+// bucketDays is the minimum number of days of every bucket.
+var bucketDays = map[models.StatsBucket]int{
+	models.StatsBucketDay:   1,
+	models.StatsBucketWeek:  7,
+	models.StatsBucketMonth: 28,
+}
+
+// getAppStatsHandler is the handler for the /apps/{appID}/stats endpoint.
+// It returns the rating distribution, mean, median and volume of the app reviews,
+// grouped by the bucket query parameter: day (the default), week or month.
+// The since and until query parameters are the first and last days of the range, UTC,
+// until defaults to today and since to 30 days before until.
+func (s *server) getAppStatsHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.PathValue("appID")
+	params := r.URL.Query()
+
+	bucket := models.StatsBucket(params.Get("bucket"))
+	if bucket == "" {
+		bucket = models.StatsBucketDay
+	}
+	if !bucket.Valid() {
+		http.Error(w, "bucket must be day, week or month", http.StatusBadRequest)
+		return
+	}
+
+	since, until, err := parseStatsRange(params.Get("since"), params.Get("until"), bucket)
+	if err != nil {
+		s.logger.Error("error parsing stats range", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.appsClient.GetApp(principalFromContext(r.Context()).workspaceID(), appID); err != nil {
+		if errors.As(err, &apps.ErrAppNotFound{}) {
+			s.logger.Error("app not found", "appID", appID)
+			http.Error(w, "app not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error getting app", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	stats, err := s.reviewsClient.GetAppStats(principalFromContext(r.Context()).workspaceID(), appID, bucket, since, until)
+	if err != nil {
+		s.logger.Error("error getting app stats", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[models.AppStats]{
+		Data: stats,
+	})
+}
+
+// parseStatsRange parses the since and until days of the stats, defaulting to the last 30 days.
+func parseStatsRange(rawSince string, rawUntil string, bucket models.StatsBucket) (time.Time, time.Time, error) {
+	until, err := reviews.ParseDate(rawUntil)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid until: %w", err)
+	}
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	since, err := reviews.ParseDate(rawSince)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid since: %w", err)
+	}
+	if since.IsZero() {
+		since = until.AddDate(0, 0, -(defaultStatsDays - 1))
+	}
+
+	if since.After(until) {
+		return time.Time{}, time.Time{}, errors.New("since must not be after until")
+	}
+
+	days := int(until.Sub(since).Hours()/24) + 1
+	if days/bucketDays[bucket] > maxStatsBuckets {
+		return time.Time{}, time.Time{}, fmt.Errorf("the range is too long for %d %s buckets, use a shorter range or a larger bucket", maxStatsBuckets, bucket)
+	}
+
+	return since, until, nil
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/apps"
+)
+
+// streamBatchSize is the number of review events read from the database at once.
+const streamBatchSize = 100
+
+// streamReviewsHandler is the handler for the GET /reviews/stream endpoint.
+// It streams the new reviews of every app of the workspace, see streamReviews.
+func (s *server) streamReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	s.streamReviews(w, r, "")
+}
+
+// streamAppReviewsHandler is the handler for the GET /reviews/{appID}/stream endpoint.
+// It streams the new reviews of the app, see streamReviews.
+func (s *server) streamAppReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.PathValue("appID")
+
+	if _, err := s.appsClient.GetApp(principalFromContext(r.Context()).workspaceID(), appID); err != nil {
+		if errors.As(err, &apps.ErrAppNotFound{}) {
+			s.logger.Error("app not found", "appID", appID)
+			http.Error(w, "app not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error getting app", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.streamReviews(w, r, appID)
+}
+
+// streamReviews sends the reviews stored by the consumer as Server-Sent Events, a "review" event per review
+// with the id of its review event, until the client disconnects or the server stops.
+// The stream starts with the reviews stored after the connection, or resumes after the Last-Event-ID header
+// (or last_event_id query parameter) of a reconnecting client. A comment is sent every keep-alive interval,
+// so the proxies do not close an idle stream.
+func (s *server) streamReviews(w http.ResponseWriter, r *http.Request, appID string) {
+	workspaceID := principalFromContext(r.Context()).workspaceID()
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	// subscribe before reading the events, so the events committed in between are not missed
+	notifications, unsubscribe := s.tailer.Subscribe()
+	defer unsubscribe()
+
+	var lastID int64
+	var err error
+	if lastEventID != "" {
+		lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastID < 0 {
+			http.Error(w, "Last-Event-ID must be a review event id", http.StatusBadRequest)
+			return
+		}
+	} else {
+		lastID, err = s.reviewsClient.LastReviewEventID()
+		if err != nil {
+			s.logger.Error("error getting the last review event", "error", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// stops nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		s.logger.Error("error flushing the reviews stream", "error", err)
+		return
+	}
+
+	s.logger.Debug("reviews stream opened", "appID", appID, "workspaceID", workspaceID, "lastEventID", lastID)
+
+	keepAlive := time.NewTicker(s.config.StreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		for {
+			events, err := s.reviewsClient.FindReviewEvents(workspaceID, appID, lastID, streamBatchSize)
+			if err != nil {
+				s.logger.Error("error getting review events", "error", err)
+				return
+			}
+
+			for _, event := range events {
+				data, err := json.Marshal(event.Review)
+				if err != nil {
+					s.logger.Error("error encoding review", "error", err)
+					return
+				}
+				if _, err := fmt.Fprintf(w, "id: %d\nevent: review\ndata: %s\n\n", event.ID, data); err != nil {
+					return
+				}
+				lastID = event.ID
+			}
+
+			if len(events) < streamBatchSize {
+				break
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case _, ok := <-notifications:
+			if !ok {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/renantatsuo/app-review/server/internal/apps"
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// getAppSyncHandler is the handler for the GET /apps/{appID}/sync endpoint.
+// It returns where the incremental fetch of every storefront of the app stands, to debug missing reviews.
+func (s *server) getAppSyncHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.PathValue("appID")
+
+	if _, err := s.appsClient.GetApp(principalFromContext(r.Context()).workspaceID(), appID); err != nil {
+		if errors.As(err, &apps.ErrAppNotFound{}) {
+			s.logger.Error("app not found", "appID", appID)
+			http.Error(w, "app not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error getting app", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	states, err := s.reviewsClient.GetSyncStates(principalFromContext(r.Context()).workspaceID(), appID)
+	if err != nil {
+		s.logger.Error("error getting sync states", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[[]models.SyncState]{
+		Data: states,
+	})
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/users"
+)
+
+// userRequest is the body of the POST /users endpoint.
+type userRequest struct {
+	Username string      `json:"username"`
+	Password string      `json:"password"`
+	Role     models.Role `json:"role"`
+}
+
+// userUpdateRequest is the body of the PATCH /users/{userID} endpoint, omitted fields are left unchanged.
+type userUpdateRequest struct {
+	Role     *models.Role `json:"role"`
+	Password *string      `json:"password"`
+}
+
+// getUsersHandler is the handler for the GET /users endpoint.
+func (s *server) getUsersHandler(w http.ResponseWriter, r *http.Request) {
+	users, err := s.usersClient.GetUsers(principalFromContext(r.Context()).workspaceID())
+	if err != nil {
+		s.logger.Error("error getting users", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[[]models.User]{
+		Data: users,
+	})
+}
+
+// postUsersHandler is the handler for the POST /users endpoint.
+func (s *server) postUsersHandler(w http.ResponseWriter, r *http.Request) {
+	var req userRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("error decoding request", "error", err)
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	if err := users.ValidateUsername(req.Username); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := users.ValidatePassword(req.Password); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !req.Role.Valid() {
+		http.Error(w, "role must be viewer, editor or admin", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.usersClient.CreateUser(principalFromContext(r.Context()).workspaceID(), req.Username, req.Password, req.Role)
+	if err != nil {
+		if errors.Is(err, users.ErrUsernameTaken) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		s.logger.Error("error creating user", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Info("user created", "id", user.ID, "username", user.Username, "role", user.Role, "createdBy", principalFromContext(r.Context()).actor())
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ResponseData[models.User]{
+		Data: user,
+	})
+}
+
+// patchUserHandler is the handler for the PATCH /users/{userID} endpoint.
+// It changes the role or the password of the user, a new password logs them out.
+func (s *server) patchUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("userID"), 10, 64)
+	if err != nil {
+		http.Error(w, "userID must be a number", http.StatusBadRequest)
+		return
+	}
+
+	var req userUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("error decoding request", "error", err)
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Role != nil && !req.Role.Valid() {
+		http.Error(w, "role must be viewer, editor or admin", http.StatusBadRequest)
+		return
+	}
+	if req.Password != nil {
+		if err := users.ValidatePassword(*req.Password); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	user, err := s.usersClient.UpdateUser(principalFromContext(r.Context()).workspaceID(), id, users.Update{Role: req.Role, Password: req.Password})
+	if err != nil {
+		if errors.As(err, &users.ErrUserNotFound{}) {
+			s.logger.Error("user not found", "id", id)
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error updating user", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Info("user updated", "id", user.ID, "username", user.Username, "role", user.Role,
+		"passwordChanged", req.Password != nil, "updatedBy", principalFromContext(r.Context()).actor())
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[models.User]{
+		Data: user,
+	})
+}
+
+// deleteUserHandler is the handler for the DELETE /users/{userID} endpoint.
+// It deletes the user and logs them out, the apps they added keep their username.
+func (s *server) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("userID"), 10, 64)
+	if err != nil {
+		http.Error(w, "userID must be a number", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.usersClient.DeleteUser(principalFromContext(r.Context()).workspaceID(), id); err != nil {
+		if errors.As(err, &users.ErrUserNotFound{}) {
+			s.logger.Error("user not found", "id", id)
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+
+		s.logger.Error("error deleting user", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	s.logger.Info("user deleted", "id", id, "deletedBy", principalFromContext(r.Context()).actor())
+
+	w.WriteHeader(http.StatusNoContent)
+}
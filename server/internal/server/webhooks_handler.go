@@ -0,0 +1,267 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/webhooks"
+)
+
+const defaultDeliveriesLimit = 50
+
+// webhookRequest is the body of the POST and PATCH /webhooks endpoints.
+// On PATCH every field is optional, only the given ones are updated.
+type webhookRequest struct {
+	URL       *string `json:"url"`
+	Secret    *string `json:"secret"`
+	AppID     *string `json:"app_id"`
+	MaxRating *int    `json:"max_rating"`
+	Keyword   *string `json:"keyword"`
+	Active    *bool   `json:"active"`
+}
+
+// validate is a helper function to validate the given fields of the request.
+func (req webhookRequest) validate() error {
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return err
+		}
+	}
+	if req.AppID != nil && *req.AppID != "" {
+		if _, err := validateAppID(*req.AppID, ""); err != nil {
+			return err
+		}
+	}
+	if req.MaxRating != nil && (*req.MaxRating < 0 || *req.MaxRating > 5) {
+		return errors.New("max_rating must be a number between 0 and 5")
+	}
+	return nil
+}
+
+// getWebhooksHandler is the handler for the GET /webhooks endpoint.
+func (s *server) getWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := s.webhooksClient.GetAllSubscriptions(principalFromContext(r.Context()).workspaceID())
+	if err != nil {
+		s.logger.Error("error getting webhook subscriptions", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[[]models.WebhookSubscription]{
+		Data: subscriptions,
+	})
+}
+
+// postWebhooksHandler is the handler for the POST /webhooks endpoint.
+// It creates a subscription, generating its secret if none is given.
+// The secret is only returned in this response.
+func (s *server) postWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("error decoding request", "error", err)
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.URL == nil {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		s.logger.Error("error validating webhook", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	subscription := models.WebhookSubscription{
+		WorkspaceID: principalFromContext(r.Context()).workspaceID(),
+		URL:         *req.URL,
+		Secret:      webhooks.NewSecret(),
+		Active:      true,
+	}
+	if req.Secret != nil && *req.Secret != "" {
+		subscription.Secret = *req.Secret
+	}
+	if req.AppID != nil {
+		subscription.AppID = *req.AppID
+	}
+	if req.MaxRating != nil {
+		subscription.MaxRating = *req.MaxRating
+	}
+	if req.Keyword != nil {
+		subscription.Keyword = *req.Keyword
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+
+	subscription, err := s.webhooksClient.AddSubscription(subscription)
+	if err != nil {
+		s.logger.Error("error creating webhook subscription", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ResponseData[models.WebhookSubscription]{
+		Data: subscription,
+	})
+}
+
+// getWebhookHandler is the handler for the GET /webhooks/{webhookID} endpoint.
+func (s *server) getWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	subscription, err := s.webhooksClient.GetWorkspaceSubscription(principalFromContext(r.Context()).workspaceID(), id)
+	if err != nil {
+		s.handleWebhookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[models.WebhookSubscription]{
+		Data: subscription,
+	})
+}
+
+// patchWebhookHandler is the handler for the PATCH /webhooks/{webhookID} endpoint.
+// The secret cannot be changed, create a new subscription instead.
+func (s *server) patchWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("error decoding request", "error", err)
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Secret != nil {
+		http.Error(w, "secret cannot be changed", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		s.logger.Error("error validating webhook", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := s.webhooksClient.UpdateSubscription(principalFromContext(r.Context()).workspaceID(), id, webhooks.Update{
+		URL:       req.URL,
+		AppID:     req.AppID,
+		MaxRating: req.MaxRating,
+		Keyword:   req.Keyword,
+		Active:    req.Active,
+	})
+	if err != nil {
+		s.handleWebhookError(w, err)
+		return
+	}
+
+	subscription, err := s.webhooksClient.GetWorkspaceSubscription(principalFromContext(r.Context()).workspaceID(), id)
+	if err != nil {
+		s.handleWebhookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[models.WebhookSubscription]{
+		Data: subscription,
+	})
+}
+
+// deleteWebhookHandler is the handler for the DELETE /webhooks/{webhookID} endpoint.
+// It deletes the subscription and its delivery log, its pending deliveries are dropped.
+func (s *server) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.webhooksClient.DeleteSubscription(principalFromContext(r.Context()).workspaceID(), id); err != nil {
+		s.handleWebhookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getWebhookDeliveriesHandler is the handler for the GET /webhooks/{webhookID}/deliveries endpoint.
+// It returns the latest delivery attempts of the subscription, most recent first.
+func (s *server) getWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeliveriesLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		limit, err = parseLimit(raw)
+		if err != nil {
+			s.logger.Error("error parsing limit", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if _, err := s.webhooksClient.GetWorkspaceSubscription(principalFromContext(r.Context()).workspaceID(), id); err != nil {
+		s.handleWebhookError(w, err)
+		return
+	}
+
+	deliveries, err := s.webhooksClient.GetDeliveries(id, limit)
+	if err != nil {
+		s.logger.Error("error getting webhook deliveries", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ResponseData[[]models.WebhookDelivery]{
+		Data: deliveries,
+	})
+}
+
+// parseWebhookID parses the webhookID path value, writing a bad request if it is invalid.
+func (s *server) parseWebhookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("webhookID"), 10, 64)
+	if err != nil {
+		http.Error(w, "webhookID must be a number", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// handleWebhookError writes a not found if the subscription does not exist,
+// or an internal server error otherwise.
+func (s *server) handleWebhookError(w http.ResponseWriter, err error) {
+	if errors.As(err, &webhooks.ErrSubscriptionNotFound{}) {
+		s.logger.Error("webhook subscription not found", "error", err)
+		http.Error(w, "webhook subscription not found", http.StatusNotFound)
+		return
+	}
+
+	s.logger.Error("error handling webhook subscription", "error", err)
+	http.Error(w, "internal server error", http.StatusInternalServerError)
+}
+
+// validateWebhookURL is a helper function to validate the URL the webhooks are posted to.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	return nil
+}
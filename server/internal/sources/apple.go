@@ -0,0 +1,123 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/pkg/apple"
+)
+
+// AppleSource fetches the apps and their reviews from the App Store.
+type AppleSource struct {
+	client *apple.AppleClient
+}
+
+func NewAppleSource(client *apple.AppleClient) *AppleSource {
+	return &AppleSource{client: client}
+}
+
+func (s *AppleSource) Platform() models.Platform {
+	return models.PlatformIOS
+}
+
+// LookupApp gets the app data from the Apple API.
+func (s *AppleSource) LookupApp(ctx context.Context, appID string) (models.App, error) {
+	appsResponse, err := s.client.GetAppData(ctx, appID)
+	if err != nil {
+		return models.App{}, err
+	}
+
+	if appsResponse.ResultCount == 0 {
+		return models.App{}, ErrAppNotFound
+	}
+
+	return models.AppFromAppleApp(appsResponse.Results[0]), nil
+}
+
+// LookupAppByBundleID gets the app data of the bundle ID from the Apple API.
+func (s *AppleSource) LookupAppByBundleID(ctx context.Context, bundleID string) (models.App, error) {
+	appsResponse, err := s.client.GetAppDataByBundleID(ctx, bundleID)
+	if err != nil {
+		return models.App{}, err
+	}
+
+	if appsResponse.ResultCount == 0 {
+		return models.App{}, ErrAppNotFound
+	}
+
+	return models.AppFromAppleApp(appsResponse.Results[0]), nil
+}
+
+// SearchApps searches the apps of the country storefront with the Apple API.
+func (s *AppleSource) SearchApps(ctx context.Context, term string, country string, limit int) ([]models.AppSearchResult, error) {
+	searchResponse, err := s.client.SearchApps(ctx, term, country, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []models.AppSearchResult{}
+	for _, app := range searchResponse.Results {
+		res = append(res, models.AppSearchResultFromAppleApp(app))
+	}
+
+	return res, nil
+}
+
+// FetchReviewsSince follows the pages of the reviews feed until a review older than the since time.
+// There are no reviews if the feed was not modified since the previous commit,
+// which caches the validators of the first page of the feed.
+// The feed has apple.MaxReviewsPages pages at most and is always followed in full, so the fetch is never truncated and there is no cursor.
+func (s *AppleSource) FetchReviewsSince(ctx context.Context, appID string, country string, since time.Time, cursor string) ([]models.Review, Commit, error) {
+	res := []models.Review{}
+
+	latest, err := s.client.GetLatestReviews(ctx, appID, country)
+	if err != nil {
+		return nil, nil, err
+	}
+	commit := latest.SaveValidators
+
+	reviews := latest
+	for {
+		for _, review := range reviews.Feed.Entry {
+			r, err := models.ReviewFromAppleReview(review, appID, country)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error converting apple review to model: %w", err)
+			}
+
+			if r.SentAt.Before(since) {
+				return res, commit, nil
+			}
+			res = append(res, r)
+		}
+
+		if !reviews.HasNext() {
+			return res, commit, nil
+		}
+
+		reviews, err = reviews.Next(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// FetchReviewsPage fetches a page of the reviews feed, there are apple.MaxReviewsPages pages at most.
+func (s *AppleSource) FetchReviewsPage(ctx context.Context, appID string, country string, page int) ([]models.Review, bool, error) {
+	reviews, err := s.client.GetReviewsPage(ctx, appID, country, page)
+	if err != nil {
+		return nil, false, err
+	}
+
+	res := []models.Review{}
+	for _, review := range reviews.Feed.Entry {
+		r, err := models.ReviewFromAppleReview(review, appID, country)
+		if err != nil {
+			return nil, false, fmt.Errorf("error converting apple review to model: %w", err)
+		}
+		res = append(res, r)
+	}
+
+	return res, reviews.HasNext() && page < apple.MaxReviewsPages, nil
+}
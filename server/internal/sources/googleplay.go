@@ -0,0 +1,74 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/pkg/apple"
+	"github.com/renantatsuo/app-review/server/pkg/googleplay"
+)
+
+// maxGooglePlayPages limits how many pages of reviews are fetched at once,
+// so the first fetch of an app with many reviews does not go through all of them.
+const maxGooglePlayPages = 10
+
+// GooglePlaySource fetches the apps and their reviews from Google Play.
+// The app IDs are the package names of the apps.
+type GooglePlaySource struct {
+	client *googleplay.GooglePlayClient
+}
+
+func NewGooglePlaySource(client *googleplay.GooglePlayClient) *GooglePlaySource {
+	return &GooglePlaySource{client: client}
+}
+
+func (s *GooglePlaySource) Platform() models.Platform {
+	return models.PlatformAndroid
+}
+
+// LookupApp gets the app data from its store page.
+func (s *GooglePlaySource) LookupApp(ctx context.Context, appID string) (models.App, error) {
+	app, err := s.client.GetAppData(ctx, appID, apple.DefaultCountry)
+	if err != nil {
+		if errors.Is(err, googleplay.ErrAppNotFound) {
+			return models.App{}, ErrAppNotFound
+		}
+		return models.App{}, err
+	}
+
+	return models.AppFromGooglePlayApp(app), nil
+}
+
+// FetchReviewsSince follows the pages of the latest reviews, or from the page token of the cursor,
+// until a review older than the since time.
+// It returns a TruncatedError with the reviews of maxGooglePlayPages pages if it did not reach the since time,
+// its cursor is the token of the next page.
+// The requests are never conditional, so there is nothing to commit.
+func (s *GooglePlaySource) FetchReviewsSince(ctx context.Context, appID string, country string, since time.Time, cursor string) ([]models.Review, Commit, error) {
+	res := []models.Review{}
+	token := cursor
+
+	for range maxGooglePlayPages {
+		page, err := s.client.GetLatestReviews(ctx, appID, country, token)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, review := range page.Reviews {
+			r := models.ReviewFromGooglePlayReview(review, appID, country)
+			if r.SentAt.Before(since) {
+				return res, noCommit, nil
+			}
+			res = append(res, r)
+		}
+
+		if page.NextToken == "" {
+			return res, noCommit, nil
+		}
+		token = page.NextToken
+	}
+
+	return res, noCommit, &TruncatedError{Cursor: token}
+}
@@ -0,0 +1,67 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/pkg/googleplay"
+)
+
+// endlessTransport answers every reviews request with a page of one review sent at the given time,
+// followed by a next page whose token is the number of the request.
+type endlessTransport struct {
+	sentAt time.Time
+	// bodies are the bodies of the requests.
+	bodies []string
+}
+
+func (e *endlessTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	e.bodies = append(e.bodies, string(body))
+
+	n := len(e.bodies)
+	payload := fmt.Sprintf(`)]}'`+"\n\n"+`[["wrb.fr","UsvDTd","[[[\"review-%d\",[\"Author\"],5,null,\"Great\",[%d,0]]],[null,\"token-%d\"]]",null,null,null,"generic"]]`,
+		n, e.sentAt.Unix(), n)
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(payload)), Request: request}, nil
+}
+
+func TestGooglePlayFetchReviewsSinceTruncates(t *testing.T) {
+	now := time.Now().UTC()
+	transport := &endlessTransport{sentAt: now}
+	source := NewGooglePlaySource(googleplay.New(googleplay.WithHTTPClient(&http.Client{Transport: transport})))
+
+	reviews, _, err := source.FetchReviewsSince(context.Background(), "com.example.app", "us", now.Add(-time.Hour), "")
+	var truncated *TruncatedError
+	if !errors.As(err, &truncated) || !errors.Is(err, ErrTruncated) {
+		t.Fatalf("got error %v, want a TruncatedError", err)
+	}
+	if len(reviews) != maxGooglePlayPages || len(transport.bodies) != maxGooglePlayPages {
+		t.Errorf("got %d reviews in %d requests, want %d of both", len(reviews), len(transport.bodies), maxGooglePlayPages)
+	}
+	if want := fmt.Sprintf("token-%d", maxGooglePlayPages); truncated.Cursor != want {
+		t.Errorf("got cursor %q, want the token of the next page %q", truncated.Cursor, want)
+	}
+
+	// the fetch resumes from the page of the cursor
+	if _, _, err := source.FetchReviewsSince(context.Background(), "com.example.app", "us", now.Add(-time.Hour), truncated.Cursor); !errors.Is(err, ErrTruncated) {
+		t.Errorf("got error %v, want ErrTruncated", err)
+	}
+	if body := transport.bodies[maxGooglePlayPages]; !strings.Contains(body, truncated.Cursor) {
+		t.Errorf("got request body %q, want the token of the cursor", body)
+	}
+
+	transport.sentAt = now.Add(-2 * time.Hour)
+	reviews, _, err = source.FetchReviewsSince(context.Background(), "com.example.app", "us", now.Add(-time.Hour), "")
+	if err != nil || len(reviews) != 0 {
+		t.Errorf("got %d reviews and error %v, want none once a review is older than the since time", len(reviews), err)
+	}
+}
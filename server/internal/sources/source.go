@@ -0,0 +1,91 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// ErrAppNotFound is returned when the store has no app with the given ID.
+var ErrAppNotFound = errors.New("app not found")
+
+// ErrTruncated is matched by the TruncatedError returned with the latest reviews
+// when there are too many reviews since the given time to fetch them at once.
+var ErrTruncated = errors.New("reviews truncated")
+
+// TruncatedError is returned along with the fetched reviews when the older ones since the given time were not fetched.
+// The fetch resumes after the returned reviews from its cursor.
+type TruncatedError struct {
+	Cursor string
+}
+
+func (e *TruncatedError) Error() string {
+	return ErrTruncated.Error()
+}
+
+func (e *TruncatedError) Unwrap() error {
+	return ErrTruncated
+}
+
+// Source is a store the apps and their reviews are fetched from.
+type Source interface {
+	// Platform is the platform of the apps of the store.
+	Platform() models.Platform
+	// LookupApp returns the metadata of the app, or ErrAppNotFound.
+	LookupApp(ctx context.Context, appID string) (models.App, error)
+	// FetchReviewsSince returns the reviews of the app on the country storefront sent at or after the since time,
+	// most recent first. The reviews sent exactly at the since time may already be stored.
+	// The commit is called once the reviews are persisted, see Commit.
+	// It returns a TruncatedError along with the reviews if it could not fetch all of them,
+	// its cursor fetches the next ones. The cursor is empty to fetch from the latest reviews.
+	FetchReviewsSince(ctx context.Context, appID string, country string, since time.Time, cursor string) ([]models.Review, Commit, error)
+}
+
+// Commit saves what makes the next fetch of a storefront conditional, like the validators of the reviews feed.
+// It must only be called once the fetched reviews are persisted, otherwise the next fetch could skip them.
+// Failing to commit only makes the next fetch unconditional.
+type Commit func(ctx context.Context) error
+
+// noCommit is the Commit of the fetches that are never conditional.
+func noCommit(ctx context.Context) error {
+	return nil
+}
+
+// Pager is implemented by the sources whose reviews can be fetched by page number, to backfill them.
+type Pager interface {
+	// FetchReviewsPage returns a page of the reviews of the app on the country storefront, most recent first.
+	// Pages start at 1, and it also returns whether there is a next page.
+	FetchReviewsPage(ctx context.Context, appID string, country string, page int) ([]models.Review, bool, error)
+}
+
+// Searcher is implemented by the sources whose apps can be found without knowing their store ID.
+type Searcher interface {
+	// SearchApps returns at most limit apps of the country storefront matching the term, most relevant first.
+	SearchApps(ctx context.Context, term string, country string, limit int) ([]models.AppSearchResult, error)
+	// LookupAppByBundleID returns the metadata of the app with the bundle ID, or ErrAppNotFound.
+	LookupAppByBundleID(ctx context.Context, bundleID string) (models.App, error)
+}
+
+// Sources are the sources of every supported platform.
+type Sources map[models.Platform]Source
+
+// New creates the sources from their implementations.
+func New(sources ...Source) Sources {
+	res := Sources{}
+	for _, source := range sources {
+		res[source.Platform()] = source
+	}
+	return res
+}
+
+// Get returns the source of the platform.
+func (s Sources) Get(platform models.Platform) (Source, error) {
+	source, ok := s[platform]
+	if !ok {
+		return nil, fmt.Errorf("no source for platform %q", platform)
+	}
+	return source, nil
+}
@@ -0,0 +1,19 @@
+package users
+
+import (
+	"database/sql"
+	"log/slog"
+)
+
+// UsersClient is the client for the users of the web app and their sessions.
+type UsersClient struct {
+	logger *slog.Logger
+	db     *sql.DB
+}
+
+func New(logger *slog.Logger, db *sql.DB) *UsersClient {
+	return &UsersClient{
+		logger: logger,
+		db:     db,
+	}
+}
@@ -0,0 +1,235 @@
+package users
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+const userColumns = "id, workspace_id, username, role, created_at, updated_at, last_login_at"
+
+var (
+	// ErrInvalidCredentials is returned when the username does not exist or the password does not match.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrUsernameTaken is returned when another user already has the username.
+	ErrUsernameTaken = errors.New("username is already taken")
+
+	usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,64}$`)
+)
+
+// ErrUserNotFound is an error type for when a user is not found.
+type ErrUserNotFound struct {
+	ID int64
+}
+
+func (e ErrUserNotFound) Error() string {
+	return fmt.Sprintf("user not found: %d", e.ID)
+}
+
+// Update holds the user fields to update, nil fields are left unchanged.
+type Update struct {
+	Role     *models.Role
+	Password *string
+}
+
+// ValidateUsername returns an error if the username cannot be used.
+func ValidateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return errors.New("username must be 1 to 64 letters, digits, dots, dashes or underscores")
+	}
+	return nil
+}
+
+// CreateUser creates a user of the workspace with the given password and role.
+// It returns ErrUsernameTaken if another user of any workspace has the username, ignoring the case.
+func (u *UsersClient) CreateUser(workspaceID int64, username string, password string, role models.Role) (models.User, error) {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	now := time.Now().UTC()
+	res, err := u.db.Exec(`INSERT INTO users (workspace_id, username, password_hash, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (username) DO NOTHING`, workspaceID, username, hash, role, now, now)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return models.User{}, err
+	}
+	if affected == 0 {
+		return models.User{}, ErrUsernameTaken
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return models.User{}, err
+	}
+
+	return u.GetUser(id)
+}
+
+// Authenticate returns the user with the username if the password matches, and records the login.
+// It returns ErrInvalidCredentials if the username does not exist or the password does not match.
+func (u *UsersClient) Authenticate(username string, password string) (models.User, error) {
+	var id int64
+	var hash string
+	err := u.db.QueryRow("SELECT id, password_hash FROM users WHERE username = ?", username).Scan(&id, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		// hash anyway, so the response time does not tell which usernames exist
+		hashPassword(password)
+		return models.User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+
+	ok, err := verifyPassword(hash, password)
+	if err != nil {
+		return models.User{}, err
+	}
+	if !ok {
+		return models.User{}, ErrInvalidCredentials
+	}
+
+	if _, err := u.db.Exec("UPDATE users SET last_login_at = ? WHERE id = ?", time.Now().UTC(), id); err != nil {
+		u.logger.Error("error recording user login", "id", id, "error", err)
+	}
+
+	return u.GetUser(id)
+}
+
+// GetUser returns the user.
+func (u *UsersClient) GetUser(id int64) (models.User, error) {
+	row := u.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ?", id)
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, ErrUserNotFound{ID: id}
+	}
+	return user, err
+}
+
+// GetUsers returns every user of the workspace.
+func (u *UsersClient) GetUsers(workspaceID int64) ([]models.User, error) {
+	users := []models.User{}
+
+	rows, err := u.db.Query("SELECT "+userColumns+" FROM users WHERE workspace_id = ? ORDER BY id", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// UpdateUser updates the role or the password of the user of the workspace.
+// Changing the password logs the user out of all their sessions.
+func (u *UsersClient) UpdateUser(workspaceID int64, id int64, update Update) (models.User, error) {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return models.User{}, err
+	}
+	defer tx.Rollback()
+
+	sets := []string{"updated_at = ?"}
+	args := []any{time.Now().UTC()}
+
+	if update.Role != nil {
+		sets = append(sets, "role = ?")
+		args = append(args, *update.Role)
+	}
+	if update.Password != nil {
+		hash, err := hashPassword(*update.Password)
+		if err != nil {
+			return models.User{}, err
+		}
+		sets = append(sets, "password_hash = ?")
+		args = append(args, hash)
+
+		if _, err := tx.Exec("DELETE FROM sessions WHERE user_id = ?", id); err != nil {
+			return models.User{}, err
+		}
+	}
+
+	res, err := tx.Exec("UPDATE users SET "+strings.Join(sets, ", ")+" WHERE id = ? AND workspace_id = ?", append(args, id, workspaceID)...)
+	if err != nil {
+		return models.User{}, err
+	}
+	if err := checkUserUpdated(res, id); err != nil {
+		return models.User{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.User{}, err
+	}
+
+	return u.GetUser(id)
+}
+
+// DeleteUser deletes the user of the workspace and their sessions.
+func (u *UsersClient) DeleteUser(workspaceID int64, id int64) error {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM sessions WHERE user_id = ?", id); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("DELETE FROM users WHERE id = ? AND workspace_id = ?", id, workspaceID)
+	if err != nil {
+		return err
+	}
+	if err := checkUserUpdated(res, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func scanUser(row interface{ Scan(...any) error }) (models.User, error) {
+	var user models.User
+	var lastLoginAt sql.NullTime
+
+	err := row.Scan(&user.ID, &user.WorkspaceID, &user.Username, &user.Role, &user.CreatedAt, &user.UpdatedAt, &lastLoginAt)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	if lastLoginAt.Valid {
+		user.LastLoginAt = &lastLoginAt.Time
+	}
+
+	return user, nil
+}
+
+// checkUserUpdated returns ErrUserNotFound if no user was affected by the statement.
+func checkUserUpdated(res sql.Result, id int64) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrUserNotFound{ID: id}
+	}
+
+	return nil
+}
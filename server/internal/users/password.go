@@ -0,0 +1,106 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// passwordAlgorithm prefixes the hashes, so the algorithm can be changed while the old hashes still verify.
+	passwordAlgorithm = "argon2id"
+	// passwordTime, passwordMemory (in KiB) and passwordThreads follow the second recommended option of RFC 9106.
+	passwordTime      = 3
+	passwordMemory    = 64 * 1024
+	passwordThreads   = 4
+	passwordSaltBytes = 16
+	passwordKeyBytes  = 32
+
+	// maxConcurrentHashes is how many passwords are hashed at once, each hash takes passwordMemory,
+	// so a burst of logins queues instead of exhausting the memory of the server.
+	maxConcurrentHashes = 4
+
+	// MinPasswordLength is the length of the shortest password accepted.
+	MinPasswordLength = 8
+)
+
+var errInvalidPasswordHash = errors.New("invalid password hash")
+
+// hashSlots is the semaphore of the running hashes, see maxConcurrentHashes.
+var hashSlots = make(chan struct{}, maxConcurrentHashes)
+
+// ValidatePassword returns an error if the password is too weak to be set.
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	return nil
+}
+
+// hashPassword hashes the password with argon2id and a random salt, as algorithm$v=version$m=memory,t=time,p=threads$salt$key.
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2Key(password, salt, passwordKeyBytes)
+
+	return strings.Join([]string{
+		passwordAlgorithm,
+		fmt.Sprintf("v=%d", argon2.Version),
+		argon2Params(passwordMemory, passwordTime, passwordThreads),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+// verifyPassword returns true if the password matches the hash.
+// The parameters of the hash must be the ones hashPassword uses, so a hash cannot make the server do an unbounded amount of work.
+func verifyPassword(hash string, password string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[0] != passwordAlgorithm ||
+		parts[1] != fmt.Sprintf("v=%d", argon2.Version) || parts[2] != argon2Params(passwordMemory, passwordTime, passwordThreads) {
+		return false, errInvalidPasswordHash
+	}
+
+	salt, want, err := decodeSaltAndKey(parts[3], parts[4])
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2Key(password, salt, uint32(len(want)))
+
+	return subtle.ConstantTimeCompare(key, want) == 1, nil
+}
+
+// argon2Key derives the key of the password with argon2id, once one of the hashSlots is free.
+func argon2Key(password string, salt []byte, keyLength uint32) []byte {
+	hashSlots <- struct{}{}
+	defer func() { <-hashSlots }()
+
+	return argon2.IDKey([]byte(password), salt, passwordTime, passwordMemory, passwordThreads, keyLength)
+}
+
+// decodeSaltAndKey decodes the salt and the key of a hash, the key must be passwordKeyBytes long.
+func decodeSaltAndKey(rawSalt string, rawKey string) ([]byte, []byte, error) {
+	salt, err := base64.RawStdEncoding.DecodeString(rawSalt)
+	if err != nil {
+		return nil, nil, errInvalidPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(rawKey)
+	if err != nil || len(key) != passwordKeyBytes {
+		return nil, nil, errInvalidPasswordHash
+	}
+	return salt, key, nil
+}
+
+// argon2Params formats the parameters of an argon2id hash.
+func argon2Params(memory uint32, time uint32, threads uint8) string {
+	return fmt.Sprintf("m=%d,t=%d,p=%d", memory, time, threads)
+}
@@ -0,0 +1,96 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// tokenBytes is the number of random bytes of the session and CSRF tokens.
+const tokenBytes = 32
+
+// ErrSessionNotFound is returned when a session token does not exist or expired.
+var ErrSessionNotFound = errors.New("session not found")
+
+// CreateSession logs the user in for the given duration, it returns the session and its token,
+// which is only stored hashed and cannot be retrieved again.
+func (u *UsersClient) CreateSession(userID int64, ttl time.Duration) (models.Session, string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return models.Session{}, "", err
+	}
+	csrfToken, err := randomToken()
+	if err != nil {
+		return models.Session{}, "", err
+	}
+
+	now := time.Now().UTC()
+	session := models.Session{
+		UserID:    userID,
+		CSRFToken: csrfToken,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	// the expired sessions are cleaned up as new ones are created
+	if _, err := u.db.Exec("DELETE FROM sessions WHERE expires_at <= ?", now); err != nil {
+		u.logger.Error("error deleting expired sessions", "error", err)
+	}
+
+	_, err = u.db.Exec("INSERT INTO sessions (id, user_id, csrf_token, created_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+		hashToken(token), session.UserID, session.CSRFToken, session.CreatedAt, session.ExpiresAt)
+	if err != nil {
+		return models.Session{}, "", err
+	}
+
+	return session, token, nil
+}
+
+// GetSession returns the session of the token and its user.
+// It returns ErrSessionNotFound if the session does not exist or expired.
+func (u *UsersClient) GetSession(token string) (models.Session, models.User, error) {
+	var session models.Session
+	err := u.db.QueryRow("SELECT user_id, csrf_token, created_at, expires_at FROM sessions WHERE id = ? AND expires_at > ?",
+		hashToken(token), time.Now().UTC()).Scan(&session.UserID, &session.CSRFToken, &session.CreatedAt, &session.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Session{}, models.User{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return models.Session{}, models.User{}, err
+	}
+
+	user, err := u.GetUser(session.UserID)
+	if errors.As(err, &ErrUserNotFound{}) {
+		return models.Session{}, models.User{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return models.Session{}, models.User{}, err
+	}
+
+	return session, user, nil
+}
+
+// DeleteSession logs the session out, deleting a session that does not exist is not an error.
+func (u *UsersClient) DeleteSession(token string) error {
+	_, err := u.db.Exec("DELETE FROM sessions WHERE id = ?", hashToken(token))
+	return err
+}
+
+func randomToken() (string, error) {
+	random := make([]byte, tokenBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random), nil
+}
+
+// hashToken returns the hex SHA-256 of the session token, so a leaked database does not leak the sessions.
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,19 @@
+package webhooks
+
+import (
+	"database/sql"
+	"log/slog"
+)
+
+// WebhooksClient is the client for the webhook subscriptions and their delivery log.
+type WebhooksClient struct {
+	logger *slog.Logger
+	db     *sql.DB
+}
+
+func New(logger *slog.Logger, db *sql.DB) *WebhooksClient {
+	return &WebhooksClient{
+		logger: logger,
+		db:     db,
+	}
+}
@@ -0,0 +1,240 @@
+package webhooks
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+const (
+	subscriptionColumns = "id, workspace_id, url, app_id, max_rating, keyword, active, created_at, updated_at"
+	deliveryColumns     = "id, subscription_id, event_id, attempt, status_code, error, duration_ms, created_at"
+)
+
+// ErrSubscriptionNotFound is an error type for when a webhook subscription is not found.
+type ErrSubscriptionNotFound struct {
+	ID int64
+}
+
+func (e ErrSubscriptionNotFound) Error() string {
+	return fmt.Sprintf("webhook subscription not found: %d", e.ID)
+}
+
+// Update holds the subscription fields to update, nil fields are left unchanged.
+type Update struct {
+	URL       *string
+	AppID     *string
+	MaxRating *int
+	Keyword   *string
+	Active    *bool
+}
+
+// AddSubscription adds a new webhook subscription to its workspace and returns it with its ID.
+func (w *WebhooksClient) AddSubscription(subscription models.WebhookSubscription) (models.WebhookSubscription, error) {
+	res, err := w.db.Exec("INSERT INTO webhook_subscriptions (workspace_id, url, secret, app_id, max_rating, keyword, active) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		subscription.WorkspaceID, subscription.URL, subscription.Secret, subscription.AppID, subscription.MaxRating, subscription.Keyword, subscription.Active)
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	created, err := w.GetSubscription(id)
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	created.Secret = subscription.Secret
+
+	return created, nil
+}
+
+// GetSubscription returns the webhook subscription, without its secret.
+func (w *WebhooksClient) GetSubscription(id int64) (models.WebhookSubscription, error) {
+	row := w.db.QueryRow("SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE id = ?", id)
+	subscription, err := scanSubscription(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.WebhookSubscription{}, ErrSubscriptionNotFound{ID: id}
+	}
+	return subscription, err
+}
+
+// GetWorkspaceSubscription returns the webhook subscription of the workspace, without its secret.
+// The subscriptions of the other workspaces are not found.
+func (w *WebhooksClient) GetWorkspaceSubscription(workspaceID int64, id int64) (models.WebhookSubscription, error) {
+	row := w.db.QueryRow("SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE id = ? AND workspace_id = ?", id, workspaceID)
+	subscription, err := scanSubscription(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.WebhookSubscription{}, ErrSubscriptionNotFound{ID: id}
+	}
+	return subscription, err
+}
+
+// GetSubscriptionSecret returns the secret the subscription payloads are signed with.
+func (w *WebhooksClient) GetSubscriptionSecret(id int64) (string, error) {
+	var secret string
+	err := w.db.QueryRow("SELECT secret FROM webhook_subscriptions WHERE id = ?", id).Scan(&secret)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrSubscriptionNotFound{ID: id}
+	}
+	return secret, err
+}
+
+// GetAllSubscriptions returns every webhook subscription of the workspace, without their secrets.
+func (w *WebhooksClient) GetAllSubscriptions(workspaceID int64) ([]models.WebhookSubscription, error) {
+	return w.querySubscriptions("SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE workspace_id = ? ORDER BY id", workspaceID)
+}
+
+// GetActiveSubscriptionsByStorefront returns the active subscriptions to the app reviews, including the ones to every app,
+// of every workspace actively watching the app on the country storefront.
+// The reviews of an app fetched once are so fanned out to the workspaces watching their storefront.
+func (w *WebhooksClient) GetActiveSubscriptionsByStorefront(appID string, country string) ([]models.WebhookSubscription, error) {
+	// the countries of a workspace app are comma separated, they are wrapped in commas to match whole countries
+	return w.querySubscriptions("SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE active AND (app_id = '' OR app_id = ?)"+
+		" AND workspace_id IN (SELECT workspace_id FROM workspace_apps WHERE app_id = ? AND status = ?"+
+		" AND instr(',' || countries || ',', ',' || ? || ',') > 0) ORDER BY id",
+		appID, appID, models.AppStatusActive, country)
+}
+
+// UpdateSubscription updates the non nil fields of the update of the subscription of the workspace.
+func (w *WebhooksClient) UpdateSubscription(workspaceID int64, id int64, update Update) error {
+	sets := []string{"updated_at = CURRENT_TIMESTAMP"}
+	args := []any{}
+
+	if update.URL != nil {
+		sets = append(sets, "url = ?")
+		args = append(args, *update.URL)
+	}
+	if update.AppID != nil {
+		sets = append(sets, "app_id = ?")
+		args = append(args, *update.AppID)
+	}
+	if update.MaxRating != nil {
+		sets = append(sets, "max_rating = ?")
+		args = append(args, *update.MaxRating)
+	}
+	if update.Keyword != nil {
+		sets = append(sets, "keyword = ?")
+		args = append(args, *update.Keyword)
+	}
+	if update.Active != nil {
+		sets = append(sets, "active = ?")
+		args = append(args, *update.Active)
+	}
+
+	res, err := w.db.Exec("UPDATE webhook_subscriptions SET "+strings.Join(sets, ", ")+" WHERE id = ? AND workspace_id = ?", append(args, id, workspaceID)...)
+	if err != nil {
+		return err
+	}
+
+	return checkSubscriptionUpdated(res, id)
+}
+
+// DeleteSubscription deletes the webhook subscription of the workspace and its delivery log.
+func (w *WebhooksClient) DeleteSubscription(workspaceID int64, id int64) error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM webhook_subscriptions WHERE id = ? AND workspace_id = ?", id, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkSubscriptionUpdated(res, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM webhook_deliveries WHERE subscription_id = ?", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddDelivery logs a delivery attempt.
+func (w *WebhooksClient) AddDelivery(delivery models.WebhookDelivery) error {
+	_, err := w.db.Exec("INSERT INTO webhook_deliveries (subscription_id, event_id, attempt, status_code, error, duration_ms) VALUES (?, ?, ?, ?, ?, ?)",
+		delivery.SubscriptionID, delivery.EventID, delivery.Attempt, delivery.StatusCode, delivery.Error, delivery.DurationMS)
+	return err
+}
+
+// CountDeliveries counts the delivery attempts of the event.
+func (w *WebhooksClient) CountDeliveries(eventID string) (int, error) {
+	var count int
+	err := w.db.QueryRow("SELECT COUNT(*) FROM webhook_deliveries WHERE event_id = ?", eventID).Scan(&count)
+	return count, err
+}
+
+// GetDeliveries returns the latest delivery attempts to the subscription, most recent first.
+func (w *WebhooksClient) GetDeliveries(subscriptionID int64, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+
+	rows, err := w.db.Query("SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE subscription_id = ? ORDER BY id DESC LIMIT ?", subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		err := rows.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.Attempt,
+			&delivery.StatusCode, &delivery.Error, &delivery.DurationMS, &delivery.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		delivery.Success = delivery.Error == ""
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (w *WebhooksClient) querySubscriptions(query string, args ...any) ([]models.WebhookSubscription, error) {
+	subscriptions := []models.WebhookSubscription{}
+
+	rows, err := w.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// scanSubscription scans a subscription selected with subscriptionColumns.
+func scanSubscription(row interface{ Scan(dest ...any) error }) (models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := row.Scan(&subscription.ID, &subscription.WorkspaceID, &subscription.URL, &subscription.AppID, &subscription.MaxRating,
+		&subscription.Keyword, &subscription.Active, &subscription.CreatedAt, &subscription.UpdatedAt)
+	return subscription, err
+}
+
+// checkSubscriptionUpdated returns ErrSubscriptionNotFound if no subscription was affected by the statement.
+func checkSubscriptionUpdated(res sql.Result, id int64) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrSubscriptionNotFound{ID: id}
+	}
+
+	return nil
+}
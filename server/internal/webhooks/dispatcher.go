@@ -0,0 +1,302 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/config"
+	"github.com/renantatsuo/app-review/server/internal/models"
+	"github.com/renantatsuo/app-review/server/internal/queue"
+)
+
+const (
+	// dequeueErrorBackoff is how long a worker waits before dequeuing again after an error.
+	dequeueErrorBackoff = time.Second
+	// outboxBatchSize is how many webhook outbox entries are read at once.
+	outboxBatchSize = 500
+)
+
+// Dispatcher enqueues the new reviews of the webhook outbox to the matching subscriptions and delivers them.
+// Failed deliveries are retried with backoff by the webhooks queue.
+type Dispatcher struct {
+	l              *slog.Logger
+	webhooksClient *WebhooksClient
+	queue          queue.Queue
+	config         config.Config
+	httpClient     *http.Client
+	wg             sync.WaitGroup
+}
+
+func NewDispatcher(l *slog.Logger, webhooksClient *WebhooksClient, queue queue.Queue, config config.Config) *Dispatcher {
+	return &Dispatcher{
+		l:              l,
+		webhooksClient: webhooksClient,
+		queue:          queue,
+		config:         config,
+		httpClient:     &http.Client{Timeout: config.WebhookTimeout},
+	}
+}
+
+// relay enqueues the webhook outbox every webhook outbox interval, until the context is done.
+func (d *Dispatcher) relay(ctx context.Context) {
+	ticker := time.NewTicker(d.config.WebhookOutboxInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.relayOutbox(); err != nil {
+				d.l.Error("error relaying webhook outbox", "error", err)
+			}
+		}
+	}
+}
+
+// relayOutbox enqueues the entries of the webhook outbox to the matching subscriptions, one event per app storefront,
+// and deletes them once enqueued. The entries of a storefront failing to be enqueued are kept and retried on the next relay,
+// so every review is enqueued at least once.
+func (d *Dispatcher) relayOutbox() error {
+	for {
+		entries, err := d.webhooksClient.GetOutbox(outboxBatchSize)
+		if err != nil {
+			return fmt.Errorf("error getting webhook outbox: %w", err)
+		}
+
+		var errs []error
+		for _, storefront := range groupByStorefront(entries) {
+			if err := d.notify(storefront); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			ids := make([]int64, len(storefront))
+			for i, entry := range storefront {
+				ids[i] = entry.ID
+			}
+			if err := d.webhooksClient.DeleteOutbox(ids); err != nil {
+				errs = append(errs, fmt.Errorf("error deleting webhook outbox: %w", err))
+			}
+		}
+
+		if len(errs) > 0 || len(entries) < outboxBatchSize {
+			return errors.Join(errs...)
+		}
+	}
+}
+
+// groupByStorefront groups the outbox entries by app and country storefront, keeping their order.
+func groupByStorefront(entries []OutboxEntry) [][]OutboxEntry {
+	groups := [][]OutboxEntry{}
+	index := map[[2]string]int{}
+
+	for _, entry := range entries {
+		key := [2]string{entry.Review.AppID, entry.Review.Country}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], entry)
+	}
+
+	return groups
+}
+
+// notify enqueues one event per active subscription of the workspaces watching the app storefront of the outbox entries, with the entries matching its filters.
+// The event ID is derived from the subscription and the entries, so an event enqueued again after a failure has the same ID.
+func (d *Dispatcher) notify(entries []OutboxEntry) error {
+	appID, country := entries[0].Review.AppID, entries[0].Review.Country
+
+	subscriptions, err := d.webhooksClient.GetActiveSubscriptionsByStorefront(appID, country)
+	if err != nil {
+		return fmt.Errorf("error getting webhook subscriptions: %w", err)
+	}
+
+	var errs []error
+	for _, subscription := range subscriptions {
+		matching := []models.Review{}
+		ids := []int64{}
+		for _, entry := range entries {
+			if subscription.Matches(entry.Review) {
+				matching = append(matching, entry.Review)
+				ids = append(ids, entry.ID)
+			}
+		}
+		if len(matching) == 0 {
+			continue
+		}
+
+		payload, err := json.Marshal(models.WebhookPayload{
+			Event:   models.WebhookEventReviewsCreated,
+			AppID:   appID,
+			Reviews: matching,
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		job := models.WebhookJob{EventID: eventID(subscription.ID, ids), SubscriptionID: subscription.ID, Payload: payload}
+		if err := d.queue.Enqueue(job.Encode()); err != nil {
+			errs = append(errs, fmt.Errorf("error enqueuing webhook for subscription %d: %w", subscription.ID, err))
+			continue
+		}
+
+		d.l.Debug("enqueued webhook", "subscriptionID", subscription.ID, "eventID", job.EventID, "reviews", len(matching))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Start starts the outbox relay and the delivery workers, they stop once the context is done.
+func (d *Dispatcher) Start(ctx context.Context) {
+	workers := max(d.config.WebhookWorkers, 1)
+	d.l.Info("starting webhook dispatcher", "workers", workers, "outboxInterval", d.config.WebhookOutboxInterval.String())
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.relay(ctx)
+	}()
+
+	for worker := range workers {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.work(ctx, d.l.With("webhookWorker", worker))
+		}()
+	}
+}
+
+// Wait blocks until the relay and every worker finished their in-flight work and stopped.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// work dequeues and delivers webhooks until the context is done.
+func (d *Dispatcher) work(ctx context.Context, l *slog.Logger) {
+	for {
+		msg, err := d.queue.Dequeue(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			l.Error("error dequeuing webhook", "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(dequeueErrorBackoff):
+			}
+			continue
+		}
+
+		job, err := models.DecodeWebhookJob(msg.Item)
+		if err != nil {
+			// a malformed job will never be delivered, drop it
+			l.Error("error decoding webhook job, dropping it", "item", string(msg.Item), "error", err)
+			if err := d.queue.Ack(msg.ID); err != nil {
+				l.Error("error acking webhook", "error", err)
+			}
+			continue
+		}
+
+		if err := d.deliver(job); err != nil {
+			l.Error("error delivering webhook, it will be retried", "subscriptionID", job.SubscriptionID, "eventID", job.EventID, "error", err)
+			if _, err := d.queue.Nack(msg.ID, err); err != nil {
+				l.Error("error nacking webhook", "subscriptionID", job.SubscriptionID, "eventID", job.EventID, "error", err)
+			}
+			continue
+		}
+
+		if err := d.queue.Ack(msg.ID); err != nil {
+			l.Error("error acking webhook", "subscriptionID", job.SubscriptionID, "eventID", job.EventID, "error", err)
+		}
+	}
+}
+
+// deliver posts the signed job payload to its subscription and logs the attempt.
+// Jobs of deleted or inactive subscriptions are dropped.
+func (d *Dispatcher) deliver(job models.WebhookJob) error {
+	subscription, err := d.webhooksClient.GetSubscription(job.SubscriptionID)
+	if err != nil {
+		if errors.As(err, &ErrSubscriptionNotFound{}) {
+			d.l.Info("webhook subscription was deleted, dropping event", "subscriptionID", job.SubscriptionID, "eventID", job.EventID)
+			return nil
+		}
+		return err
+	}
+
+	if !subscription.Active {
+		d.l.Info("webhook subscription is inactive, dropping event", "subscriptionID", job.SubscriptionID, "eventID", job.EventID)
+		return nil
+	}
+
+	secret, err := d.webhooksClient.GetSubscriptionSecret(job.SubscriptionID)
+	if err != nil {
+		return err
+	}
+
+	previous, err := d.webhooksClient.CountDeliveries(job.EventID)
+	if err != nil {
+		return err
+	}
+
+	delivery := models.WebhookDelivery{
+		SubscriptionID: job.SubscriptionID,
+		EventID:        job.EventID,
+		Attempt:        previous + 1,
+	}
+
+	start := time.Now()
+	delivery.StatusCode, err = d.post(subscription.URL, secret, job)
+	delivery.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	if logErr := d.webhooksClient.AddDelivery(delivery); logErr != nil {
+		d.l.Error("error logging webhook delivery", "subscriptionID", job.SubscriptionID, "eventID", job.EventID, "error", logErr)
+	}
+
+	return err
+}
+
+// post posts the signed payload and returns the response status code.
+// Any status code other than 2xx is an error.
+func (d *Dispatcher) post(url string, secret string, job models.WebhookJob) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(job.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, job.EventID)
+	req.Header.Set(HeaderTimestamp, fmt.Sprint(timestamp))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, job.Payload))
+
+	res, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
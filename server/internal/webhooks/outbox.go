@@ -0,0 +1,52 @@
+package webhooks
+
+import (
+	"strings"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+// OutboxEntry is a review created by the consumer, waiting in the webhook_outbox to be enqueued to the subscriptions.
+type OutboxEntry struct {
+	ID     int64
+	Review models.Review
+}
+
+// GetOutbox returns up to limit entries of the webhook outbox with their reviews, oldest first.
+func (w *WebhooksClient) GetOutbox(limit int) ([]OutboxEntry, error) {
+	entries := []OutboxEntry{}
+
+	rows, err := w.db.Query(`SELECT o.id, r.id, r.app_id, r.country, r.author, r.title, r.content, r.rating, r.sent_at, r.created_at, r.updated_at
+		FROM webhook_outbox o JOIN reviews r ON r.id = o.review_id ORDER BY o.id LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry OutboxEntry
+		err := rows.Scan(&entry.ID, &entry.Review.ID, &entry.Review.AppID, &entry.Review.Country, &entry.Review.Author, &entry.Review.Title,
+			&entry.Review.Content, &entry.Review.Rating, &entry.Review.SentAt, &entry.Review.CreatedAt, &entry.Review.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// DeleteOutbox deletes the given entries of the webhook outbox, once they are enqueued.
+func (w *WebhooksClient) DeleteOutbox(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	_, err := w.db.Exec("DELETE FROM webhook_outbox WHERE id IN (?"+strings.Repeat(", ?", len(ids)-1)+")", args...)
+	return err
+}
@@ -0,0 +1,48 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// HeaderEventID is the event ID, the same on every retry of the event.
+	HeaderEventID = "X-Webhook-Event-ID"
+	// HeaderTimestamp is the unix time the payload was signed at.
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature is the "sha256=" prefixed signature of the payload.
+	HeaderSignature = "X-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+// Sign returns the signature of the payload sent at the given unix timestamp:
+// the hex encoded HMAC-SHA256 of "{timestamp}.{body}" keyed with the subscription secret.
+// Signing the timestamp lets the subscriptions reject replayed payloads.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret generates a random subscription secret.
+func NewSecret() string {
+	return rand.Text()
+}
+
+// eventID returns the ID of the event of the given outbox entries to the subscription:
+// the first 16 bytes of the SHA-256 of the subscription and entries IDs, hex encoded.
+func eventID(subscriptionID int64, entryIDs []int64) string {
+	h := sha256.New()
+	fmt.Fprint(h, subscriptionID)
+	for _, id := range entryIDs {
+		fmt.Fprint(h, ",", id)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
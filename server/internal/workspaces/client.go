@@ -0,0 +1,19 @@
+package workspaces
+
+import (
+	"database/sql"
+	"log/slog"
+)
+
+// WorkspacesClient is the client for the workspaces sharing the deployment.
+type WorkspacesClient struct {
+	logger *slog.Logger
+	db     *sql.DB
+}
+
+func New(logger *slog.Logger, db *sql.DB) *WorkspacesClient {
+	return &WorkspacesClient{
+		logger: logger,
+		db:     db,
+	}
+}
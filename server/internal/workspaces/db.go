@@ -0,0 +1,149 @@
+package workspaces
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/internal/models"
+)
+
+const (
+	// workspaceColumns are the columns scanned by scanWorkspace.
+	workspaceColumns = "id, name, digest_slack_webhook_url, digest_email_to, incident_slack_webhook_url, incident_email_to, created_at"
+
+	// emailsSeparator separates the emails stored in the email_to columns.
+	emailsSeparator = ","
+)
+
+// ErrWorkspaceNameTaken is returned when another workspace already has the name.
+var ErrWorkspaceNameTaken = errors.New("workspace name is already taken")
+
+// ErrWorkspaceNotFound is an error type for when a workspace is not found.
+type ErrWorkspaceNotFound struct {
+	ID int64
+}
+
+func (e ErrWorkspaceNotFound) Error() string {
+	return fmt.Sprintf("workspace not found: %d", e.ID)
+}
+
+// CreateWorkspace creates an empty workspace.
+// It returns ErrWorkspaceNameTaken if another workspace has the name, ignoring the case.
+func (c *WorkspacesClient) CreateWorkspace(name string) (models.Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.Workspace{}, errors.New("workspace name is required")
+	}
+
+	res, err := c.db.Exec("INSERT INTO workspaces (name, created_at) VALUES (?, ?) ON CONFLICT (name) DO NOTHING", name, time.Now().UTC())
+	if err != nil {
+		return models.Workspace{}, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return models.Workspace{}, err
+	}
+	if affected == 0 {
+		return models.Workspace{}, ErrWorkspaceNameTaken
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return models.Workspace{}, err
+	}
+
+	return c.GetWorkspace(id)
+}
+
+// GetWorkspace returns the workspace.
+func (c *WorkspacesClient) GetWorkspace(id int64) (models.Workspace, error) {
+	workspace, err := scanWorkspace(c.db.QueryRow("SELECT "+workspaceColumns+" FROM workspaces WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Workspace{}, ErrWorkspaceNotFound{ID: id}
+	}
+	return workspace, err
+}
+
+// GetWorkspaces returns every workspace.
+func (c *WorkspacesClient) GetWorkspaces() ([]models.Workspace, error) {
+	return c.queryWorkspaces("SELECT " + workspaceColumns + " FROM workspaces ORDER BY id")
+}
+
+// GetWorkspacesWatching returns the workspaces that have the app active on the country storefront.
+func (c *WorkspacesClient) GetWorkspacesWatching(appID string, country string) ([]models.Workspace, error) {
+	// the countries of a workspace app are comma separated, they are wrapped in commas to match whole countries
+	return c.queryWorkspaces("SELECT "+workspaceColumns+" FROM workspaces WHERE id IN (SELECT workspace_id FROM workspace_apps"+
+		" WHERE app_id = ? AND status = ? AND instr(',' || countries || ',', ',' || ? || ',') > 0) ORDER BY id",
+		appID, models.AppStatusActive, country)
+}
+
+// SetNotifications sets where the digests of the workspace apps and their incidents are sent.
+func (c *WorkspacesClient) SetNotifications(id int64, digest models.NotificationTargets, incident models.NotificationTargets) error {
+	res, err := c.db.Exec(`UPDATE workspaces SET digest_slack_webhook_url = ?, digest_email_to = ?, incident_slack_webhook_url = ?, incident_email_to = ?
+		WHERE id = ?`,
+		digest.SlackWebhookURL, strings.Join(digest.EmailTo, emailsSeparator),
+		incident.SlackWebhookURL, strings.Join(incident.EmailTo, emailsSeparator), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrWorkspaceNotFound{ID: id}
+	}
+
+	return nil
+}
+
+// queryWorkspaces returns the workspaces selected with workspaceColumns.
+func (c *WorkspacesClient) queryWorkspaces(query string, args ...any) ([]models.Workspace, error) {
+	workspaces := []models.Workspace{}
+
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		workspace, err := scanWorkspace(rows)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, workspace)
+	}
+
+	return workspaces, rows.Err()
+}
+
+// scanWorkspace scans a row of workspaceColumns into a workspace.
+func scanWorkspace(row interface{ Scan(...any) error }) (models.Workspace, error) {
+	var workspace models.Workspace
+	var digestEmailTo, incidentEmailTo string
+
+	err := row.Scan(&workspace.ID, &workspace.Name, &workspace.Digest.SlackWebhookURL, &digestEmailTo,
+		&workspace.Incident.SlackWebhookURL, &incidentEmailTo, &workspace.CreatedAt)
+	if err != nil {
+		return models.Workspace{}, err
+	}
+
+	workspace.Digest.EmailTo = splitEmails(digestEmailTo)
+	workspace.Incident.EmailTo = splitEmails(incidentEmailTo)
+
+	return workspace, nil
+}
+
+// splitEmails splits the emails of an email_to column, none if it is empty.
+func splitEmails(emails string) []string {
+	if emails == "" {
+		return nil
+	}
+	return strings.Split(emails, emailsSeparator)
+}
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE apps ADD COLUMN countries TEXT NOT NULL DEFAULT 'us';
+ALTER TABLE reviews ADD COLUMN country TEXT NOT NULL DEFAULT 'us';
+CREATE INDEX idx_reviews_app_id_country_sent_at ON reviews (app_id, country, sent_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_reviews_app_id_country_sent_at;
+ALTER TABLE reviews DROP COLUMN country;
+ALTER TABLE apps DROP COLUMN countries;
+-- +goose StatementEnd
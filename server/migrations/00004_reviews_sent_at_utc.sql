@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+UPDATE reviews SET sent_at = strftime('%Y-%m-%d %H:%M:%S', sent_at) || '+00:00';
+CREATE INDEX idx_reviews_app_id_sent_at_id ON reviews (app_id, sent_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_reviews_app_id_sent_at_id;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- reviews has no INTEGER PRIMARY KEY, so its rowids can change on VACUUM,
+-- 00020_reviews_fts_seq.sql keys reviews_fts on an explicit column instead.
+CREATE VIRTUAL TABLE reviews_fts USING fts5(
+    title,
+    content,
+    content = 'reviews',
+    content_rowid = 'rowid',
+    tokenize = 'porter unicode61 remove_diacritics 2'
+);
+INSERT INTO reviews_fts (reviews_fts) VALUES ('rebuild');
+CREATE TRIGGER reviews_fts_insert AFTER INSERT ON reviews BEGIN
+    INSERT INTO reviews_fts (rowid, title, content) VALUES (new.rowid, new.title, new.content);
+END;
+CREATE TRIGGER reviews_fts_delete AFTER DELETE ON reviews BEGIN
+    INSERT INTO reviews_fts (reviews_fts, rowid, title, content) VALUES ('delete', old.rowid, old.title, old.content);
+END;
+CREATE TRIGGER reviews_fts_update AFTER UPDATE OF title, content ON reviews BEGIN
+    INSERT INTO reviews_fts (reviews_fts, rowid, title, content) VALUES ('delete', old.rowid, old.title, old.content);
+    INSERT INTO reviews_fts (rowid, title, content) VALUES (new.rowid, new.title, new.content);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TRIGGER reviews_fts_update;
+DROP TRIGGER reviews_fts_delete;
+DROP TRIGGER reviews_fts_insert;
+DROP TABLE reviews_fts;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE apps ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE apps DROP COLUMN status;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- polling_interval is in seconds, NULL uses the POLLING_INTERVAL default
+ALTER TABLE apps ADD COLUMN polling_interval INTEGER;
+ALTER TABLE apps ADD COLUMN adaptive_polling BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE apps ADD COLUMN next_poll_at TIMESTAMP;
+ALTER TABLE apps ADD COLUMN last_polled_at TIMESTAMP;
+CREATE INDEX idx_apps_status_next_poll_at ON apps (status, next_poll_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_apps_status_next_poll_at;
+ALTER TABLE apps DROP COLUMN last_polled_at;
+ALTER TABLE apps DROP COLUMN next_poll_at;
+ALTER TABLE apps DROP COLUMN adaptive_polling;
+ALTER TABLE apps DROP COLUMN polling_interval;
+-- +goose StatementEnd
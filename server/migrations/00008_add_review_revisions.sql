@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- review_revisions keeps the previous versions of the edited reviews
+CREATE TABLE review_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    review_id TEXT NOT NULL,
+    app_id TEXT NOT NULL,
+    author TEXT NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    rating INTEGER NOT NULL,
+    sent_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_review_revisions_review_id ON review_revisions (review_id, sent_at);
+CREATE INDEX idx_review_revisions_app_id ON review_revisions (app_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_review_revisions_app_id;
+DROP INDEX idx_review_revisions_review_id;
+DROP TABLE review_revisions;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE webhook_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    -- empty app_id matches every app, zero max_rating every rating and empty keyword every review
+    app_id TEXT NOT NULL DEFAULT '',
+    max_rating INTEGER NOT NULL DEFAULT 0,
+    keyword TEXT NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_webhook_subscriptions_app_id ON webhook_subscriptions (app_id);
+CREATE TABLE webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL,
+    event_id TEXT NOT NULL,
+    attempt INTEGER NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id, id);
+CREATE INDEX idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_webhook_deliveries_event_id;
+DROP INDEX idx_webhook_deliveries_subscription_id;
+DROP TABLE webhook_deliveries;
+DROP INDEX idx_webhook_subscriptions_app_id;
+DROP TABLE webhook_subscriptions;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- review_daily_stats counts the reviews of every app by UTC day they were sent and rating, and by country since 00022.
+-- It is maintained by the consumer when it stores the reviews.
+CREATE TABLE review_daily_stats (
+    app_id TEXT NOT NULL,
+    day TEXT NOT NULL,
+    rating INTEGER NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (app_id, day, rating)
+);
+INSERT INTO review_daily_stats (app_id, day, rating, count)
+SELECT app_id, date(sent_at), rating, COUNT(*) FROM reviews GROUP BY app_id, date(sent_at), rating;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE review_daily_stats;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE incidents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    -- keyword is only set for the keyword_surge incidents
+    keyword TEXT NOT NULL DEFAULT '',
+    message TEXT NOT NULL,
+    value REAL NOT NULL,
+    baseline REAL NOT NULL,
+    window_start TIMESTAMP NOT NULL,
+    window_end TIMESTAMP NOT NULL,
+    detected_at TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_incidents_app_id_detected_at ON incidents (app_id, detected_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_incidents_app_id_detected_at;
+DROP TABLE incidents;
+-- +goose StatementEnd
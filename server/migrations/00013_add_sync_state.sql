@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE sync_state (
+    app_id TEXT NOT NULL,
+    country TEXT NOT NULL,
+    -- high_water_mark is the sent_at of the latest review fetched,
+    -- last_review_ids the comma separated ids of the reviews sent at that time
+    high_water_mark TIMESTAMP,
+    last_review_ids TEXT NOT NULL DEFAULT '',
+    last_fetched_at TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    last_error_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (app_id, country)
+);
+INSERT INTO sync_state (app_id, country, high_water_mark, last_review_ids)
+SELECT r.app_id, r.country, r.sent_at, group_concat(r.id, ',')
+FROM reviews r
+WHERE r.sent_at = (SELECT MAX(sent_at) FROM reviews WHERE app_id = r.app_id AND country = r.country)
+GROUP BY r.app_id, r.country;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE sync_state;
+-- +goose StatementEnd
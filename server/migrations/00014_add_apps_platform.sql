@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE apps ADD COLUMN platform TEXT NOT NULL DEFAULT 'ios';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE apps DROP COLUMN platform;
+-- +goose StatementEnd
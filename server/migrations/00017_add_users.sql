@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    password_hash TEXT NOT NULL,
+    role TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP
+);
+CREATE TABLE sessions (
+    -- id is the SHA-256 of the session token of the cookie
+    id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users (id),
+    csrf_token TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX sessions_user_id ON sessions (user_id);
+-- who added the app and who last changed its status, a username or an api key name
+ALTER TABLE apps ADD COLUMN added_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE apps ADD COLUMN status_updated_by TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE apps DROP COLUMN status_updated_by;
+ALTER TABLE apps DROP COLUMN added_by;
+DROP TABLE sessions;
+DROP TABLE users;
+-- +goose StatementEnd
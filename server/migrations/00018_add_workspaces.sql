@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE TABLE workspaces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- the existing apps, users, keys and webhooks belong to the default workspace
+INSERT INTO workspaces (id, name) VALUES (1, 'default');
+-- the apps a workspace watches, with its own monitoring settings.
+-- apps keeps the store data and the polling schedule, shared by every workspace watching the app,
+-- so an app is fetched once whatever the number of workspaces watching it
+CREATE TABLE workspace_apps (
+    workspace_id INTEGER NOT NULL REFERENCES workspaces (id),
+    app_id TEXT NOT NULL REFERENCES apps (id),
+    countries TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'active',
+    -- polling_interval is in seconds, NULL uses the POLLING_INTERVAL default
+    polling_interval INTEGER,
+    adaptive_polling BOOLEAN NOT NULL DEFAULT FALSE,
+    added_by TEXT NOT NULL DEFAULT '',
+    status_updated_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, app_id)
+);
+CREATE INDEX idx_workspace_apps_app_id_status ON workspace_apps (app_id, status);
+INSERT INTO workspace_apps (workspace_id, app_id, countries, status, polling_interval, adaptive_polling, added_by, status_updated_by, created_at, updated_at)
+    SELECT 1, id, countries, status, polling_interval, adaptive_polling, added_by, status_updated_by, created_at, updated_at FROM apps;
+DROP INDEX idx_apps_status_next_poll_at;
+ALTER TABLE apps DROP COLUMN countries;
+ALTER TABLE apps DROP COLUMN status;
+ALTER TABLE apps DROP COLUMN polling_interval;
+ALTER TABLE apps DROP COLUMN adaptive_polling;
+ALTER TABLE apps DROP COLUMN added_by;
+ALTER TABLE apps DROP COLUMN status_updated_by;
+CREATE INDEX idx_apps_next_poll_at ON apps (next_poll_at);
+ALTER TABLE users ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE api_keys ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE webhook_subscriptions ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE webhook_subscriptions DROP COLUMN workspace_id;
+ALTER TABLE api_keys DROP COLUMN workspace_id;
+ALTER TABLE users DROP COLUMN workspace_id;
+DROP INDEX idx_apps_next_poll_at;
+ALTER TABLE apps ADD COLUMN countries TEXT NOT NULL DEFAULT 'us';
+ALTER TABLE apps ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE apps ADD COLUMN polling_interval INTEGER;
+ALTER TABLE apps ADD COLUMN adaptive_polling BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE apps ADD COLUMN added_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE apps ADD COLUMN status_updated_by TEXT NOT NULL DEFAULT '';
+-- an app watched by several workspaces keeps the settings of the oldest one
+UPDATE apps SET (countries, status, polling_interval, adaptive_polling, added_by, status_updated_by) = (
+    SELECT countries, status, polling_interval, adaptive_polling, added_by, status_updated_by
+    FROM workspace_apps WHERE app_id = apps.id ORDER BY workspace_id LIMIT 1
+) WHERE id IN (SELECT app_id FROM workspace_apps);
+CREATE INDEX idx_apps_status_next_poll_at ON apps (status, next_poll_at);
+DROP INDEX idx_workspace_apps_app_id_status;
+DROP TABLE workspace_apps;
+DROP TABLE workspaces;
+-- +goose StatementEnd
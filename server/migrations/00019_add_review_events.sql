@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- review_events is the sequence of the reviews stored by the consumer, the server tails it to stream them.
+-- AUTOINCREMENT never reuses the ids of the pruned events, so they can be resumed from
+CREATE TABLE review_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    review_id TEXT NOT NULL,
+    app_id TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_review_events_created_at ON review_events(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE review_events;
+-- +goose StatementEnd
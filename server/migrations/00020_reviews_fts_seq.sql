@@ -0,0 +1,92 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- reviews_fts was keyed on the implicit rowid of reviews, which VACUUM can renumber as reviews has a TEXT primary key.
+-- reviews is rebuilt with seq, an INTEGER PRIMARY KEY aliasing the rowid so VACUUM keeps it, and reviews_fts is keyed on it.
+DROP TRIGGER reviews_fts_update;
+DROP TRIGGER reviews_fts_delete;
+DROP TRIGGER reviews_fts_insert;
+DROP TABLE reviews_fts;
+CREATE TABLE reviews_new (
+    seq INTEGER PRIMARY KEY,
+    id TEXT NOT NULL UNIQUE,
+    app_id TEXT NOT NULL,
+    country TEXT NOT NULL DEFAULT 'us',
+    author TEXT NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    rating INTEGER NOT NULL,
+    sent_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO reviews_new (seq, id, app_id, country, author, title, content, rating, sent_at, created_at, updated_at)
+    SELECT rowid, id, app_id, country, author, title, content, rating, sent_at, created_at, updated_at FROM reviews;
+DROP TABLE reviews;
+ALTER TABLE reviews_new RENAME TO reviews;
+CREATE INDEX idx_reviews_app_id_country_sent_at ON reviews (app_id, country, sent_at);
+CREATE INDEX idx_reviews_app_id_sent_at_id ON reviews (app_id, sent_at, id);
+CREATE VIRTUAL TABLE reviews_fts USING fts5(
+    title,
+    content,
+    content = 'reviews',
+    content_rowid = 'seq',
+    tokenize = 'porter unicode61 remove_diacritics 2'
+);
+INSERT INTO reviews_fts (reviews_fts) VALUES ('rebuild');
+CREATE TRIGGER reviews_fts_insert AFTER INSERT ON reviews BEGIN
+    INSERT INTO reviews_fts (rowid, title, content) VALUES (new.seq, new.title, new.content);
+END;
+CREATE TRIGGER reviews_fts_delete AFTER DELETE ON reviews BEGIN
+    INSERT INTO reviews_fts (reviews_fts, rowid, title, content) VALUES ('delete', old.seq, old.title, old.content);
+END;
+CREATE TRIGGER reviews_fts_update AFTER UPDATE OF title, content ON reviews BEGIN
+    INSERT INTO reviews_fts (reviews_fts, rowid, title, content) VALUES ('delete', old.seq, old.title, old.content);
+    INSERT INTO reviews_fts (rowid, title, content) VALUES (new.seq, new.title, new.content);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TRIGGER reviews_fts_update;
+DROP TRIGGER reviews_fts_delete;
+DROP TRIGGER reviews_fts_insert;
+DROP TABLE reviews_fts;
+CREATE TABLE reviews_old (
+    id TEXT UNIQUE PRIMARY KEY,
+    app_id TEXT NOT NULL,
+    author TEXT NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    rating INTEGER NOT NULL,
+    sent_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    country TEXT NOT NULL DEFAULT 'us'
+);
+INSERT INTO reviews_old (id, app_id, author, title, content, rating, sent_at, created_at, updated_at, country)
+    SELECT id, app_id, author, title, content, rating, sent_at, created_at, updated_at, country FROM reviews;
+DROP TABLE reviews;
+ALTER TABLE reviews_old RENAME TO reviews;
+CREATE INDEX idx_reviews_app_id_country_sent_at ON reviews (app_id, country, sent_at);
+CREATE INDEX idx_reviews_app_id_sent_at_id ON reviews (app_id, sent_at, id);
+CREATE VIRTUAL TABLE reviews_fts USING fts5(
+    title,
+    content,
+    content = 'reviews',
+    content_rowid = 'rowid',
+    tokenize = 'porter unicode61 remove_diacritics 2'
+);
+INSERT INTO reviews_fts (reviews_fts) VALUES ('rebuild');
+CREATE TRIGGER reviews_fts_insert AFTER INSERT ON reviews BEGIN
+    INSERT INTO reviews_fts (rowid, title, content) VALUES (new.rowid, new.title, new.content);
+END;
+CREATE TRIGGER reviews_fts_delete AFTER DELETE ON reviews BEGIN
+    INSERT INTO reviews_fts (reviews_fts, rowid, title, content) VALUES ('delete', old.rowid, old.title, old.content);
+END;
+CREATE TRIGGER reviews_fts_update AFTER UPDATE OF title, content ON reviews BEGIN
+    INSERT INTO reviews_fts (reviews_fts, rowid, title, content) VALUES ('delete', old.rowid, old.title, old.content);
+    INSERT INTO reviews_fts (rowid, title, content) VALUES (new.rowid, new.title, new.content);
+END;
+-- +goose StatementEnd
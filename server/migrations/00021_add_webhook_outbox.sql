@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- webhook_outbox holds the reviews created by the consumer until they are enqueued to the webhook subscriptions.
+-- It is written in the transaction storing the review, so a stored review is notified even if its job fails afterwards
+CREATE TABLE webhook_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    review_id TEXT NOT NULL,
+    app_id TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_webhook_outbox_app_id ON webhook_outbox (app_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_webhook_outbox_app_id;
+DROP TABLE webhook_outbox;
+-- +goose StatementEnd
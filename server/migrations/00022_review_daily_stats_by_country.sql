@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- review_daily_stats also counts the reviews by country storefront,
+-- so the stats of a workspace only count the storefronts it watches.
+DROP TABLE review_daily_stats;
+CREATE TABLE review_daily_stats (
+    app_id TEXT NOT NULL,
+    country TEXT NOT NULL,
+    day TEXT NOT NULL,
+    rating INTEGER NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (app_id, country, day, rating)
+);
+INSERT INTO review_daily_stats (app_id, country, day, rating, count)
+SELECT app_id, country, date(sent_at), rating, COUNT(*) FROM reviews GROUP BY app_id, country, date(sent_at), rating;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE review_daily_stats;
+CREATE TABLE review_daily_stats (
+    app_id TEXT NOT NULL,
+    day TEXT NOT NULL,
+    rating INTEGER NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (app_id, day, rating)
+);
+INSERT INTO review_daily_stats (app_id, day, rating, count)
+SELECT app_id, date(sent_at), rating, COUNT(*) FROM reviews GROUP BY app_id, date(sent_at), rating;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- a fetch truncated by the source is resumed from resume_cursor by the next ones,
+-- down to resume_since, the high water mark it started from
+ALTER TABLE sync_state ADD COLUMN resume_since TIMESTAMP;
+ALTER TABLE sync_state ADD COLUMN resume_cursor TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE sync_state DROP COLUMN resume_cursor;
+ALTER TABLE sync_state DROP COLUMN resume_since;
+-- +goose StatementEnd
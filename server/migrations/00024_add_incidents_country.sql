@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- incidents are detected by country storefront, so a workspace only sees the incidents of the storefronts it watches.
+-- The incidents detected before over every storefront of an app have no country, and are not shown to any workspace
+ALTER TABLE incidents ADD COLUMN country TEXT NOT NULL DEFAULT '';
+DROP INDEX idx_incidents_app_id_detected_at;
+CREATE INDEX idx_incidents_app_id_country_detected_at ON incidents (app_id, country, detected_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_incidents_app_id_country_detected_at;
+CREATE INDEX idx_incidents_app_id_detected_at ON incidents (app_id, detected_at);
+ALTER TABLE incidents DROP COLUMN country;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- where the digests of the apps of a workspace and their incidents are sent,
+-- a Slack compatible incoming webhook and comma separated emails, empty ones are skipped
+ALTER TABLE workspaces ADD COLUMN digest_slack_webhook_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE workspaces ADD COLUMN digest_email_to TEXT NOT NULL DEFAULT '';
+ALTER TABLE workspaces ADD COLUMN incident_slack_webhook_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE workspaces ADD COLUMN incident_email_to TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE workspaces DROP COLUMN incident_email_to;
+ALTER TABLE workspaces DROP COLUMN incident_slack_webhook_url;
+ALTER TABLE workspaces DROP COLUMN digest_email_to;
+ALTER TABLE workspaces DROP COLUMN digest_slack_webhook_url;
+-- +goose StatementEnd
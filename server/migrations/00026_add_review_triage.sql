@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- the triage of the reviews by each workspace watching them, with who triaged them last and when
+CREATE TABLE review_triage (
+    workspace_id INTEGER NOT NULL REFERENCES workspaces (id),
+    review_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    triaged_by TEXT NOT NULL,
+    triaged_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, review_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE review_triage;
+-- +goose StatementEnd
@@ -0,0 +1,152 @@
+package apple
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/pkg/fixtures"
+)
+
+const testAppID = "1458862350"
+
+// newFixturesClient returns a client replaying the responses recorded in testdata,
+// set RECORD_FIXTURES to record them again from Apple.
+func newFixturesClient() *AppleClient {
+	transport := fixtures.New("testdata", fixtures.ModeFromEnv("RECORD_FIXTURES"))
+	return New(WithHTTPClient(&http.Client{Transport: transport}), WithCache(nil))
+}
+
+func TestGetAppData(t *testing.T) {
+	response, err := newFixturesClient().GetAppData(context.Background(), testAppID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.ResultCount != 1 || response.Results[0].TrackID != 1458862350 || response.Results[0].TrackName == "" {
+		t.Errorf("got %+v, want the app %s", response, testAppID)
+	}
+}
+
+func TestGetLatestReviewsFollowsTheNextPages(t *testing.T) {
+	ctx := context.Background()
+
+	reviews, err := newFixturesClient().GetLatestReviews(ctx, testAppID, DefaultCountry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews.Feed.Entry) == 0 || !reviews.HasNext() {
+		t.Fatalf("got %d reviews on the first page, want reviews and a next page", len(reviews.Feed.Entry))
+	}
+
+	next, err := reviews.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next.Feed.Entry) == 0 {
+		t.Fatal("got no reviews on the next page")
+	}
+	if reviews.Feed.Entry[0].ID.Label == next.Feed.Entry[0].ID.Label {
+		t.Errorf("got the first page again as the next page")
+	}
+}
+
+func TestGetReviewsPage(t *testing.T) {
+	reviews, err := newFixturesClient().GetReviewsPage(context.Background(), testAppID, DefaultCountry, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews.Feed.Entry) == 0 {
+		t.Fatal("got no reviews on page 2")
+	}
+
+	if _, err := newFixturesClient().GetReviewsPage(context.Background(), testAppID, DefaultCountry, MaxReviewsPages+1); err == nil {
+		t.Errorf("got no error for the page after the last one")
+	}
+}
+
+// statusTransport answers the requests with the status codes, one per request, then 200.
+type statusTransport struct {
+	statusCodes []int
+	retryAfter  string
+	requests    int
+}
+
+func (s *statusTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	statusCode := http.StatusOK
+	if s.requests < len(s.statusCodes) {
+		statusCode = s.statusCodes[s.requests]
+	}
+	s.requests++
+
+	header := http.Header{}
+	if s.retryAfter != "" {
+		header.Set("Retry-After", s.retryAfter)
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(`{"resultCount":0,"results":[]}`)),
+		Request:    request,
+	}, nil
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		statusCodes []int
+		retryAfter  string
+		requests    int
+		statusCode  int
+	}{
+		{"transient errors", []int{503, 500, 429}, "", 4, 0},
+		{"too many transient errors", []int{503, 503, 503, 503}, "", 4, 503},
+		{"not found", []int{404}, "", 1, 404},
+		{"retry after too long", []int{429}, "3600", 1, 429},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &statusTransport{statusCodes: tt.statusCodes, retryAfter: tt.retryAfter}
+			client := New(WithHTTPClient(&http.Client{Transport: transport}), WithRetries(3, time.Millisecond, time.Second))
+
+			_, err := client.GetAppData(context.Background(), testAppID)
+
+			var httpErr *HTTPError
+			switch {
+			case tt.statusCode == 0 && err != nil:
+				t.Errorf("got error %v, want none", err)
+			case tt.statusCode != 0 && (!errors.As(err, &httpErr) || httpErr.StatusCode != tt.statusCode):
+				t.Errorf("got error %v, want a %d HTTPError", err, tt.statusCode)
+			}
+			if transport.requests != tt.requests {
+				t.Errorf("got %d requests, want %d", transport.requests, tt.requests)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 18, 6, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"Sun, 18 Oct 2026 06:00:30 GMT", 30 * time.Second},
+		{"Sun, 18 Oct 2026 05:00:00 GMT", 0},
+		{"soon", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
@@ -1,16 +1,27 @@
 package apple
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"net/url"
 )
 
-const AppleAppsURLFmt = "https://itunes.apple.com/lookup?id=%s"
+const (
+	AppleAppsURLFmt = "https://itunes.apple.com/lookup?id=%s"
+	// AppleAppsByBundleIDURLFmt is the lookup of an app by its bundle ID, like com.example.app.
+	AppleAppsByBundleIDURLFmt = "https://itunes.apple.com/lookup?bundleId=%s"
+)
 
 type App struct {
-	TrackID       int    `json:"trackId"`
-	TrackName     string `json:"trackName"`
-	ArtworkURL512 string `json:"artworkUrl512"`
+	TrackID           int     `json:"trackId"`
+	TrackName         string  `json:"trackName"`
+	TrackViewURL      string  `json:"trackViewUrl"`
+	BundleID          string  `json:"bundleId"`
+	ArtistName        string  `json:"artistName"`
+	ArtworkURL100     string  `json:"artworkUrl100"`
+	ArtworkURL512     string  `json:"artworkUrl512"`
+	AverageUserRating float64 `json:"averageUserRating"`
+	UserRatingCount   int     `json:"userRatingCount"`
 }
 
 type AppsResponse struct {
@@ -19,17 +30,23 @@ type AppsResponse struct {
 }
 
 // GetAppData returns the app data for a given app ID.
-func (c *AppleClient) GetAppData(appID string) (AppsResponse, error) {
+func (c *AppleClient) GetAppData(ctx context.Context, appID string) (AppsResponse, error) {
 	url := fmt.Sprintf(AppleAppsURLFmt, appID)
 
-	response, err := c.httpClient.Get(url)
-	if err != nil {
+	var appsResponse AppsResponse
+	if err := c.getJSON(ctx, url, &appsResponse); err != nil {
 		return AppsResponse{}, err
 	}
-	defer response.Body.Close()
+
+	return appsResponse, nil
+}
+
+// GetAppDataByBundleID returns the app data for a given bundle ID.
+func (c *AppleClient) GetAppDataByBundleID(ctx context.Context, bundleID string) (AppsResponse, error) {
+	url := fmt.Sprintf(AppleAppsByBundleIDURLFmt, url.QueryEscape(bundleID))
 
 	var appsResponse AppsResponse
-	if err := json.NewDecoder(response.Body).Decode(&appsResponse); err != nil {
+	if err := c.getJSON(ctx, url, &appsResponse); err != nil {
 		return AppsResponse{}, err
 	}
 
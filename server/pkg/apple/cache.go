@@ -0,0 +1,61 @@
+package apple
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Validators are the validators of a response, sent back in the conditional requests of its URL
+// so Apple answers 304 Not Modified when the response did not change.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// validatorsFromResponse returns the validators of the response, empty if it has none.
+func validatorsFromResponse(response *http.Response) Validators {
+	return Validators{
+		ETag:         response.Header.Get("ETag"),
+		LastModified: response.Header.Get("Last-Modified"),
+	}
+}
+
+// IsZero returns true if there are no validators.
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// Cache stores the validators of the responses by URL.
+type Cache interface {
+	// Get returns the validators of the URL, false if there are none.
+	Get(ctx context.Context, url string) (Validators, bool, error)
+	// Set stores the validators of the URL.
+	Set(ctx context.Context, url string, validators Validators) error
+}
+
+// MemoryCache is a Cache keeping the validators in memory, they are lost when the process stops.
+type MemoryCache struct {
+	mu         sync.Mutex
+	validators map[string]Validators
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{validators: map[string]Validators{}}
+}
+
+func (c *MemoryCache) Get(ctx context.Context, url string) (Validators, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	validators, ok := c.validators[url]
+	return validators, ok, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, url string, validators Validators) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.validators[url] = validators
+	return nil
+}
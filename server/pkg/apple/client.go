@@ -5,8 +5,25 @@ import (
 	"time"
 )
 
+const (
+	// DefaultRateLimit is the default number of requests per second of a client.
+	DefaultRateLimit = 5
+	// DefaultMaxRetries is the default number of retries of a request that failed with a transient error.
+	DefaultMaxRetries = 3
+	// DefaultRetryBackoff is the default backoff of the first retry, it doubles on every retry.
+	DefaultRetryBackoff = 500 * time.Millisecond
+	// DefaultMaxRetryWait is the default longest wait before a retry.
+	// A Retry-After longer than it fails the request instead of blocking the caller.
+	DefaultMaxRetryWait = 30 * time.Second
+)
+
 type AppleClient struct {
-	httpClient *http.Client
+	httpClient   *http.Client
+	limiter      *rateLimiter
+	maxRetries   int
+	retryBackoff time.Duration
+	maxRetryWait time.Duration
+	cache        Cache
 }
 
 type Option func(*AppleClient)
@@ -17,11 +34,42 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithRateLimit limits the requests of the client to requestsPerSecond,
+// allowing bursts of up to burst requests. A zero rate disables the limit.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *AppleClient) {
+		c.limiter = newRateLimiter(requestsPerSecond, burst)
+	}
+}
+
+// WithRetries sets how many times a request that failed with a transient error is retried,
+// with a jittered exponential backoff starting at backoff and waiting at most maxWait.
+func WithRetries(maxRetries int, backoff time.Duration, maxWait time.Duration) Option {
+	return func(c *AppleClient) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+		c.maxRetryWait = maxWait
+	}
+}
+
+// WithCache sets the cache of the validators of the latest reviews responses,
+// shared by the clients of every service when it is persistent. A nil cache disables the conditional requests.
+func WithCache(cache Cache) Option {
+	return func(c *AppleClient) {
+		c.cache = cache
+	}
+}
+
 func New(opts ...Option) *AppleClient {
 	client := &AppleClient{
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		limiter:      newRateLimiter(DefaultRateLimit, DefaultRateLimit),
+		maxRetries:   DefaultMaxRetries,
+		retryBackoff: DefaultRetryBackoff,
+		maxRetryWait: DefaultMaxRetryWait,
+		cache:        NewMemoryCache(),
 	}
 
 	for _, opt := range opts {
@@ -0,0 +1,57 @@
+package apple
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPError is returned when Apple answers a request with a non 2xx status code.
+type HTTPError struct {
+	StatusCode int
+	URL        string
+	// RetryAfter is the wait asked by the Retry-After header, zero if there is none.
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("apple: %s returned %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Temporary returns true if the request may succeed when retried:
+// when it was rate limited or the server is unavailable.
+func (e *HTTPError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// newHTTPError creates the error of the response.
+func newHTTPError(response *http.Response) *HTTPError {
+	return &HTTPError{
+		StatusCode: response.StatusCode,
+		URL:        response.Request.URL.String(),
+		RetryAfter: parseRetryAfter(response.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, either a number of seconds or a HTTP date.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0)
+	}
+
+	return 0
+}
@@ -0,0 +1,144 @@
+package apple
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// getJSON gets the URL and decodes its JSON response into v.
+func (c *AppleClient) getJSON(ctx context.Context, rawURL string, v any) error {
+	response, err := c.get(ctx, rawURL, Validators{})
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+// getConditionalJSON gets the URL with the cached validators of its previous response and decodes its JSON response into v.
+// It returns false without decoding anything if the response was not modified since.
+// The validators of the response are returned rather than cached, the caller saves them once it handled the response,
+// so a response it failed to handle is requested in full again.
+func (c *AppleClient) getConditionalJSON(ctx context.Context, rawURL string, v any) (bool, Validators, error) {
+	if c.cache == nil {
+		return true, Validators{}, c.getJSON(ctx, rawURL, v)
+	}
+
+	// the cache only saves requests, without validators the request is not conditional
+	validators, _, _ := c.cache.Get(ctx, rawURL)
+
+	response, err := c.get(ctx, rawURL, validators)
+	if err != nil {
+		return false, Validators{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified {
+		return false, validators, nil
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+		return false, Validators{}, err
+	}
+
+	return true, validatorsFromResponse(response), nil
+}
+
+// get gets the URL through the rate limiter, retrying the transient errors.
+// The request is conditional if there are validators, a 304 response is then returned as is.
+// It returns a *HTTPError if the response status code is not 2xx.
+func (c *AppleClient) get(ctx context.Context, rawURL string, validators Validators) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		response, err := c.try(ctx, rawURL, validators)
+		if err == nil {
+			return response, nil
+		}
+
+		if attempt >= c.maxRetries || !retryable(ctx, err) {
+			return nil, err
+		}
+
+		wait := c.backoff(attempt)
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+			wait = httpErr.RetryAfter
+		}
+		if wait > c.maxRetryWait {
+			return nil, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// try makes a single request.
+func (c *AppleClient) try(ctx context.Context, rawURL string, validators Validators) (*http.Response, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if validators.ETag != "" {
+		request.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		request.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusNotModified && !validators.IsZero() {
+		return response, nil
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		// drained so the connection can be reused
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+		return nil, newHTTPError(response)
+	}
+
+	return response, nil
+}
+
+// backoff returns the jittered exponential backoff before the retry of the attempt,
+// between half and all of retryBackoff * 2^attempt.
+func (c *AppleClient) backoff(attempt int) time.Duration {
+	backoff := min(c.retryBackoff<<attempt, c.maxRetryWait)
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// retryable returns true if the request failed with a transient error:
+// a temporary HTTP error or a network error, unless the context is done.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Temporary()
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
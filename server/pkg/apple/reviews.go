@@ -1,15 +1,21 @@
 package apple
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 	"strings"
 )
 
 const (
-	AppleRSSURLFmt  = "https://itunes.apple.com/us/rss/customerreviews/id=%s/sortBy=mostRecent/json"
-	AppleTimeFormat = "2006-01-02T15:04:05-07:00"
+	AppleRSSURLFmt     = "https://itunes.apple.com/%s/rss/customerreviews/id=%s/sortBy=mostRecent/json"
+	AppleRSSPageURLFmt = "https://itunes.apple.com/%s/rss/customerreviews/page=%d/id=%s/sortBy=mostRecent/json"
+	AppleTimeFormat    = "2006-01-02T15:04:05-07:00"
+
+	// MaxReviewsPages is the number of pages of the reviews feed, older reviews are not available.
+	MaxReviewsPages = 10
+
+	// DefaultCountry is the storefront used when none is given.
+	DefaultCountry = "us"
 )
 
 var ErrNoNextPage = fmt.Errorf("no next page")
@@ -19,7 +25,13 @@ type ReviewsResponse[T any] struct {
 		Entry []T          `json:"entry"`
 		Link  []ReviewLink `json:"link"`
 	} `json:"feed"`
-	httpClient *http.Client `json:"-"`
+	// NotModified is true if the reviews did not change since the previous request of the URL,
+	// the response then has no reviews.
+	NotModified bool         `json:"-"`
+	client      *AppleClient `json:"-"`
+	// url and validators are the URL of a conditional response and its validators, see SaveValidators.
+	url        string     `json:"-"`
+	validators Validators `json:"-"`
 }
 
 type Review struct {
@@ -57,26 +69,63 @@ type ReviewLink struct {
 	} `json:"attributes"`
 }
 
-func getAppleRSSURL(appID string) string {
-	return fmt.Sprintf(AppleRSSURLFmt, appID)
+func getAppleRSSURL(appID string, country string) string {
+	return fmt.Sprintf(AppleRSSURLFmt, country, appID)
 }
 
-// GetLatestReviews returns the latest reviews for a given app ID
-func (c *AppleClient) GetLatestReviews(appID string) (ReviewsResponse[Review], error) {
-	url := getAppleRSSURL(appID)
+// GetLatestReviews returns the latest reviews for a given app ID on the given country storefront.
+// The request is conditional on the validators of the previous response in the cache of the client,
+// so the response is NotModified, without reviews, if no review was sent or edited since.
+// The validators of the response are only cached by SaveValidators.
+func (c *AppleClient) GetLatestReviews(ctx context.Context, appID string, country string) (ReviewsResponse[Review], error) {
+	url := getAppleRSSURL(appID, country)
 
-	response, err := c.httpClient.Get(url)
+	var reviewsResponse ReviewsResponse[Review]
+	modified, validators, err := c.getConditionalJSON(ctx, url, &reviewsResponse)
 	if err != nil {
 		return ReviewsResponse[Review]{}, err
 	}
-	defer response.Body.Close()
 
-	var reviewsResponse ReviewsResponse[Review]
-	if err := json.NewDecoder(response.Body).Decode(&reviewsResponse); err != nil {
-		return ReviewsResponse[Review]{}, err
+	reviewsResponse.NotModified = !modified
+	reviewsResponse.client = c
+	if modified {
+		reviewsResponse.url = url
+		reviewsResponse.validators = validators
+	}
+
+	return reviewsResponse, nil
+}
+
+// SaveValidators caches the validators of a GetLatestReviews response, so the next request of the feed
+// is conditional on them. It is called once the reviews of the response and its next pages are persisted:
+// saved any earlier, the retry of a failed sync would be answered 304 Not Modified and miss those reviews.
+// It does nothing for the other responses.
+func (r *ReviewsResponse[T]) SaveValidators(ctx context.Context) error {
+	if r.url == "" || r.client == nil || r.client.cache == nil {
+		return nil
+	}
+
+	return r.client.cache.Set(ctx, r.url, r.validators)
+}
+
+// GetReviewsPage returns the given page of the latest reviews, from 1 to MaxReviewsPages,
+// for a given app ID on the given country storefront.
+func (c *AppleClient) GetReviewsPage(ctx context.Context, appID string, country string, page int) (ReviewsResponse[Review], error) {
+	if page < 1 || page > MaxReviewsPages {
+		return ReviewsResponse[Review]{}, fmt.Errorf("page must be between 1 and %d", MaxReviewsPages)
 	}
 
-	reviewsResponse.httpClient = c.httpClient
+	return getReviews[Review](ctx, c, fmt.Sprintf(AppleRSSPageURLFmt, country, page, appID))
+}
+
+// getReviews gets a page of the reviews feed, keeping the client to get the next pages.
+func getReviews[T any](ctx context.Context, c *AppleClient, url string) (ReviewsResponse[T], error) {
+	var reviewsResponse ReviewsResponse[T]
+	if err := c.getJSON(ctx, url, &reviewsResponse); err != nil {
+		return ReviewsResponse[T]{}, err
+	}
+
+	reviewsResponse.client = c
 
 	return reviewsResponse, nil
 }
@@ -97,7 +146,7 @@ func (r *ReviewsResponse[Review]) HasNext() bool {
 }
 
 // Next returns the next page of reviews.
-func (r *ReviewsResponse[Review]) Next() (ReviewsResponse[Review], error) {
+func (r *ReviewsResponse[Review]) Next(ctx context.Context) (ReviewsResponse[Review], error) {
 	if !r.HasNext() {
 		return ReviewsResponse[Review]{}, ErrNoNextPage
 	}
@@ -115,20 +164,7 @@ func (r *ReviewsResponse[Review]) Next() (ReviewsResponse[Review], error) {
 		return ReviewsResponse[Review]{}, ErrNoNextPage
 	}
 
-	response, err := r.httpClient.Get(nextPageURL)
-	if err != nil {
-		return ReviewsResponse[Review]{}, err
-	}
-	defer response.Body.Close()
-
-	var nextPageResponse ReviewsResponse[Review]
-	if err := json.NewDecoder(response.Body).Decode(&nextPageResponse); err != nil {
-		return ReviewsResponse[Review]{}, err
-	}
-
-	nextPageResponse.httpClient = r.httpClient
-
-	return nextPageResponse, nil
+	return getReviews[Review](ctx, r.client, nextPageURL)
 }
 
 // fixNextPageURL fixes the next page URL
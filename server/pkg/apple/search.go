@@ -0,0 +1,32 @@
+package apple
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+const (
+	AppleSearchURLFmt = "https://itunes.apple.com/search?term=%s&entity=software&country=%s&limit=%d"
+	// MaxSearchLimit is the most results a search returns.
+	MaxSearchLimit = 200
+)
+
+type SearchResponse struct {
+	ResultCount int   `json:"resultCount"`
+	Results     []App `json:"results"`
+}
+
+// SearchApps returns the apps of the country storefront matching the term, most relevant first.
+// The limit is capped to MaxSearchLimit.
+func (c *AppleClient) SearchApps(ctx context.Context, term string, country string, limit int) (SearchResponse, error) {
+	limit = min(limit, MaxSearchLimit)
+	url := fmt.Sprintf(AppleSearchURLFmt, url.QueryEscape(term), country, limit)
+
+	var searchResponse SearchResponse
+	if err := c.getJSON(ctx, url, &searchResponse); err != nil {
+		return SearchResponse{}, err
+	}
+
+	return searchResponse, nil
+}
@@ -0,0 +1,203 @@
+// Package fixtures records HTTP responses to disk and replays them,
+// so the clients of the stores can be exercised without network access.
+package fixtures
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Mode is whether a Transport records or replays the responses.
+type Mode int
+
+const (
+	// ModeReplay answers the requests with the recorded responses, without network access.
+	ModeReplay Mode = iota
+	// ModeRecord makes the requests and records their responses, overwriting the previous ones.
+	ModeRecord
+)
+
+var ErrFixtureNotFound = errors.New("fixture not found")
+
+// maxNameLength is the length of a fixture file name, before the hash making it unique.
+const maxNameLength = 120
+
+// Fixture is a recorded response, stored as JSON.
+type Fixture struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	RequestBody string      `json:"request_body,omitempty"`
+	StatusCode  int         `json:"status_code"`
+	Header      http.Header `json:"header"`
+	Body        string      `json:"body"`
+}
+
+// Transport is a http.RoundTripper recording the responses to a directory, one file per method, URL and request body,
+// or replaying them from it.
+type Transport struct {
+	dir  string
+	mode Mode
+	next http.RoundTripper
+}
+
+type Option func(*Transport)
+
+// WithTransport sets the transport making the requests that are recorded, http.DefaultTransport by default.
+func WithTransport(next http.RoundTripper) Option {
+	return func(t *Transport) {
+		t.next = next
+	}
+}
+
+func New(dir string, mode Mode, opts ...Option) *Transport {
+	t := &Transport{
+		dir:  dir,
+		mode: mode,
+		next: http.DefaultTransport,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// ModeFromEnv returns ModeRecord if the environment variable is set, ModeReplay otherwise.
+func ModeFromEnv(name string) Mode {
+	if os.Getenv(name) != "" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+func (t *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if t.mode == ModeRecord {
+		return t.record(request)
+	}
+	return t.replay(request)
+}
+
+// record makes the request and stores its response.
+func (t *Transport) record(request *http.Request) (*http.Response, error) {
+	requestBody, err := readBody(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := Fixture{
+		Method:      request.Method,
+		URL:         request.URL.String(),
+		RequestBody: string(requestBody),
+		StatusCode:  response.StatusCode,
+		Header:      response.Header,
+		Body:        string(body),
+	}
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(t.path(request, requestBody), append(data, '\n'), 0o644); err != nil {
+		return nil, err
+	}
+
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	return response, nil
+}
+
+// replay returns the recorded response of the request.
+func (t *Transport) replay(request *http.Request) (*http.Response, error) {
+	requestBody, err := readBody(request)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(t.path(request, requestBody))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %s", ErrFixtureNotFound, request.Method, request.URL)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("error decoding fixture of %s %s: %w", request.Method, request.URL, err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.StatusCode, http.StatusText(fixture.StatusCode)),
+		StatusCode:    fixture.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        fixture.Header,
+		Body:          io.NopCloser(strings.NewReader(fixture.Body)),
+		ContentLength: int64(len(fixture.Body)),
+		Request:       request,
+	}, nil
+}
+
+// readBody reads the body of the request and restores it, so the request can still be sent.
+func readBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading the body of %s %s: %w", request.Method, request.URL, err)
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// path returns the fixture file of the request, a readable name of its method and URL
+// followed by a hash of them and of its body, so long URLs sharing a prefix and requests to the same URL
+// with different bodies do not collide. The requests without a body are hashed by their method and URL only.
+func (t *Transport) path(request *http.Request, body []byte) string {
+	key := request.Method + " " + request.URL.String()
+	if len(body) > 0 {
+		key += "\n" + string(body)
+	}
+	hash := sha256.Sum256([]byte(key))
+
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '=':
+			return r
+		}
+		return '_'
+	}, request.Method+"_"+request.URL.Host+request.URL.RequestURI())
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+
+	return filepath.Join(t.dir, name+"-"+hex.EncodeToString(hash[:4])+".json")
+}
@@ -0,0 +1,117 @@
+package fixtures
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, client *http.Client, url string) (int, string) {
+	t.Helper()
+
+	response, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("error getting %s: %v", url, err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("error reading the body of %s: %v", url, err)
+	}
+	return response.StatusCode, string(body)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "body of "+r.URL.RequestURI())
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	recorder := &http.Client{Transport: New(dir, ModeRecord)}
+	for _, path := range []string{"/feed?page=1", "/feed?page=2", "/missing"} {
+		get(t, recorder, server.URL+path)
+	}
+
+	server.Close()
+
+	replayer := &http.Client{Transport: New(dir, ModeReplay)}
+	tests := []struct {
+		path       string
+		statusCode int
+		body       string
+	}{
+		{"/feed?page=1", http.StatusOK, "body of /feed?page=1"},
+		{"/feed?page=2", http.StatusOK, "body of /feed?page=2"},
+		{"/missing", http.StatusNotFound, "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		statusCode, body := get(t, replayer, server.URL+tt.path)
+		if statusCode != tt.statusCode || body != tt.body {
+			t.Errorf("replayed %s as %d %q, want %d %q", tt.path, statusCode, body, tt.statusCode, tt.body)
+		}
+	}
+
+	if requests != 3 {
+		t.Errorf("got %d requests to the server, want only the 3 recorded", requests)
+	}
+}
+
+func TestReplayWithoutFixture(t *testing.T) {
+	client := &http.Client{Transport: New(t.TempDir(), ModeReplay)}
+
+	_, err := client.Get("https://itunes.apple.com/lookup?id=1")
+	if !errors.Is(err, ErrFixtureNotFound) {
+		t.Errorf("got error %v, want ErrFixtureNotFound", err)
+	}
+}
+
+func TestRecordAndReplayRequestBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		io.WriteString(w, "body of "+string(body))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	post := func(client *http.Client, body string) string {
+		t.Helper()
+
+		response, err := client.Post(server.URL+"/rpc", "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("error posting %q: %v", body, err)
+		}
+		defer response.Body.Close()
+
+		res, _ := io.ReadAll(response.Body)
+		return string(res)
+	}
+
+	recorder := &http.Client{Transport: New(dir, ModeRecord)}
+	for _, body := range []string{"page=1", "page=2"} {
+		if got := post(recorder, body); got != "body of "+body {
+			t.Errorf("recorded %q as %q, want the body sent to the server", body, got)
+		}
+	}
+
+	server.Close()
+
+	replayer := &http.Client{Transport: New(dir, ModeReplay)}
+	for _, body := range []string{"page=1", "page=2"} {
+		if got := post(replayer, body); got != "body of "+body {
+			t.Errorf("replayed %q as %q, want %q", body, got, "body of "+body)
+		}
+	}
+}
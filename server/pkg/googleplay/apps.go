@@ -0,0 +1,85 @@
+package googleplay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	GooglePlayAppURLFmt = "https://play.google.com/store/apps/details?id=%s&hl=%s&gl=%s"
+
+	// DefaultLanguage is the language of the app pages and reviews.
+	DefaultLanguage = "en"
+
+	// titleSuffix is appended by the store to the title of the app pages.
+	titleSuffix = " - Apps on Google Play"
+)
+
+// ErrAppNotFound is returned when the store has no app with the given package name.
+var ErrAppNotFound = errors.New("app not found")
+
+var (
+	ogTitleRegexp = regexp.MustCompile(`<meta[^>]+property="og:title"[^>]+content="([^"]*)"`)
+	ogImageRegexp = regexp.MustCompile(`<meta[^>]+property="og:image"[^>]+content="([^"]*)"`)
+)
+
+type App struct {
+	PackageName string
+	Title       string
+	IconURL     string
+}
+
+// GetAppData returns the app data for a given package name, from its store page on the given country storefront.
+func (c *GooglePlayClient) GetAppData(ctx context.Context, packageName string, country string) (App, error) {
+	pageURL := fmt.Sprintf(GooglePlayAppURLFmt, url.QueryEscape(packageName), DefaultLanguage, country)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return App{}, err
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return App{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return App{}, ErrAppNotFound
+	}
+	if response.StatusCode != http.StatusOK {
+		return App{}, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	page, err := io.ReadAll(response.Body)
+	if err != nil {
+		return App{}, err
+	}
+
+	return parseAppPage(packageName, page)
+}
+
+// parseAppPage parses the app data from the Open Graph tags of its store page.
+func parseAppPage(packageName string, page []byte) (App, error) {
+	title := ogTitleRegexp.FindSubmatch(page)
+	if title == nil {
+		return App{}, ErrAppNotFound
+	}
+
+	app := App{
+		PackageName: packageName,
+		Title:       strings.TrimSuffix(html.UnescapeString(string(title[1])), titleSuffix),
+	}
+	if image := ogImageRegexp.FindSubmatch(page); image != nil {
+		app.IconURL = html.UnescapeString(string(image[1]))
+	}
+
+	return app, nil
+}
@@ -0,0 +1,32 @@
+package googleplay
+
+import (
+	"net/http"
+	"time"
+)
+
+type GooglePlayClient struct {
+	httpClient *http.Client
+}
+
+type Option func(*GooglePlayClient)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *GooglePlayClient) {
+		c.httpClient = httpClient
+	}
+}
+
+func New(opts ...Option) *GooglePlayClient {
+	client := &GooglePlayClient{
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client
+}
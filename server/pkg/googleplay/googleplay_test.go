@@ -0,0 +1,172 @@
+package googleplay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/renantatsuo/app-review/server/pkg/fixtures"
+)
+
+const testPackageName = "com.example.companion"
+
+// newFixturesClient returns a client replaying the responses recorded in testdata, in the format of the store
+// for example packages. Set RECORD_FIXTURES to record them from Google Play, after changing the packages to published apps.
+func newFixturesClient() *GooglePlayClient {
+	transport := fixtures.New("testdata", fixtures.ModeFromEnv("RECORD_FIXTURES"))
+	return New(WithHTTPClient(&http.Client{Transport: transport}))
+}
+
+func TestGetAppData(t *testing.T) {
+	app, err := newFixturesClient().GetAppData(context.Background(), testPackageName, "us")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := App{
+		PackageName: testPackageName,
+		Title:       "Dungeons & Dragons Companion",
+		IconURL:     "https://play-lh.googleusercontent.com/Zu4x1tUcZ2rkpHPBRbFeQXPa5EtlBNhY8oFyyyKi2ZFQ3_uSAxpBl3MyDmvmDzTSsg=w526-h296-rw",
+	}
+	if app != want {
+		t.Errorf("got %+v, want %+v", app, want)
+	}
+
+	if _, err := newFixturesClient().GetAppData(context.Background(), "com.example.missing", "us"); !errors.Is(err, ErrAppNotFound) {
+		t.Errorf("got error %v for a missing app, want ErrAppNotFound", err)
+	}
+}
+
+func TestParseAppPage(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want App
+		err  error
+	}{
+		{
+			name: "title and image",
+			page: `<meta property="og:title" content="Notes &amp; Lists - Apps on Google Play"><meta property="og:image" content="https://example.com/icon.png?w=1&amp;h=1">`,
+			want: App{PackageName: testPackageName, Title: "Notes & Lists", IconURL: "https://example.com/icon.png?w=1&h=1"},
+		},
+		{
+			name: "attributes in another order",
+			page: `<meta data-x="1" property="og:title" itemprop="name" content="Notes">`,
+			want: App{PackageName: testPackageName, Title: "Notes"},
+		},
+		{
+			name: "no title",
+			page: `<meta property="og:image" content="https://example.com/icon.png">`,
+			err:  ErrAppNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, err := parseAppPage(testPackageName, []byte(tt.page))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if app != tt.want {
+				t.Errorf("got %+v, want %+v", app, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetLatestReviews(t *testing.T) {
+	page, err := newFixturesClient().GetLatestReviews(context.Background(), testPackageName, "us", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page.Reviews) != 3 {
+		t.Fatalf("got %d reviews, want 3", len(page.Reviews))
+	}
+	want := Review{
+		ID:        "3d2b9f0e-8a1c-4b6e-9f7d-2c5a1e8b4d60",
+		Author:    "Ana Souza",
+		Content:   "Best companion app for our weekly sessions.\nThe dice roller is great!",
+		Rating:    5,
+		UpdatedAt: time.Date(2025, 10, 18, 0, 0, 0, 0, time.UTC),
+	}
+	if page.Reviews[0] != want {
+		t.Errorf("got %+v, want %+v", page.Reviews[0], want)
+	}
+	if page.Reviews[2].Content != "" || page.Reviews[2].Rating != 4 {
+		t.Errorf("got %+v, want a 4 stars review without content", page.Reviews[2])
+	}
+	if page.NextToken == "" {
+		t.Fatal("got no next token")
+	}
+
+	next, err := newFixturesClient().GetLatestReviews(context.Background(), testPackageName, "us", page.NextToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next.Reviews) != 2 || next.Reviews[0].ID == page.Reviews[0].ID || next.NextToken != "" {
+		t.Errorf("got %d reviews and token %q on the next page, want 2 other reviews on the last page", len(next.Reviews), next.NextToken)
+	}
+
+	empty, err := newFixturesClient().GetLatestReviews(context.Background(), "com.example.empty", "us", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(empty.Reviews) != 0 || empty.NextToken != "" {
+		t.Errorf("got %+v for an app without reviews, want no reviews", empty)
+	}
+}
+
+func TestParseReviewsPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		reviews int
+		token   string
+		err     error
+	}{
+		{
+			name:    "last page",
+			payload: `)]}'` + "\n\n" + `[["wrb.fr","UsvDTd","[[[\"id\",[\"Author\"],3,null,\"Fine\",[1760745600,0]]],null]",null,null,null,"generic"],["di",40]]`,
+			reviews: 1,
+		},
+		{
+			name:    "next page",
+			payload: `)]}'` + "\n\n" + `[["wrb.fr","UsvDTd","[[[\"id\",[\"Author\"],3,null,\"Fine\",[1760745600,0]]],[null,\"token\"]]",null,null,null,"generic"]]`,
+			reviews: 1,
+			token:   "token",
+		},
+		{
+			name:    "app without reviews",
+			payload: `)]}'` + "\n\n" + `[["wrb.fr","UsvDTd",null,null,null,null,"generic"],["di",40]]`,
+		},
+		{
+			name:    "other calls only",
+			payload: `)]}'` + "\n\n" + `[["di",40],["af.httprm",39,"-1",14]]`,
+		},
+		{
+			name:    "review without date",
+			payload: `)]}'` + "\n\n" + `[["wrb.fr","UsvDTd","[[[\"id\",[\"Author\"],3,null,\"Fine\"]]]",null,null,null,"generic"]]`,
+			err:     ErrInvalidPayload,
+		},
+		{
+			name:    "not JSON",
+			payload: `<html>Error 400 (Bad Request)</html>`,
+			err:     ErrInvalidPayload,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := ParseReviewsPayload([]byte(tt.payload))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if len(page.Reviews) != tt.reviews || page.NextToken != tt.token {
+				t.Errorf("got %d reviews and token %q, want %d and %q", len(page.Reviews), page.NextToken, tt.reviews, tt.token)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package googleplay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	GooglePlayReviewsURLFmt = "https://play.google.com/_/PlayStoreUi/data/batchexecute?hl=%s&gl=%s"
+
+	// reviewsRPC is the id of the remote procedure returning the reviews of an app.
+	reviewsRPC = "UsvDTd"
+	// sortNewest sorts the reviews by most recent first.
+	sortNewest = 2
+	// ReviewsPageSize is the number of reviews of a page.
+	ReviewsPageSize = 100
+)
+
+var ErrInvalidPayload = errors.New("invalid reviews payload")
+
+// responsePrefix guards the store responses against JSON hijacking, it precedes the payload.
+var responsePrefix = []byte(")]}'")
+
+type Review struct {
+	ID        string
+	Author    string
+	Content   string
+	Rating    int
+	UpdatedAt time.Time
+}
+
+// ReviewsPage is a page of reviews, most recent first.
+// NextToken fetches the next page, it is empty on the last page.
+type ReviewsPage struct {
+	Reviews   []Review
+	NextToken string
+}
+
+// GetLatestReviews returns a page of the latest reviews for a given package name on the given country storefront.
+// The token is the NextToken of the previous page, empty for the first page.
+func (c *GooglePlayClient) GetLatestReviews(ctx context.Context, packageName string, country string, token string) (ReviewsPage, error) {
+	body, err := reviewsRequestBody(packageName, token)
+	if err != nil {
+		return ReviewsPage{}, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(GooglePlayReviewsURLFmt, DefaultLanguage, country),
+		bytes.NewBufferString(body))
+	if err != nil {
+		return ReviewsPage{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=UTF-8")
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return ReviewsPage{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return ReviewsPage{}, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	payload, err := io.ReadAll(response.Body)
+	if err != nil {
+		return ReviewsPage{}, err
+	}
+
+	return ParseReviewsPayload(payload)
+}
+
+// reviewsRequestBody builds the form of the reviews remote procedure call.
+func reviewsRequestBody(packageName string, token string) (string, error) {
+	rawToken := []byte("null")
+	if token != "" {
+		rawToken, _ = json.Marshal(token)
+	}
+	rawPackageName, _ := json.Marshal(packageName)
+
+	args := fmt.Sprintf(`[null,null,[2,%d,[%d,null,%s],null,[null,null]],[%s,7]]`, sortNewest, ReviewsPageSize, rawToken, rawPackageName)
+	request, err := json.Marshal([][][]any{{{reviewsRPC, args, nil, "generic"}}})
+	if err != nil {
+		return "", err
+	}
+
+	return url.Values{"f.req": {string(request)}}.Encode(), nil
+}
+
+// ParseReviewsPayload parses the response of the reviews remote procedure call.
+// The response is a list of envelopes, the one of the call holds the reviews as a JSON string of nested arrays,
+// the list of reviews followed by [null, "next token"] if there are more pages,
+// where each review is [id, [author, ...], rating, null, content, [updated seconds, nanos], ...].
+func ParseReviewsPayload(payload []byte) (ReviewsPage, error) {
+	payload = bytes.TrimPrefix(bytes.TrimSpace(payload), responsePrefix)
+
+	var envelopes [][]any
+	if err := json.Unmarshal(payload, &envelopes); err != nil {
+		return ReviewsPage{}, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	var data []any
+	for _, envelope := range envelopes {
+		if len(envelope) < 3 || envelope[1] != reviewsRPC {
+			continue
+		}
+		raw, ok := envelope[2].(string)
+		if !ok {
+			// apps without reviews have no data
+			return ReviewsPage{}, nil
+		}
+		if err := json.Unmarshal([]byte(raw), &data); err != nil {
+			return ReviewsPage{}, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+		}
+		break
+	}
+
+	page := ReviewsPage{}
+	if len(data) == 0 {
+		return page, nil
+	}
+
+	entries, _ := data[0].([]any)
+	for _, entry := range entries {
+		review, err := parseReview(entry)
+		if err != nil {
+			return ReviewsPage{}, err
+		}
+		page.Reviews = append(page.Reviews, review)
+	}
+
+	for _, value := range data[1:] {
+		if token, ok := at(value, -1).(string); ok {
+			page.NextToken = token
+			break
+		}
+	}
+
+	return page, nil
+}
+
+// parseReview parses a review of the reviews payload.
+func parseReview(entry any) (Review, error) {
+	id, ok := at(entry, 0).(string)
+	if !ok {
+		return Review{}, fmt.Errorf("%w: review without id", ErrInvalidPayload)
+	}
+
+	rating, ok := at(entry, 2).(float64)
+	if !ok {
+		return Review{}, fmt.Errorf("%w: review %s without rating", ErrInvalidPayload, id)
+	}
+
+	seconds, ok := at(at(entry, 5), 0).(float64)
+	if !ok {
+		return Review{}, fmt.Errorf("%w: review %s without date", ErrInvalidPayload, id)
+	}
+
+	author, _ := at(at(entry, 1), 0).(string)
+	content, _ := at(entry, 4).(string)
+
+	return Review{
+		ID:        id,
+		Author:    author,
+		Content:   content,
+		Rating:    int(rating),
+		UpdatedAt: time.Unix(int64(seconds), 0).UTC(),
+	}, nil
+}
+
+// at returns the element at the index of a decoded JSON array, negative indexes count from the end.
+// It returns nil if the value is not an array or the index is out of range.
+func at(value any, index int) any {
+	array, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	if index < 0 {
+		index += len(array)
+	}
+	if index < 0 || index >= len(array) {
+		return nil
+	}
+	return array[index]
+}
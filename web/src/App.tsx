@@ -1,20 +1,72 @@
-import { useQuery } from "@tanstack/react-query";
+import { useQuery, useQueryClient } from "@tanstack/react-query";
 import React from "react";
 import { App as AppComponent } from "~/components/App";
 import { Button } from "~/components/Button";
 import { Input } from "~/components/Input";
+import { Login } from "~/components/Login";
 import { Message } from "~/components/Message";
 import { Review } from "~/components/Review";
 import { isHttpError } from "~/lib/http/http-error";
-import { addApp, fetchApps } from "~/lib/server/apps";
-import { fetchReviews } from "~/lib/server/reviews";
+import {
+  addApp,
+  fetchApps,
+  isAppReference,
+  searchApps,
+} from "~/lib/server/apps";
+import { canEditApps, logout, whoami, type Whoami } from "~/lib/server/auth";
+import {
+  fetchReviews,
+  subscribeToReviews,
+  type ReviewsResponse,
+} from "~/lib/server/reviews";
 import "./App.css";
 
 function App() {
+  const queryClient = useQueryClient();
+  const {
+    data: whoamiResponse,
+    isLoading: whoamiLoading,
+    refetch: refetchWhoami,
+  } = useQuery({
+    queryKey: ["whoami"],
+    queryFn: whoami,
+    staleTime: Infinity,
+    retry: false,
+  });
+
+  const handleLogout = async () => {
+    await logout();
+    queryClient.clear();
+    refetchWhoami();
+  };
+
+  if (whoamiLoading) {
+    return <div className="app">Loading...</div>;
+  }
+
+  if (!whoamiResponse) {
+    return (
+      <div className="app">
+        <Login onLoggedIn={() => refetchWhoami()} />
+      </div>
+    );
+  }
+
+  return <Dashboard who={whoamiResponse.data} onLogout={handleLogout} />;
+}
+
+interface DashboardProps {
+  who: Whoami;
+  onLogout: () => void;
+}
+
+function Dashboard({ who, onLogout }: DashboardProps) {
+  const queryClient = useQueryClient();
   const [selectedAppId, setSelectedAppId] = React.useState("");
   const [newAppId, setNewAppId] = React.useState("");
   const [isAddingApp, setIsAddingApp] = React.useState(false);
   const [addError, setAddError] = React.useState<string | null>(null);
+  const [searchTerm, setSearchTerm] = React.useState("");
 
   const {
     data: appsResponse,
@@ -28,6 +80,14 @@ function App() {
     retry: false,
   });
 
+  const { data: searchResults, isFetching: searching } = useQuery({
+    queryKey: ["app-search", searchTerm],
+    queryFn: () => searchApps(searchTerm),
+    enabled: !!searchTerm,
+    staleTime: 5 * 60 * 1000, // 5 minutes
+    retry: false,
+  });
+
   const {
     data: reviews,
     error: reviewsError,
@@ -40,6 +100,22 @@ function App() {
     retry: false,
   });
 
+  // prepend the reviews stored while the app is selected, instead of polling for them
+  React.useEffect(() => {
+    if (!selectedAppId) return;
+
+    return subscribeToReviews(selectedAppId, (review) => {
+      queryClient.setQueryData<ReviewsResponse>(
+        ["reviews", selectedAppId],
+        (previous) =>
+          previous && {
+            ...previous,
+            data: [review, ...previous.data.filter((r) => r.id !== review.id)],
+          }
+      );
+    });
+  }, [queryClient, selectedAppId]);
+
   const handleAppSelect = (appId: string) => {
     setSelectedAppId(appId);
   };
@@ -48,18 +124,30 @@ function App() {
     e.preventDefault();
     setAddError(null);
 
-    if (!newAppId.trim()) return;
+    const value = newAppId.trim();
+    if (!value) return;
+
+    // anything but an ID, a URL or a bundle ID is the name of the app
+    if (!isAppReference(value)) {
+      setSearchTerm(value);
+      return;
+    }
 
+    await add(value);
+  };
+
+  const add = async (app: string) => {
     setIsAddingApp(true);
     try {
-      await addApp(newAppId.trim());
+      await addApp(app);
       setNewAppId("");
+      setSearchTerm("");
       refetchApps(); // Refresh the apps list
     } catch (error) {
       if (isHttpError(error)) {
         if (error.status === 400) {
           setAddError(
-            "Invalid App ID. Please enter a valid numeric App Store ID."
+            "Invalid app. Please enter an App Store ID, an apps.apple.com URL or a bundle ID."
           );
         } else if (error.status === 404) {
           setAddError("App not found. Please check the App ID and try again.");
@@ -83,6 +171,15 @@ function App() {
         <div className="header">
           <h1>Apps Reviews</h1>
           <p className="subtitle">Select an app to view its recent reviews</p>
+          {who.user && (
+            <p className="header__user">
+              Logged in as {who.user.username} ({who.user.role})
+              {who.workspace && ` in ${who.workspace.name}`}
+              <Button onClick={onLogout} variant="link">
+                Log Out
+              </Button>
+            </p>
+          )}
         </div>
 
         <div className="apps-list">
@@ -126,34 +223,76 @@ function App() {
             </div>
           )}
 
-          <div className="apps-list__add-app">
-            <h3>Add New App</h3>
-            <form onSubmit={handleAddApp} className="add-app-form">
-              <div className="input-group">
-                <Input
-                  type="text"
-                  value={newAppId}
-                  onChange={(e) => setNewAppId(e.target.value)}
-                  placeholder="Enter App Store App ID (e.g., 1234567890)"
-                  disabled={isAddingApp}
-                  required
-                />
-                <Button
-                  type="submit"
-                  disabled={isAddingApp || !newAppId.trim()}
-                >
-                  {isAddingApp ? "Adding..." : "Add App"}
-                </Button>
-              </div>
-            </form>
-            {addError && (
-              <div className="add-app-form__error">
-                <Message type="error">
-                  <span>{addError}</span>
-                </Message>
-              </div>
-            )}
-          </div>
+          {canEditApps(who) && (
+            <div className="apps-list__add-app">
+              <h3>Add New App</h3>
+              <form onSubmit={handleAddApp} className="add-app-form">
+                <div className="input-group">
+                  <Input
+                    type="text"
+                    value={newAppId}
+                    onChange={(e) => setNewAppId(e.target.value)}
+                    placeholder="Search by name, or enter an App Store ID, URL or bundle ID"
+                    disabled={isAddingApp}
+                    required
+                  />
+                  <Button
+                    type="submit"
+                    disabled={isAddingApp || !newAppId.trim()}
+                  >
+                    {isAddingApp
+                      ? "Adding..."
+                      : newAppId.trim() && !isAppReference(newAppId.trim())
+                        ? "Search"
+                        : "Add App"}
+                  </Button>
+                </div>
+              </form>
+              {searchTerm && (
+                <div className="add-app-form__results">
+                  {searching ? (
+                    <p>Searching the App Store...</p>
+                  ) : searchResults?.data.length === 0 ? (
+                    <p>No apps found for "{searchTerm}".</p>
+                  ) : (
+                    searchResults?.data.map((result) => {
+                      const added = apps.some((app) => app.id === result.id);
+                      return (
+                        <div key={result.id} className="search-result">
+                          <img
+                            src={result.icon_url}
+                            alt={result.name}
+                            className="search-result__icon"
+                          />
+                          <div className="search-result__content">
+                            <p className="search-result__name">{result.name}</p>
+                            <p className="search-result__details">
+                              {result.developer}
+                              {result.rating_count > 0 &&
+                                ` · ${result.rating.toFixed(1)}★ (${result.rating_count})`}
+                            </p>
+                          </div>
+                          <Button
+                            onClick={() => add(result.id)}
+                            disabled={isAddingApp || added}
+                          >
+                            {added ? "Added" : "Add"}
+                          </Button>
+                        </div>
+                      );
+                    })
+                  )}
+                </div>
+              )}
+              {addError && (
+                <div className="add-app-form__error">
+                  <Message type="error">
+                    <span>{addError}</span>
+                  </Message>
+                </div>
+              )}
+            </div>
+          )}
         </div>
 
         {reviewsError && (
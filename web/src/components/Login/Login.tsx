@@ -0,0 +1,69 @@
+import React from "react";
+import { Button } from "~/components/Button";
+import { Input } from "~/components/Input";
+import { Message } from "~/components/Message";
+import { isHttpError } from "~/lib/http/http-error";
+import { login } from "~/lib/server/auth";
+import "./Login.css";
+
+interface LoginProps {
+  onLoggedIn: () => void;
+}
+
+export const Login: React.FC<LoginProps> = ({ onLoggedIn }) => {
+  const [username, setUsername] = React.useState("");
+  const [password, setPassword] = React.useState("");
+  const [isLoggingIn, setIsLoggingIn] = React.useState(false);
+  const [error, setError] = React.useState<string | null>(null);
+
+  const handleSubmit = async (e: React.FormEvent<HTMLFormElement>) => {
+    e.preventDefault();
+    setError(null);
+    setIsLoggingIn(true);
+    try {
+      await login(username.trim(), password);
+      onLoggedIn();
+    } catch (error) {
+      if (isHttpError(error) && error.status === 401) {
+        setError("Invalid username or password.");
+      } else {
+        setError("Failed to log in. Please try again.");
+      }
+    } finally {
+      setIsLoggingIn(false);
+    }
+  };
+
+  return (
+    <form onSubmit={handleSubmit} className="login">
+      <h1>Apps Reviews</h1>
+      <p className="login__subtitle">Log in to view the app reviews</p>
+      <Input
+        type="text"
+        value={username}
+        onChange={(e) => setUsername(e.target.value)}
+        placeholder="Username"
+        autoComplete="username"
+        disabled={isLoggingIn}
+        required
+      />
+      <Input
+        type="password"
+        value={password}
+        onChange={(e) => setPassword(e.target.value)}
+        placeholder="Password"
+        autoComplete="current-password"
+        disabled={isLoggingIn}
+        required
+      />
+      <Button type="submit" disabled={isLoggingIn || !username.trim()}>
+        {isLoggingIn ? "Logging in..." : "Log In"}
+      </Button>
+      {error && (
+        <Message type="error">
+          <span>{error}</span>
+        </Message>
+      )}
+    </form>
+  );
+};
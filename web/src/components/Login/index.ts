@@ -0,0 +1 @@
+export { Login } from "./Login";
@@ -4,11 +4,30 @@ import { HttpError } from "~/lib/http/http-error";
  * A "Typed" fetch implementation of Http.
  */
 
+/**
+ * The CSRF token of the session, sent back with the requests changing anything.
+ * The requests are authenticated with the session cookie of the logged in user,
+ * an API key would be readable by anyone loading the app.
+ */
+let csrfToken: string | undefined;
+
+const safeMethods = ["GET", "HEAD", "OPTIONS"];
+
+export const setCsrfToken = (token: string | undefined) => {
+  csrfToken = token;
+};
+
 export const execute = async <T>(req: Request): Promise<T> => {
+  if (csrfToken && !safeMethods.includes(req.method)) {
+    req.headers.set("X-CSRF-Token", csrfToken);
+  }
   const response = await fetch(req);
   if (!response.ok) {
     throw new HttpError(response.status, response.statusText);
   }
+  if (response.status === 204) {
+    return undefined as T;
+  }
   try {
     const asJson = await response.json();
     return asJson as T;
@@ -20,3 +39,18 @@ export const execute = async <T>(req: Request): Promise<T> => {
 export const get = async <T>(url: string, opts?: RequestInit): Promise<T> => {
   return execute<T>(new Request(url, { ...opts, method: "GET" }));
 };
+
+export const post = async <T>(
+  url: string,
+  body?: unknown,
+  opts?: RequestInit
+): Promise<T> => {
+  return execute<T>(
+    new Request(url, {
+      ...opts,
+      method: "POST",
+      headers: { "Content-Type": "application/json", ...opts?.headers },
+      body: body === undefined ? undefined : JSON.stringify(body),
+    })
+  );
+};
@@ -2,12 +2,38 @@ import * as HttpClient from "~/lib/http/typed-fetch";
 
 export type App = {
   id: string;
+  workspace_id: number;
+  platform: "ios" | "android";
   name: string;
   thumbnail_url: string;
+  countries: string[];
+  status: "active" | "paused" | "archived";
+  polling_interval: string | null;
+  adaptive_polling: boolean;
+  next_poll_at: string | null;
+  last_polled_at: string | null;
+  added_by: string;
+  status_updated_by: string;
   created_at: string;
   updated_at: string;
 };
 
+export type AppSearchResult = {
+  id: string;
+  platform: "ios" | "android";
+  name: string;
+  bundle_id: string;
+  developer: string;
+  icon_url: string;
+  store_url: string;
+  rating: number;
+  rating_count: number;
+};
+
+export type AppSearchResponse = {
+  data: AppSearchResult[];
+};
+
 export type AppsResponse = {
   data: App[];
 };
@@ -24,8 +50,27 @@ export const fetchApp = async (appId: string): Promise<AppResponse> => {
   return await HttpClient.get<AppResponse>(`/api/apps/${appId}`);
 };
 
-export const addApp = async (appId: string): Promise<string> => {
-  return await HttpClient.execute<string>(
-    new Request(`/api/apps/${appId}`, { method: "POST" })
+export const searchApps = async (
+  term: string
+): Promise<AppSearchResponse> => {
+  return await HttpClient.get<AppSearchResponse>(
+    `/api/apps/search?q=${encodeURIComponent(term)}&limit=10`
   );
 };
+
+/**
+ * Adds an app by its App Store ID, apps.apple.com URL or bundle ID.
+ */
+export const addApp = async (app: string): Promise<string> => {
+  return await HttpClient.post<string>(
+    `/api/apps?app=${encodeURIComponent(app)}`
+  );
+};
+
+/**
+ * Returns true if the value is an App Store ID, an apps.apple.com URL or a bundle ID,
+ * rather than a name to search the App Store for.
+ */
+export const isAppReference = (value: string): boolean => {
+  return /^\d+$/.test(value) || /^[^\s]+[./][^\s]+$/.test(value);
+};
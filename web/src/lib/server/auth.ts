@@ -0,0 +1,60 @@
+import * as HttpClient from "~/lib/http/typed-fetch";
+
+export type Role = "viewer" | "editor" | "admin";
+
+export type Workspace = {
+  id: number;
+  name: string;
+  created_at: string;
+};
+
+export type User = {
+  id: number;
+  workspace_id: number;
+  username: string;
+  role: Role;
+  created_at: string;
+  updated_at: string;
+  last_login_at: string | null;
+};
+
+export type Whoami = {
+  user?: User;
+  api_key?: { id: number; workspace_id: number; name: string; scopes: string[] };
+  workspace?: Workspace;
+  csrf_token?: string;
+};
+
+export type WhoamiResponse = {
+  data: Whoami;
+};
+
+export const login = async (
+  username: string,
+  password: string
+): Promise<WhoamiResponse> => {
+  const response = await HttpClient.post<WhoamiResponse>("/api/auth/login", {
+    username,
+    password,
+  });
+  HttpClient.setCsrfToken(response.data.csrf_token);
+  return response;
+};
+
+export const logout = async (): Promise<void> => {
+  await HttpClient.post<void>("/api/auth/logout");
+  HttpClient.setCsrfToken(undefined);
+};
+
+export const whoami = async (): Promise<WhoamiResponse> => {
+  const response = await HttpClient.get<WhoamiResponse>("/api/auth/whoami");
+  HttpClient.setCsrfToken(response.data.csrf_token);
+  return response;
+};
+
+/**
+ * Returns true if the logged in user can add, pause and delete apps.
+ */
+export const canEditApps = (who: Whoami): boolean => {
+  return !!who.user && who.user.role !== "viewer";
+};
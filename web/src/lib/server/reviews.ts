@@ -1,7 +1,8 @@
 import * as HttpClient from "~/lib/http/typed-fetch";
 
-type Review = {
+export type Review = {
   id: string;
+  country: string;
   author: string;
   title: string;
   content: string;
@@ -9,10 +10,28 @@ type Review = {
   sent_at: string;
 };
 
-type ReviewsResponse = {
+export type ReviewsResponse = {
   data: Review[];
 };
 
 export const fetchReviews = async (appId: string): Promise<ReviewsResponse> => {
   return await HttpClient.get<ReviewsResponse>(`/api/reviews/${appId}`);
 };
+
+/**
+ * Calls onReview with every review of the app stored from now on, until the returned function is called.
+ * The browser reconnects the stream on its own, resuming after the last review it received.
+ * EventSource cannot send headers, so the stream is authenticated with the session cookie only.
+ */
+export const subscribeToReviews = (
+  appId: string,
+  onReview: (review: Review) => void
+): (() => void) => {
+  const source = new EventSource(`/api/reviews/${appId}/stream`, {
+    withCredentials: true,
+  });
+  source.addEventListener("review", (event) => {
+    onReview(JSON.parse(event.data) as Review);
+  });
+  return () => source.close();
+};